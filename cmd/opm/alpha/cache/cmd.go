@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/operator-framework/operator-registry/pkg/lib/serve"
+)
+
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage precomputed catalog caches",
+	}
+	cmd.AddCommand(newBuildCmd())
+	return cmd
+}
+
+func newBuildCmd() *cobra.Command {
+	var cacheDir string
+	logger := logrus.New()
+	cmd := &cobra.Command{
+		Use:   "build <sqlite_file | config_dir>",
+		Short: "Build a cache of a catalog for fast serve startup",
+		Long: `Build a cache of a catalog for fast serve startup
+
+The catalog is converted to the form it is served in and written to the cache directory,
+keyed by the digest of the catalog's content, along with the digest of the source it was
+built from. When 'opm alpha serve' is given the same --cache-dir, it loads the cache instead
+of parsing the source, as long as the source is unchanged; a sqlite database is only served
+from its cache with --in-memory. The catalog's digest is printed.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dgst, err := serve.BuildCache(cmd.Context(), args[0], cacheDir, logrus.NewEntry(logger))
+			if err != nil {
+				return err
+			}
+			fmt.Println(dgst)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&cacheDir, "cache-dir", "", "directory to write the cache to")
+	if err := cmd.MarkFlagRequired("cache-dir"); err != nil {
+		logger.Fatalf("Failed to mark `cache-dir` flag for `build` subcommand as required")
+	}
+	return cmd
+}
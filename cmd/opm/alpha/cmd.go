@@ -4,10 +4,13 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/operator-framework/operator-registry/cmd/opm/alpha/bundle"
+	"github.com/operator-framework/operator-registry/cmd/opm/alpha/cache"
+	"github.com/operator-framework/operator-registry/cmd/opm/alpha/federate"
 	initcmd "github.com/operator-framework/operator-registry/cmd/opm/alpha/init"
+	"github.com/operator-framework/operator-registry/cmd/opm/alpha/query"
 	"github.com/operator-framework/operator-registry/cmd/opm/alpha/render"
-	"github.com/operator-framework/operator-registry/cmd/opm/alpha/serve"
 	"github.com/operator-framework/operator-registry/cmd/opm/alpha/validate"
+	"github.com/operator-framework/operator-registry/cmd/opm/serve"
 )
 
 func NewCmd() *cobra.Command {
@@ -17,6 +20,6 @@ func NewCmd() *cobra.Command {
 		Short:  "Run an alpha subcommand",
 	}
 
-	runCmd.AddCommand(bundle.NewCmd(), initcmd.NewCmd(), serve.NewCmd(), render.NewCmd(), validate.NewCmd())
+	runCmd.AddCommand(bundle.NewCmd(), initcmd.NewCmd(), serve.NewCmd(), federate.NewCmd(), render.NewCmd(), validate.NewCmd(), cache.NewCmd(), query.NewCmd())
 	return runCmd
 }
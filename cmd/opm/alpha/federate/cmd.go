@@ -0,0 +1,196 @@
+package federate
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+	health "github.com/operator-framework/operator-registry/pkg/api/grpc_health_v1"
+	"github.com/operator-framework/operator-registry/pkg/client"
+	"github.com/operator-framework/operator-registry/pkg/federation"
+	"github.com/operator-framework/operator-registry/pkg/lib/certs"
+	"github.com/operator-framework/operator-registry/pkg/lib/dns"
+	"github.com/operator-framework/operator-registry/pkg/lib/graceful"
+	"github.com/operator-framework/operator-registry/pkg/lib/log"
+	"github.com/operator-framework/operator-registry/pkg/server"
+)
+
+type federate struct {
+	upstreams       []string
+	refreshInterval time.Duration
+
+	port           string
+	terminationLog string
+	debug          bool
+
+	tlsCert     string
+	tlsKey      string
+	tlsClientCA string
+	httpPort    string
+
+	upstreamCA   string
+	upstreamCert string
+	upstreamKey  string
+
+	logger *logrus.Entry
+}
+
+func NewCmd() *cobra.Command {
+	logger := logrus.New()
+	f := federate{
+		logger: logrus.NewEntry(logger),
+	}
+	cmd := &cobra.Command{
+		Use:   "federate --upstream <address> [--upstream <address>...]",
+		Short: "serve the catalogs of several registry servers as one",
+		Long: `serve the catalogs of several registry servers as one via grpc
+
+Upstreams are given in priority order. A package present in several upstreams is served
+entirely by the first healthy upstream that has it. The health of each upstream is reported
+by the grpc health service under the upstream's address.`,
+		Args: cobra.NoArgs,
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			if len(f.upstreams) == 0 {
+				return fmt.Errorf("at least one --upstream is required")
+			}
+			if f.debug {
+				logger.SetLevel(logrus.DebugLevel)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return f.run(cmd.Context())
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&f.upstreams, "upstream", nil, "address of an upstream registry server, in priority order")
+	cmd.Flags().DurationVar(&f.refreshInterval, "refresh-interval", 30*time.Second, "interval at which to check upstream health and refresh their package lists")
+	cmd.Flags().BoolVar(&f.debug, "debug", false, "enable debug logging")
+	cmd.Flags().StringVarP(&f.port, "port", "p", "50051", "port number to serve on")
+	cmd.Flags().StringVarP(&f.terminationLog, "termination-log", "t", "/dev/termination-log", "path to a container termination log file")
+	cmd.Flags().StringVar(&f.tlsCert, "tls-cert", "", "path to a PEM-encoded certificate to serve TLS with, reloaded on change")
+	cmd.Flags().StringVar(&f.tlsKey, "tls-key", "", "path to the PEM-encoded private key for --tls-cert, reloaded on change")
+	cmd.Flags().StringVar(&f.tlsClientCA, "tls-client-ca", "", "path to a PEM-encoded CA bundle used to require and verify client certificates (mutual TLS)")
+	cmd.Flags().StringVar(&f.httpPort, "http-port", "", "port number to serve the HTTP/JSON gateway on, disabled if unset")
+	cmd.Flags().StringVar(&f.upstreamCA, "upstream-ca", "", "path to a PEM-encoded CA bundle used to verify upstreams, which enables TLS to upstreams")
+	cmd.Flags().StringVar(&f.upstreamCert, "upstream-cert", "", "path to a PEM-encoded client certificate presented to upstreams, which enables TLS to upstreams")
+	cmd.Flags().StringVar(&f.upstreamKey, "upstream-key", "", "path to the PEM-encoded private key for --upstream-cert")
+	return cmd
+}
+
+func (f *federate) run(ctx context.Context) error {
+	// Immediately set up termination log
+	err := log.AddDefaultWriterHooks(f.terminationLog)
+	if err != nil {
+		f.logger.WithError(err).Warn("unable to set termination log path")
+	}
+
+	// Ensure there is a default nsswitch config
+	if err := dns.EnsureNsswitch(); err != nil {
+		f.logger.WithError(err).Warn("unable to write default nsswitch config")
+	}
+
+	f.logger = f.logger.WithFields(logrus.Fields{"upstreams": f.upstreams, "port": f.port})
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	upstreams, err := f.dialUpstreams()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for _, u := range upstreams {
+			u.Client.Close()
+		}
+	}()
+	store := federation.NewQuerier(upstreams...)
+
+	tlsConfig, err := server.TLSConfig(f.tlsCert, f.tlsKey, f.tlsClientCA)
+	if err != nil {
+		return err
+	}
+
+	lis, err := net.Listen("tcp", ":"+f.port)
+	if err != nil {
+		f.logger.Fatalf("failed to listen: %s", err)
+	}
+
+	grpcServer := grpc.NewServer(append(server.TLSServerOptions(tlsConfig), server.TracingServerOptions()...)...)
+	api.RegisterRegistryServer(grpcServer, server.NewRegistryServer(store))
+	health.RegisterHealthServer(grpcServer, server.NewStoreHealthServer(store))
+	reflection.Register(grpcServer)
+
+	var gateway *server.Gateway
+	if len(f.httpPort) > 0 {
+		gateway, err = server.NewGateway(":"+f.httpPort, store, tlsConfig, server.TracingInterceptors())
+		if err != nil {
+			f.logger.Fatalf("failed to listen: %s", err)
+		}
+		f.logger.WithField("http-port", f.httpPort).Info("serving http gateway")
+	}
+
+	f.logger.Info("serving federated registry")
+	return graceful.Shutdown(f.logger, func() error {
+		var g errgroup.Group
+		g.Go(func() error {
+			return grpcServer.Serve(lis)
+		})
+		if gateway != nil {
+			g.Go(gateway.Serve)
+		}
+		g.Go(func() error {
+			store.Refresh(ctx)
+			if err := store.Ready(); err != nil {
+				f.logger.WithError(err).Warn("no upstream is serving")
+			}
+			store.Watch(ctx, f.refreshInterval)
+			return nil
+		})
+		return g.Wait()
+	}, func() {
+		cancel()
+		if gateway != nil {
+			gateway.GracefulStop()
+		}
+		grpcServer.GracefulStop()
+	})
+}
+
+func (f *federate) dialUpstreams() ([]federation.Upstream, error) {
+	var tlsConfig *tls.Config
+	if f.upstreamCA != "" || f.upstreamCert != "" || f.upstreamKey != "" {
+		var err error
+		tlsConfig, err = certs.ClientTLSConfig(f.upstreamCA, f.upstreamCert, f.upstreamKey, false)
+		if err != nil {
+			return nil, fmt.Errorf("upstream tls config: %v", err)
+		}
+	}
+
+	var upstreams []federation.Upstream
+	for _, address := range f.upstreams {
+		var (
+			c   *client.Client
+			err error
+		)
+		if tlsConfig != nil {
+			c, err = client.NewTLSClient(address, tlsConfig)
+		} else {
+			c, err = client.NewClient(address)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("dial upstream %s: %v", address, err)
+		}
+		upstreams = append(upstreams, federation.Upstream{Name: address, Client: c})
+	}
+	return upstreams, nil
+}
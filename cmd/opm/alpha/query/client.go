@@ -0,0 +1,133 @@
+package query
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"sigs.k8s.io/yaml"
+
+	"github.com/operator-framework/operator-registry/pkg/client"
+	"github.com/operator-framework/operator-registry/pkg/lib/certs"
+)
+
+// clientOptions are the flags of the commands querying a registry server.
+type clientOptions struct {
+	output  string
+	timeout time.Duration
+
+	tls                   bool
+	tlsCA                 string
+	tlsCert               string
+	tlsKey                string
+	tlsInsecureSkipVerify bool
+}
+
+func (o *clientOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.output, "output", "o", "table", "output format (table|json|yaml)")
+	cmd.Flags().DurationVar(&o.timeout, "timeout", 30*time.Second, "time to wait for the server to answer")
+	cmd.Flags().BoolVar(&o.tls, "tls", false, "connect to the server with TLS, verified with the system's CAs unless --tls-ca is set")
+	cmd.Flags().StringVar(&o.tlsCA, "tls-ca", "", "path to a PEM-encoded CA bundle used to verify the server, which enables TLS")
+	cmd.Flags().StringVar(&o.tlsCert, "tls-cert", "", "path to a PEM-encoded client certificate presented to the server, which enables TLS")
+	cmd.Flags().StringVar(&o.tlsKey, "tls-key", "", "path to the PEM-encoded private key for --tls-cert")
+	cmd.Flags().BoolVar(&o.tlsInsecureSkipVerify, "tls-insecure-skip-verify", false, "skip verifying the server's certificate, which enables TLS")
+}
+
+// validate checks the flags before connecting, so that invalid flags are reported with the command's usage.
+func (o *clientOptions) validate() error {
+	switch o.output {
+	case "table", "json", "yaml":
+		return nil
+	default:
+		return fmt.Errorf("invalid --output value %q, expected (table|json|yaml)", o.output)
+	}
+}
+
+// run connects to the registry server at address and calls f with a client and a context that times out after
+// --timeout. Errors returned by f are not usage errors, so the command's usage isn't printed with them.
+func (o *clientOptions) run(cmd *cobra.Command, address string, f func(ctx context.Context, c *client.Client) error) error {
+	if err := o.validate(); err != nil {
+		return err
+	}
+	cmd.SilenceUsage = true
+
+	var (
+		c   *client.Client
+		err error
+	)
+	if o.tls || o.tlsCA != "" || o.tlsCert != "" || o.tlsKey != "" || o.tlsInsecureSkipVerify {
+		var tlsConfig *tls.Config
+		if tlsConfig, err = certs.ClientTLSConfig(o.tlsCA, o.tlsCert, o.tlsKey, o.tlsInsecureSkipVerify); err != nil {
+			return fmt.Errorf("tls config: %v", err)
+		}
+		c, err = client.NewTLSClient(address, tlsConfig)
+	} else {
+		c, err = client.NewClient(address)
+	}
+	if err != nil {
+		return fmt.Errorf("dial %s: %v", address, err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), o.timeout)
+	defer cancel()
+	return f(ctx, c)
+}
+
+// write writes v to out in the --output format. Tables are written by table, and JSON and YAML are the encoding
+// of v: the protobuf JSON encoding of messages and lists of messages, as served by the HTTP gateway, and the
+// encoding/json encoding of anything else.
+func (o *clientOptions) write(out io.Writer, v interface{}, table func(w io.Writer)) error {
+	if o.output == "table" {
+		w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		table(w)
+		return w.Flush()
+	}
+
+	encoded, err := encodeJSON(v)
+	if err != nil {
+		return err
+	}
+	if o.output == "yaml" {
+		if encoded, err = yaml.JSONToYAML(encoded); err != nil {
+			return err
+		}
+		_, err = out.Write(encoded)
+		return err
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, encoded, "", "    "); err != nil {
+		return err
+	}
+	indented.WriteByte('\n')
+	_, err = indented.WriteTo(out)
+	return err
+}
+
+func encodeJSON(v interface{}) ([]byte, error) {
+	if m, ok := v.(proto.Message); ok {
+		return protojson.Marshal(m)
+	}
+	// lists of messages are encoded as JSON arrays of their protobuf JSON encodings
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice && rv.Type().Elem().Implements(reflect.TypeOf((*proto.Message)(nil)).Elem()) {
+		elems := make([]json.RawMessage, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			elem, err := protojson.Marshal(rv.Index(i).Interface().(proto.Message))
+			if err != nil {
+				return nil, err
+			}
+			elems = append(elems, elem)
+		}
+		return json.Marshal(elems)
+	}
+	return json.Marshal(v)
+}
@@ -0,0 +1,28 @@
+package query
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "query",
+		Short: "Query the content of catalogs and registry servers",
+		Long: `Query the content of catalogs and registry servers
+
+The properties command reads a sqlite database or a declarative config directory. The other
+commands query a running registry server at an address, such as localhost:50051, through its
+gRPC API, whether it serves a sqlite database or declarative configs.`,
+	}
+	cmd.AddCommand(
+		newPropertiesCmd(),
+		newPackagesCmd(),
+		newPackageCmd(),
+		newBundleCmd(),
+		newBundlesCmd(),
+		newReplacementsCmd(),
+		newProvidersCmd(),
+		newHealthCmd(),
+	)
+	return cmd
+}
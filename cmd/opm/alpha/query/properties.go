@@ -0,0 +1,164 @@
+package query
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/operator-framework/operator-registry/internal/declcfg"
+	"github.com/operator-framework/operator-registry/pkg/lib/serve"
+	"github.com/operator-framework/operator-registry/pkg/lib/tmp"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+	"github.com/operator-framework/operator-registry/pkg/sqlite"
+)
+
+func newPropertiesCmd() *cobra.Command {
+	var (
+		predicate registry.PropertyPredicate
+		value     string
+		output    string
+	)
+	cmd := &cobra.Command{
+		Use:   "properties <sqlite_file | config_dir>",
+		Short: "List the bundles of a catalog with a property",
+		Long: `List the bundles of a catalog with a property
+
+Bundles are listed with their properties of the given type, optionally only those whose
+value, or the part of it selected by --path, equals --value. Paths are JSONPath expressions
+made of member and index selectors, like $.packageName or $.gvks[0].kind. A selected string
+is compared to --value as is, anything else as JSON.`,
+		Example: `  # list the bundles that can't be installed on newer OpenShift versions
+  opm alpha query properties index.db --type olm.maxOpenShiftVersion
+
+  # list the bundles providing the EtcdCluster API
+  opm alpha query properties catalog --type olm.gvk --path '$.kind' --value EtcdCluster`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var write func(io.Writer, []*registry.BundlePropertyMatch) error
+			switch output {
+			case "table":
+				write = writePropertyMatchesTable
+			case "json":
+				write = writePropertyMatchesJSON
+			default:
+				return fmt.Errorf("invalid --output value %q, expected (table|json)", output)
+			}
+			if cmd.Flags().Changed("value") {
+				predicate.Value = &value
+			}
+			if err := predicate.Validate(); err != nil {
+				return err
+			}
+
+			store, closeStore, err := openPropertyQuery(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			matches, err := store.ListBundlesWithProperty(cmd.Context(), predicate)
+			if err != nil {
+				return err
+			}
+			return write(cmd.OutOrStdout(), matches)
+		},
+	}
+	cmd.Flags().StringVar(&predicate.Type, "type", "", "type of the properties to match")
+	cmd.Flags().StringVar(&predicate.Path, "path", "", "JSONPath of the part of each property value to compare, the whole value if unset")
+	cmd.Flags().StringVar(&value, "value", "", "value to compare to, any value if unset")
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "output format (table|json)")
+	if err := cmd.MarkFlagRequired("type"); err != nil {
+		logrus.Fatalf("Failed to mark `type` flag for `properties` subcommand as required")
+	}
+	return cmd
+}
+
+// openPropertyQuery opens a migrated copy of the sqlite database, or loads the declarative config directory at source.
+func openPropertyQuery(ctx context.Context, source string) (registry.PropertyQuery, func(), error) {
+	sourceType, err := serve.DetectSource(source)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch sourceType {
+	case serve.SqliteSource:
+		// databases older than the properties table are migrated, in a copy so that the source isn't modified
+		tmpdb, err := tmp.CopyTmpDB(source)
+		if err != nil {
+			return nil, nil, err
+		}
+		cleanup := func() { os.Remove(tmpdb) }
+		db, err := sqlite.Open(tmpdb)
+		if err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+		cleanup = func() {
+			db.Close()
+			os.Remove(tmpdb)
+		}
+		migrator, err := sqlite.NewSQLLiteMigrator(db)
+		if err == nil {
+			err = migrator.Migrate(ctx)
+		}
+		if err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+		return sqlite.NewSQLLiteQuerierFromDb(db), cleanup, nil
+	case serve.DeclarativeConfigSource:
+		cfg, err := declcfg.LoadFS(ctx, os.DirFS(source))
+		if err != nil {
+			return nil, nil, fmt.Errorf("load declarative config directory: %v", err)
+		}
+		m, err := declcfg.ConvertToModel(ctx, *cfg)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not build index model from declarative config: %v", err)
+		}
+		return registry.NewQuerier(m), func() {}, nil
+	default:
+		return nil, nil, fmt.Errorf("%s sources are not supported, render %q first", sourceType, source)
+	}
+}
+
+func writePropertyMatchesTable(out io.Writer, matches []*registry.BundlePropertyMatch) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "PACKAGE\tBUNDLE\tVERSION\tVALUE")
+	for _, m := range matches {
+		for _, p := range m.Properties {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", m.PackageName, m.CsvName, m.Version, p.Value)
+		}
+	}
+	return w.Flush()
+}
+
+func writePropertyMatchesJSON(out io.Writer, matches []*registry.BundlePropertyMatch) error {
+	type property struct {
+		Type  string          `json:"type"`
+		Value json.RawMessage `json:"value"`
+	}
+	type match struct {
+		Package    string     `json:"package"`
+		Name       string     `json:"name"`
+		Version    string     `json:"version,omitempty"`
+		Image      string     `json:"image,omitempty"`
+		Properties []property `json:"properties"`
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "    ")
+	for _, m := range matches {
+		out := match{Package: m.PackageName, Name: m.CsvName, Version: m.Version, Image: m.BundlePath}
+		for _, p := range m.Properties {
+			out.Properties = append(out.Properties, property{Type: p.Type, Value: json.RawMessage(p.Value)})
+		}
+		if err := enc.Encode(out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,345 @@
+package query
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+
+	"github.com/operator-framework/operator-registry/pkg/federation"
+	"github.com/operator-framework/operator-registry/pkg/registrytest"
+	"github.com/operator-framework/operator-registry/pkg/sqlite"
+)
+
+const (
+	// testdataSqlite and testdataDeclcfg are the same catalog: package foo, whose beta channel has foo.v0.1.0
+	// replaced by foo.v0.2.0, both providing test.foo/v1 Foo
+	testdataSqlite  = "../../../../internal/action/testdata/foo-index-v0.2.0-sqlite/database/index.db"
+	testdataDeclcfg = "../../../../internal/action/testdata/foo-index-v0.2.0-declcfg/foo"
+)
+
+// migratedCopy returns the path of a copy of the sqlite testdata, migrated to the latest revision.
+func migratedCopy(t *testing.T) string {
+	content, err := ioutil.ReadFile(testdataSqlite)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "index.db")
+	require.NoError(t, ioutil.WriteFile(path, content, 0644))
+
+	db, err := sqlite.Open(path)
+	require.NoError(t, err)
+	defer db.Close()
+	migrator, err := sqlite.NewSQLLiteMigrator(db)
+	require.NoError(t, err)
+	require.NoError(t, migrator.Migrate(context.TODO()))
+	return path
+}
+
+// testServers serves the testdata catalog from a sqlite database, from declarative configs, and federated from
+// another server, and returns their addresses by kind.
+func testServers(t *testing.T) map[string]string {
+	serve := func(s *registrytest.Server, err error) (*registrytest.Server, string) {
+		require.NoError(t, err)
+		t.Cleanup(s.Close)
+		address, err := s.ListenTCP()
+		require.NoError(t, err)
+		return s, address
+	}
+
+	store, err := sqlite.NewSQLLiteQuerier(migratedCopy(t))
+	require.NoError(t, err)
+	_, sqliteAddress := serve(registrytest.NewServer(store))
+	declcfgServer, declcfgAddress := serve(registrytest.NewServerFromDir(testdataDeclcfg))
+	_, federatedAddress := serve(registrytest.NewServer(federation.NewQuerier(federation.Upstream{Name: "declcfg", Client: declcfgServer.Client})))
+
+	return map[string]string{
+		"sqlite":    sqliteAddress,
+		"declcfg":   declcfgAddress,
+		"federated": federatedAddress,
+	}
+}
+
+func runQuery(args ...string) (string, error) {
+	cmd := NewCmd()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(io.Discard)
+	cmd.SetArgs(args)
+	err := cmd.ExecuteContext(context.TODO())
+	return out.String(), err
+}
+
+// tableRows returns the fields of each line of a table after its header.
+func tableRows(table string) [][]string {
+	var rows [][]string
+	for _, line := range strings.Split(strings.TrimSpace(table), "\n")[1:] {
+		rows = append(rows, strings.Fields(line))
+	}
+	return rows
+}
+
+// tableValues returns the values of a table of names and values, by name.
+func tableValues(table string) map[string]string {
+	values := map[string]string{}
+	for _, line := range strings.Split(strings.TrimSpace(table), "\n") {
+		kv := strings.SplitN(line, ":", 2)
+		values[kv[0]] = strings.TrimSpace(kv[1])
+	}
+	return values
+}
+
+type testPackage struct {
+	Name               string `json:"name"`
+	DefaultChannelName string `json:"defaultChannelName"`
+	Channels           []struct {
+		Name    string `json:"name"`
+		CsvName string `json:"csvName"`
+	} `json:"channels"`
+}
+
+type testBundle struct {
+	CsvName     string `json:"csvName"`
+	PackageName string `json:"packageName"`
+	ChannelName string `json:"channelName"`
+	Version     string `json:"version"`
+	SkipRange   string `json:"skipRange"`
+}
+
+type testChannelEntry struct {
+	PackageName string `json:"packageName"`
+	ChannelName string `json:"channelName"`
+	BundleName  string `json:"bundleName"`
+	Replaces    string `json:"replaces"`
+}
+
+func TestServerCommands(t *testing.T) {
+	servers := testServers(t)
+
+	type spec struct {
+		name string
+		// args are the command's arguments, with an empty address that is replaced by each server's
+		args []string
+		// table checks the table output, and encoded the JSON encoding of the json and yaml outputs
+		table   func(t *testing.T, out string)
+		encoded func(t *testing.T, encoded []byte)
+	}
+	specs := []spec{
+		{
+			name: "packages",
+			args: []string{"packages", ""},
+			table: func(t *testing.T, out string) {
+				require.Equal(t, [][]string{{"foo", "beta", "beta"}}, tableRows(out))
+			},
+			encoded: func(t *testing.T, encoded []byte) {
+				var pkgs []testPackage
+				require.NoError(t, json.Unmarshal(encoded, &pkgs))
+				require.Len(t, pkgs, 1)
+				require.Equal(t, "foo", pkgs[0].Name)
+				require.Equal(t, "beta", pkgs[0].DefaultChannelName)
+			},
+		},
+		{
+			name: "package",
+			args: []string{"package", "", "foo"},
+			table: func(t *testing.T, out string) {
+				require.Equal(t, [][]string{{"beta", "foo.v0.2.0", "true"}}, tableRows(out))
+			},
+			encoded: func(t *testing.T, encoded []byte) {
+				var pkg testPackage
+				require.NoError(t, json.Unmarshal(encoded, &pkg))
+				require.Equal(t, "foo", pkg.Name)
+				require.Len(t, pkg.Channels, 1)
+				require.Equal(t, "foo.v0.2.0", pkg.Channels[0].CsvName)
+			},
+		},
+		{
+			name: "bundle/head",
+			args: []string{"bundle", "", "foo", "beta"},
+			table: func(t *testing.T, out string) {
+				values := tableValues(out)
+				require.Equal(t, "foo.v0.2.0", values["name"])
+				require.Equal(t, "0.2.0", values["version"])
+				require.Equal(t, "<0.2.0", values["skip range"])
+				require.Equal(t, "test.foo/v1, Kind=Foo", values["provided apis"])
+			},
+			encoded: func(t *testing.T, encoded []byte) {
+				var b testBundle
+				require.NoError(t, json.Unmarshal(encoded, &b))
+				require.Equal(t, testBundle{CsvName: "foo.v0.2.0", PackageName: "foo", ChannelName: "beta", Version: "0.2.0", SkipRange: "<0.2.0"}, b)
+			},
+		},
+		{
+			name: "bundle/named",
+			args: []string{"bundle", "", "foo", "beta", "foo.v0.1.0"},
+			table: func(t *testing.T, out string) {
+				require.Equal(t, "foo.v0.1.0", tableValues(out)["name"])
+			},
+			encoded: func(t *testing.T, encoded []byte) {
+				var b testBundle
+				require.NoError(t, json.Unmarshal(encoded, &b))
+				require.Equal(t, "foo.v0.1.0", b.CsvName)
+			},
+		},
+		{
+			name: "bundles",
+			args: []string{"bundles", "", "--package", "foo"},
+			table: func(t *testing.T, out string) {
+				require.Equal(t, [][]string{
+					{"foo", "beta", "foo.v0.1.0", "0.1.0"},
+					{"foo", "beta", "foo.v0.2.0", "0.2.0", "foo.v0.1.0"},
+				}, tableRows(out))
+			},
+			encoded: func(t *testing.T, encoded []byte) {
+				var bundles []testBundle
+				require.NoError(t, json.Unmarshal(encoded, &bundles))
+				require.Len(t, bundles, 2)
+				require.Equal(t, "foo.v0.1.0", bundles[0].CsvName)
+				require.Equal(t, "foo.v0.2.0", bundles[1].CsvName)
+			},
+		},
+		{
+			name: "replacements",
+			args: []string{"replacements", "", "foo.v0.1.0"},
+			table: func(t *testing.T, out string) {
+				require.Equal(t, [][]string{{"foo", "beta", "foo.v0.2.0", "foo.v0.1.0"}}, tableRows(out))
+			},
+			encoded: func(t *testing.T, encoded []byte) {
+				var entries []testChannelEntry
+				require.NoError(t, json.Unmarshal(encoded, &entries))
+				require.Equal(t, []testChannelEntry{{PackageName: "foo", ChannelName: "beta", BundleName: "foo.v0.2.0", Replaces: "foo.v0.1.0"}}, entries)
+			},
+		},
+		{
+			name: "providers",
+			args: []string{"providers", "", "test.foo", "v1", "Foo"},
+			table: func(t *testing.T, out string) {
+				// skipped bundles are entries of the bundles that skip them
+				require.Equal(t, [][]string{
+					{"foo", "beta", "foo.v0.1.0"},
+					{"foo", "beta", "foo.v0.2.0", "foo.v0.1.0"},
+					{"foo", "beta", "foo.v0.2.0", "foo.v0.1.1"},
+					{"foo", "beta", "foo.v0.2.0", "foo.v0.1.2"},
+				}, tableRows(out))
+			},
+			encoded: func(t *testing.T, encoded []byte) {
+				var entries []testChannelEntry
+				require.NoError(t, json.Unmarshal(encoded, &entries))
+				require.Equal(t, []testChannelEntry{
+					{PackageName: "foo", ChannelName: "beta", BundleName: "foo.v0.1.0"},
+					{PackageName: "foo", ChannelName: "beta", BundleName: "foo.v0.2.0", Replaces: "foo.v0.1.0"},
+					{PackageName: "foo", ChannelName: "beta", BundleName: "foo.v0.2.0", Replaces: "foo.v0.1.1"},
+					{PackageName: "foo", ChannelName: "beta", BundleName: "foo.v0.2.0", Replaces: "foo.v0.1.2"},
+				}, entries)
+			},
+		},
+		{
+			name: "providers/latest",
+			args: []string{"providers", "", "test.foo", "v1", "Foo", "--latest"},
+			table: func(t *testing.T, out string) {
+				require.Equal(t, [][]string{{"foo", "beta", "foo.v0.2.0", "foo.v0.1.0"}}, tableRows(out))
+			},
+			encoded: func(t *testing.T, encoded []byte) {
+				var entries []testChannelEntry
+				require.NoError(t, json.Unmarshal(encoded, &entries))
+				require.Equal(t, []testChannelEntry{{PackageName: "foo", ChannelName: "beta", BundleName: "foo.v0.2.0", Replaces: "foo.v0.1.0"}}, entries)
+			},
+		},
+		{
+			name: "health",
+			args: []string{"health", ""},
+			table: func(t *testing.T, out string) {
+				require.Equal(t, "SERVING", tableValues(out)["status"])
+			},
+			encoded: func(t *testing.T, encoded []byte) {
+				var h health
+				require.NoError(t, json.Unmarshal(encoded, &h))
+				require.True(t, h.Serving)
+			},
+		},
+	}
+
+	for kind, address := range servers {
+		address := address
+		t.Run(kind, func(t *testing.T) {
+			for _, s := range specs {
+				s := s
+				// the address is the first argument of every command
+				args := append([]string{s.args[0], address}, s.args[2:]...)
+				t.Run(s.name, func(t *testing.T) {
+					out, err := runQuery(append(args, "-o", "table")...)
+					require.NoError(t, err)
+					s.table(t, out)
+
+					out, err = runQuery(append(args, "-o", "json")...)
+					require.NoError(t, err)
+					s.encoded(t, []byte(out))
+
+					out, err = runQuery(append(args, "-o", "yaml")...)
+					require.NoError(t, err)
+					encoded, err := yaml.YAMLToJSON([]byte(out))
+					require.NoError(t, err)
+					s.encoded(t, encoded)
+				})
+			}
+		})
+	}
+
+	t.Run("InvalidOutput", func(t *testing.T) {
+		_, err := runQuery("packages", servers["declcfg"], "-o", "text")
+		require.Error(t, err)
+	})
+	t.Run("NotFound", func(t *testing.T) {
+		_, err := runQuery("package", servers["sqlite"], "missing")
+		require.Error(t, err)
+	})
+}
+
+func TestPropertiesCommand(t *testing.T) {
+	sources := map[string]string{
+		"sqlite":  migratedCopy(t),
+		"declcfg": testdataDeclcfg,
+	}
+	for kind, source := range sources {
+		source := source
+		t.Run(kind, func(t *testing.T) {
+			out, err := runQuery("properties", source, "--type", "olm.skips", "-o", "table")
+			require.NoError(t, err)
+			require.Equal(t, [][]string{
+				{"foo", "foo.v0.2.0", "0.2.0", `"foo.v0.1.1"`},
+				{"foo", "foo.v0.2.0", "0.2.0", `"foo.v0.1.2"`},
+			}, tableRows(out))
+
+			out, err = runQuery("properties", source, "--type", "olm.package", "--path", "$.version", "--value", "0.1.0", "-o", "json")
+			require.NoError(t, err)
+			var match struct {
+				Package    string `json:"package"`
+				Name       string `json:"name"`
+				Image      string `json:"image"`
+				Properties []struct {
+					Type string `json:"type"`
+				} `json:"properties"`
+			}
+			require.NoError(t, json.NewDecoder(strings.NewReader(out)).Decode(&match))
+			require.Equal(t, "foo", match.Package)
+			require.Equal(t, "foo.v0.1.0", match.Name)
+			require.Equal(t, "test.registry/foo-operator/foo-bundle:v0.1.0", match.Image)
+			require.Len(t, match.Properties, 1)
+		})
+	}
+
+	t.Run("InvalidOutput", func(t *testing.T) {
+		_, err := runQuery("properties", testdataDeclcfg, "--type", "olm.package", "-o", "text")
+		require.Error(t, err)
+	})
+	t.Run("MissingSource", func(t *testing.T) {
+		_, err := runQuery("properties", filepath.Join(os.TempDir(), "missing"), "--type", "olm.package")
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,330 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+	"github.com/operator-framework/operator-registry/pkg/client"
+)
+
+func newPackagesCmd() *cobra.Command {
+	var o clientOptions
+	cmd := &cobra.Command{
+		Use:   "packages <address>",
+		Short: "List the packages served by a registry server",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.run(cmd, args[0], func(ctx context.Context, c *client.Client) error {
+				it, err := c.ListPackages(ctx)
+				if err != nil {
+					return err
+				}
+				var names []string
+				for p := it.Next(); p != nil; p = it.Next() {
+					names = append(names, p.GetName())
+				}
+				if err := it.Error(); err != nil {
+					return err
+				}
+				sort.Strings(names)
+
+				pkgs := make([]*api.Package, 0, len(names))
+				for _, name := range names {
+					pkg, err := c.GetPackage(ctx, name)
+					if err != nil {
+						return fmt.Errorf("get package %s: %v", name, err)
+					}
+					pkgs = append(pkgs, pkg)
+				}
+
+				return o.write(cmd.OutOrStdout(), pkgs, func(w io.Writer) {
+					fmt.Fprintln(w, "PACKAGE\tDEFAULT CHANNEL\tCHANNELS")
+					for _, pkg := range pkgs {
+						var channels []string
+						for _, ch := range pkg.GetChannels() {
+							channels = append(channels, ch.GetName())
+						}
+						fmt.Fprintf(w, "%s\t%s\t%s\n", pkg.GetName(), pkg.GetDefaultChannelName(), strings.Join(channels, ","))
+					}
+				})
+			})
+		},
+	}
+	o.addFlags(cmd)
+	return cmd
+}
+
+func newPackageCmd() *cobra.Command {
+	var o clientOptions
+	cmd := &cobra.Command{
+		Use:   "package <address> <package>",
+		Short: "Show the channels of a package served by a registry server, and their heads",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.run(cmd, args[0], func(ctx context.Context, c *client.Client) error {
+				pkg, err := c.GetPackage(ctx, args[1])
+				if err != nil {
+					return err
+				}
+				return o.write(cmd.OutOrStdout(), pkg, func(w io.Writer) {
+					fmt.Fprintln(w, "CHANNEL\tHEAD\tDEFAULT")
+					for _, ch := range pkg.GetChannels() {
+						fmt.Fprintf(w, "%s\t%s\t%t\n", ch.GetName(), ch.GetCsvName(), ch.GetName() == pkg.GetDefaultChannelName())
+					}
+				})
+			})
+		},
+	}
+	o.addFlags(cmd)
+	return cmd
+}
+
+func newBundleCmd() *cobra.Command {
+	var o clientOptions
+	cmd := &cobra.Command{
+		Use:   "bundle <address> <package> <channel> [<bundle>]",
+		Short: "Show a bundle served by a registry server, by default the head of the channel",
+		Args:  cobra.RangeArgs(3, 4),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.run(cmd, args[0], func(ctx context.Context, c *client.Client) error {
+				var (
+					b   *api.Bundle
+					err error
+				)
+				if len(args) == 4 {
+					b, err = c.GetBundle(ctx, args[1], args[2], args[3])
+				} else {
+					b, err = c.GetBundleInPackageChannel(ctx, args[1], args[2])
+				}
+				if err != nil {
+					return err
+				}
+				return o.write(cmd.OutOrStdout(), b, func(w io.Writer) {
+					writeBundleTable(w, b)
+				})
+			})
+		},
+	}
+	o.addFlags(cmd)
+	return cmd
+}
+
+func newBundlesCmd() *cobra.Command {
+	var (
+		o       clientOptions
+		pkgName string
+	)
+	cmd := &cobra.Command{
+		Use:   "bundles <address>",
+		Short: "List the bundles served by a registry server, once for each channel they're in",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.run(cmd, args[0], func(ctx context.Context, c *client.Client) error {
+				it, err := c.ListBundles(ctx)
+				if err != nil {
+					return err
+				}
+				var bundles []*api.Bundle
+				for b := it.Next(); b != nil; b = it.Next() {
+					if pkgName == "" || b.GetPackageName() == pkgName {
+						bundles = append(bundles, b)
+					}
+				}
+				if err := it.Error(); err != nil {
+					return err
+				}
+				sort.Slice(bundles, func(i, j int) bool {
+					x, y := bundles[i], bundles[j]
+					if x.GetPackageName() != y.GetPackageName() {
+						return x.GetPackageName() < y.GetPackageName()
+					}
+					if x.GetChannelName() != y.GetChannelName() {
+						return x.GetChannelName() < y.GetChannelName()
+					}
+					return x.GetCsvName() < y.GetCsvName()
+				})
+
+				return o.write(cmd.OutOrStdout(), bundles, func(w io.Writer) {
+					fmt.Fprintln(w, "PACKAGE\tCHANNEL\tBUNDLE\tVERSION\tREPLACES")
+					for _, b := range bundles {
+						fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", b.GetPackageName(), b.GetChannelName(), b.GetCsvName(), b.GetVersion(), b.GetReplaces())
+					}
+				})
+			})
+		},
+	}
+	o.addFlags(cmd)
+	cmd.Flags().StringVar(&pkgName, "package", "", "only list the bundles of this package")
+	return cmd
+}
+
+func newReplacementsCmd() *cobra.Command {
+	var o clientOptions
+	cmd := &cobra.Command{
+		Use:   "replacements <address> <bundle>",
+		Short: "List the channel entries that replace or skip a bundle",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.run(cmd, args[0], func(ctx context.Context, c *client.Client) error {
+				it, err := c.GetChannelEntriesThatReplace(ctx, args[1])
+				if err != nil {
+					return err
+				}
+				return o.writeChannelEntries(cmd.OutOrStdout(), it)
+			})
+		},
+	}
+	o.addFlags(cmd)
+	return cmd
+}
+
+func newProvidersCmd() *cobra.Command {
+	var (
+		o      clientOptions
+		latest bool
+	)
+	cmd := &cobra.Command{
+		Use:   "providers <address> <group> <version> <kind>",
+		Short: "List the channel entries of bundles that provide an API",
+		Args:  cobra.ExactArgs(4),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.run(cmd, args[0], func(ctx context.Context, c *client.Client) error {
+				var (
+					it  *client.ChannelEntryIterator
+					err error
+				)
+				if latest {
+					it, err = c.GetLatestChannelEntriesThatProvide(ctx, args[1], args[2], args[3])
+				} else {
+					it, err = c.GetChannelEntriesThatProvide(ctx, args[1], args[2], args[3])
+				}
+				if err != nil {
+					return err
+				}
+				return o.writeChannelEntries(cmd.OutOrStdout(), it)
+			})
+		},
+	}
+	o.addFlags(cmd)
+	cmd.Flags().BoolVar(&latest, "latest", false, "only list the latest provider in each channel")
+	return cmd
+}
+
+// health is the health of a registry server, with the catalog it serves if it can describe it.
+type health struct {
+	Address      string `json:"address"`
+	Serving      bool   `json:"serving"`
+	Digest       string `json:"digest,omitempty"`
+	PackageCount int32  `json:"packageCount,omitempty"`
+	BundleCount  int32  `json:"bundleCount,omitempty"`
+	LoadTime     string `json:"loadTime,omitempty"`
+}
+
+func newHealthCmd() *cobra.Command {
+	var o clientOptions
+	cmd := &cobra.Command{
+		Use:   "health <address>",
+		Short: "Show the health of a registry server and the catalog it serves",
+		Long: `Show the health of a registry server and the catalog it serves
+
+The command fails if the server isn't serving. The digest, size and load time of the
+catalog are shown if the server can describe it.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.run(cmd, args[0], func(ctx context.Context, c *client.Client) error {
+				serving, err := c.HealthCheck(ctx, o.timeout)
+				if err != nil {
+					return err
+				}
+				h := health{Address: args[0], Serving: serving}
+				// older servers don't implement GetCatalogInfo
+				if info, err := c.GetCatalogInfo(ctx); err == nil {
+					h.Digest = info.GetDigest()
+					h.PackageCount = info.GetPackageCount()
+					h.BundleCount = info.GetBundleCount()
+					if info.GetLoadTime() != nil {
+						h.LoadTime = info.GetLoadTime().AsTime().Format(time.RFC3339)
+					}
+				}
+
+				err = o.write(cmd.OutOrStdout(), h, func(w io.Writer) {
+					status := "SERVING"
+					if !h.Serving {
+						status = "NOT_SERVING"
+					}
+					fmt.Fprintf(w, "address:\t%s\n", h.Address)
+					fmt.Fprintf(w, "status:\t%s\n", status)
+					if h.Digest != "" {
+						fmt.Fprintf(w, "digest:\t%s\n", h.Digest)
+						fmt.Fprintf(w, "packages:\t%d\n", h.PackageCount)
+						fmt.Fprintf(w, "bundles:\t%d\n", h.BundleCount)
+						fmt.Fprintf(w, "loaded:\t%s\n", h.LoadTime)
+					}
+				})
+				if err != nil {
+					return err
+				}
+				if !serving {
+					return fmt.Errorf("%s is not serving", args[0])
+				}
+				return nil
+			})
+		},
+	}
+	o.addFlags(cmd)
+	return cmd
+}
+
+func (o *clientOptions) writeChannelEntries(out io.Writer, it *client.ChannelEntryIterator) error {
+	var entries []*api.ChannelEntry
+	for e := it.Next(); e != nil; e = it.Next() {
+		entries = append(entries, e)
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return channelEntryKey(entries[i]) < channelEntryKey(entries[j])
+	})
+
+	return o.write(out, entries, func(w io.Writer) {
+		fmt.Fprintln(w, "PACKAGE\tCHANNEL\tBUNDLE\tREPLACES")
+		for _, e := range entries {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", e.GetPackageName(), e.GetChannelName(), e.GetBundleName(), e.GetReplaces())
+		}
+	})
+}
+
+func channelEntryKey(e *api.ChannelEntry) string {
+	return e.GetPackageName() + "/" + e.GetChannelName() + "/" + e.GetBundleName() + "/" + e.GetReplaces()
+}
+
+func writeBundleTable(w io.Writer, b *api.Bundle) {
+	gvks := func(apis []*api.GroupVersionKind) string {
+		var out []string
+		for _, a := range apis {
+			out = append(out, fmt.Sprintf("%s/%s, Kind=%s", a.GetGroup(), a.GetVersion(), a.GetKind()))
+		}
+		return strings.Join(out, "; ")
+	}
+	fmt.Fprintf(w, "name:\t%s\n", b.GetCsvName())
+	fmt.Fprintf(w, "package:\t%s\n", b.GetPackageName())
+	fmt.Fprintf(w, "channel:\t%s\n", b.GetChannelName())
+	fmt.Fprintf(w, "version:\t%s\n", b.GetVersion())
+	fmt.Fprintf(w, "image:\t%s\n", b.GetBundlePath())
+	fmt.Fprintf(w, "replaces:\t%s\n", b.GetReplaces())
+	fmt.Fprintf(w, "skips:\t%s\n", strings.Join(b.GetSkips(), ","))
+	fmt.Fprintf(w, "skip range:\t%s\n", b.GetSkipRange())
+	fmt.Fprintf(w, "provided apis:\t%s\n", gvks(b.GetProvidedApis()))
+	fmt.Fprintf(w, "required apis:\t%s\n", gvks(b.GetRequiredApis()))
+	for _, p := range b.GetProperties() {
+		fmt.Fprintf(w, "property:\t%s %s\n", p.GetType(), p.GetValue())
+	}
+}
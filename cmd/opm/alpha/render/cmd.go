@@ -1,6 +1,7 @@
 package render
 
 import (
+	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
@@ -15,8 +16,9 @@ import (
 
 func NewCmd() *cobra.Command {
 	var (
-		render action.Render
-		output string
+		render     action.Render
+		output     string
+		showDigest bool
 	)
 	cmd := &cobra.Command{
 		Use:   "render [index-image | bundle-image]...",
@@ -45,11 +47,21 @@ func NewCmd() *cobra.Command {
 				log.Fatal(err)
 			}
 
+			if showDigest {
+				digest, err := declcfg.Digest(cmd.Context(), *cfg)
+				if err != nil {
+					log.Fatal(err)
+				}
+				fmt.Println(digest)
+				return
+			}
+
 			if err := write(*cfg, os.Stdout); err != nil {
 				log.Fatal(err)
 			}
 		},
 	}
 	cmd.Flags().StringVarP(&output, "output", "o", "json", "Output format (json|yaml)")
+	cmd.Flags().BoolVar(&showDigest, "digest", false, "print a digest of the rendered catalog's content instead of the catalog")
 	return cmd
 }
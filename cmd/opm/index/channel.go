@@ -0,0 +1,144 @@
+package index
+
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/operator-framework/operator-registry/pkg/containertools"
+	"github.com/operator-framework/operator-registry/pkg/lib/indexer"
+	"github.com/operator-framework/operator-registry/pkg/lib/registry"
+)
+
+func newIndexChannelCmd() *cobra.Command {
+	indexCmd := &cobra.Command{
+		Use:   "channel",
+		Short: "edit the channels of a package in an index",
+		Long: `edit the channels of a package in an index
+
+These are the index counterparts of the 'opm registry channel' commands, which describe each edit.`,
+	}
+
+	indexCmd.PersistentFlags().Bool("debug", false, "enable debug logging")
+	indexCmd.PersistentFlags().Bool("generate", false, "if enabled, just creates the dockerfile and saves it to local disk")
+	indexCmd.PersistentFlags().StringP("out-dockerfile", "d", "", "if generating the dockerfile, this flag is used to (optionally) specify a dockerfile name")
+	indexCmd.PersistentFlags().StringP("from-index", "f", "", "previous index to edit")
+	if err := indexCmd.MarkPersistentFlagRequired("from-index"); err != nil {
+		logrus.Panic("Failed to set required `from-index` flag for `index channel`")
+	}
+	indexCmd.PersistentFlags().String("package", "", "package whose channels to edit")
+	if err := indexCmd.MarkPersistentFlagRequired("package"); err != nil {
+		logrus.Panic("Failed to set required `package` flag for `index channel`")
+	}
+	indexCmd.PersistentFlags().String("channel", "", "channel to edit")
+	if err := indexCmd.MarkPersistentFlagRequired("channel"); err != nil {
+		logrus.Panic("Failed to set required `channel` flag for `index channel`")
+	}
+	indexCmd.PersistentFlags().StringP("binary-image", "i", "", "container image for on-image `opm` command")
+	indexCmd.PersistentFlags().StringP("container-tool", "c", "", "tool to interact with container images (save, build, etc.). One of: [docker, podman]")
+	indexCmd.PersistentFlags().StringP("build-tool", "u", "", "tool to build container images. One of: [docker, podman]. Defaults to podman. Overrides part of container-tool.")
+	indexCmd.PersistentFlags().StringP("pull-tool", "p", "", "tool to pull container images. One of: [none, docker, podman]. Defaults to none. Overrides part of container-tool.")
+	indexCmd.PersistentFlags().StringP("tag", "t", "", "custom tag for container image being built")
+	if err := indexCmd.PersistentFlags().MarkHidden("debug"); err != nil {
+		logrus.Panic(err.Error())
+	}
+
+	addCmd := newIndexChannelEditCmd(registry.AddBundleToChannel, "add a bundle to a channel, as its new head")
+	addCmd.Flags().StringP("bundle", "b", "", "name of the bundle to add")
+	if err := addCmd.MarkFlagRequired("bundle"); err != nil {
+		logrus.Panic("Failed to set required `bundle` flag for `index channel add`")
+	}
+
+	rmCmd := newIndexChannelEditCmd(registry.RemoveBundleFromChannel, "remove a bundle from a channel, linking the channel around it")
+	rmCmd.Flags().StringP("bundle", "b", "", "name of the bundle to remove")
+	if err := rmCmd.MarkFlagRequired("bundle"); err != nil {
+		logrus.Panic("Failed to set required `bundle` flag for `index channel rm`")
+	}
+
+	renameCmd := newIndexChannelEditCmd(registry.RenameChannel, "rename a channel")
+	renameCmd.Flags().String("name", "", "new name of the channel")
+	if err := renameCmd.MarkFlagRequired("name"); err != nil {
+		logrus.Panic("Failed to set required `name` flag for `index channel rename`")
+	}
+
+	defaultCmd := newIndexChannelEditCmd(registry.UpdateDefaultChannel, "make a channel the default channel")
+
+	indexCmd.AddCommand(addCmd, rmCmd, renameCmd, defaultCmd)
+
+	return indexCmd
+}
+
+func newIndexChannelEditCmd(op registry.ChannelOperation, short string) *cobra.Command {
+	return &cobra.Command{
+		Use:   string(op),
+		Short: short,
+		Long:  short,
+		Args:  cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if debug, _ := cmd.Flags().GetBool("debug"); debug {
+				logrus.SetLevel(logrus.DebugLevel)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runIndexChannelEditCmdFunc(cmd, op)
+		},
+	}
+}
+
+func runIndexChannelEditCmdFunc(cmd *cobra.Command, op registry.ChannelOperation) error {
+	request := indexer.EditChannelsInIndexRequest{
+		Operation: op,
+	}
+
+	var err error
+	if request.Generate, err = cmd.Flags().GetBool("generate"); err != nil {
+		return err
+	}
+	if request.OutDockerfile, err = cmd.Flags().GetString("out-dockerfile"); err != nil {
+		return err
+	}
+	if request.FromIndex, err = cmd.Flags().GetString("from-index"); err != nil {
+		return err
+	}
+	if request.Package, err = cmd.Flags().GetString("package"); err != nil {
+		return err
+	}
+	if request.Channel, err = cmd.Flags().GetString("channel"); err != nil {
+		return err
+	}
+	if cmd.Flags().Lookup("bundle") != nil {
+		if request.Bundle, err = cmd.Flags().GetString("bundle"); err != nil {
+			return err
+		}
+	}
+	if cmd.Flags().Lookup("name") != nil {
+		if request.NewName, err = cmd.Flags().GetString("name"); err != nil {
+			return err
+		}
+	}
+	if request.BinarySourceImage, err = cmd.Flags().GetString("binary-image"); err != nil {
+		return err
+	}
+	if request.Tag, err = cmd.Flags().GetString("tag"); err != nil {
+		return err
+	}
+	if request.SkipTLS, err = cmd.Flags().GetBool("skip-tls"); err != nil {
+		return err
+	}
+
+	pullTool, buildTool, err := getContainerTools(cmd)
+	if err != nil {
+		return err
+	}
+
+	logger := logrus.WithFields(logrus.Fields{"package": request.Package, "channel": request.Channel})
+
+	logger.Infof("editing channel in the index: %s", op)
+
+	indexChannelEditor := indexer.NewIndexChannelEditor(
+		containertools.NewContainerTool(buildTool, containertools.PodmanTool),
+		containertools.NewContainerTool(pullTool, containertools.NoneTool),
+		logger)
+
+	return indexChannelEditor.EditChannelsInIndex(request)
+}
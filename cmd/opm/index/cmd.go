@@ -18,10 +18,15 @@ func AddCommand(parent *cobra.Command) {
 			}
 			return nil
 		},
-		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			if skipTLS, err := cmd.Flags().GetBool("skip-tls"); err == nil && skipTLS {
 				logrus.Warn("--skip-tls flag is set: this mode is insecure and meant for development purposes only.")
 			}
+			// cobra only runs the nearest persistent pre-run, so run the parent's too
+			if parent.PersistentPreRunE != nil {
+				return parent.PersistentPreRunE(cmd, args)
+			}
+			return nil
 		},
 	}
 
@@ -32,5 +37,7 @@ func AddCommand(parent *cobra.Command) {
 	cmd.AddCommand(newIndexExportCmd())
 	cmd.AddCommand(newIndexPruneCmd())
 	cmd.AddCommand(newIndexDeprecateTruncateCmd())
+	cmd.AddCommand(newIndexUndeprecateCmd())
+	cmd.AddCommand(newIndexChannelCmd())
 	cmd.AddCommand(newIndexPruneStrandedCmd())
 }
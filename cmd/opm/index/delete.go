@@ -1,6 +1,8 @@
 package index
 
 import (
+	"fmt"
+
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
@@ -11,8 +13,11 @@ import (
 func newIndexDeleteCmd() *cobra.Command {
 	indexCmd := &cobra.Command{
 		Use:   "rm",
-		Short: "delete an entire operator from an index",
-		Long:  `delete an entire operator from an index`,
+		Short: "delete an entire operator, or individual bundles, from an index",
+		Long: `delete an entire operator, or individual bundles, from an index
+
+Bundles that replace a deleted bundle are updated to replace the bundle it replaced, and to skip it.
+Channels headed by a deleted bundle are headed by the bundle it replaced, or are removed if there is none.`,
 
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			if debug, _ := cmd.Flags().GetBool("debug"); debug {
@@ -32,9 +37,7 @@ func newIndexDeleteCmd() *cobra.Command {
 		logrus.Panic("Failed to set required `from-index` flag for `index delete`")
 	}
 	indexCmd.Flags().StringSliceP("operators", "o", nil, "comma separated list of operators to delete")
-	if err := indexCmd.MarkFlagRequired("operators"); err != nil {
-		logrus.Panic("Failed to set required `operators` flag for `index delete`")
-	}
+	indexCmd.Flags().StringSliceP("bundles", "b", nil, "comma separated list of bundle images to delete")
 	indexCmd.Flags().StringP("binary-image", "i", "", "container image for on-image `opm` command")
 	indexCmd.Flags().StringP("container-tool", "c", "", "tool to interact with container images (save, build, etc.). One of: [none, docker, podman]")
 	indexCmd.Flags().StringP("build-tool", "u", "", "tool to build container images. One of: [docker, podman]. Defaults to podman. Overrides part of container-tool.")
@@ -71,6 +74,15 @@ func runIndexDeleteCmdFunc(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	bundles, err := cmd.Flags().GetStringSlice("bundles")
+	if err != nil {
+		return err
+	}
+
+	if (len(operators) == 0) == (len(bundles) == 0) {
+		return fmt.Errorf("exactly one of --operators or --bundles must be set")
+	}
+
 	binaryImage, err := cmd.Flags().GetString("binary-image")
 	if err != nil {
 		return err
@@ -96,7 +108,7 @@ func runIndexDeleteCmdFunc(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	logger := logrus.WithFields(logrus.Fields{"operators": operators})
+	logger := logrus.WithFields(logrus.Fields{"operators": operators, "bundles": bundles})
 
 	logger.Info("building the index")
 
@@ -111,6 +123,7 @@ func runIndexDeleteCmdFunc(cmd *cobra.Command, args []string) error {
 		BinarySourceImage: binaryImage,
 		OutDockerfile:     outDockerfile,
 		Operators:         operators,
+		Bundles:           bundles,
 		Tag:               tag,
 		Permissive:        permissive,
 		SkipTLS:           skipTLS,
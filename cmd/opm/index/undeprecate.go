@@ -0,0 +1,143 @@
+package index
+
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	"github.com/operator-framework/operator-registry/pkg/containertools"
+	"github.com/operator-framework/operator-registry/pkg/lib/indexer"
+)
+
+var undeprecateLong = templates.LongDesc(`
+	Undeprecate operator bundles in an index.
+
+	Undeprecated bundles are installable again, along with the channels they headed. Deprecating a bundle removes the bundles it replaces from the index; to restore them into their channels, list their images with --restore.
+
+	For example:
+
+		Given the update graph in quay.io/my/index:v2, produced by deprecating 1.3.0
+		1.4.0 -- replaces -> 1.3.0 [deprecated]
+
+		Applying the command:
+		opm index undeprecate --bundles "quay.io/my/bundle:1.3.0" --restore "quay.io/my/bundle:1.2.0,quay.io/my/bundle:1.1.0" --from-index "quay.io/my/index:v2" --tag "quay.io/my/index:v3"
+
+		Produces the following update graph in quay.io/my/index:v3
+		1.4.0 -- replaces -> 1.3.0 -- replaces -> 1.2.0 -- replaces -> 1.1.0
+
+	Without --restore, an undeprecated bundle remains the tail of its channels.
+	`)
+
+func newIndexUndeprecateCmd() *cobra.Command {
+	indexCmd := &cobra.Command{
+		Use:   "undeprecate",
+		Short: "Undeprecate operator bundles in an index.",
+		Long:  undeprecateLong,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if debug, _ := cmd.Flags().GetBool("debug"); debug {
+				logrus.SetLevel(logrus.DebugLevel)
+			}
+			return nil
+		},
+		RunE: runIndexUndeprecateCmdFunc,
+	}
+
+	indexCmd.Flags().Bool("debug", false, "enable debug logging")
+	indexCmd.Flags().Bool("generate", false, "if enabled, just creates the dockerfile and saves it to local disk")
+	indexCmd.Flags().StringP("out-dockerfile", "d", "", "if generating the dockerfile, this flag is used to (optionally) specify a dockerfile name")
+	indexCmd.Flags().StringP("from-index", "f", "", "previous index to undeprecate bundles in")
+	if err := indexCmd.MarkFlagRequired("from-index"); err != nil {
+		logrus.Panic("Failed to set required `from-index` flag for `index undeprecate`")
+	}
+	indexCmd.Flags().StringSliceP("bundles", "b", nil, "comma separated list of deprecated bundles to undeprecate")
+	if err := indexCmd.MarkFlagRequired("bundles"); err != nil {
+		logrus.Panic("Failed to set required `bundles` flag for `index undeprecate`")
+	}
+	indexCmd.Flags().StringSlice("restore", nil, "comma separated list of truncated bundles to add back")
+	indexCmd.Flags().StringP("binary-image", "i", "", "container image for on-image `opm` command")
+	indexCmd.Flags().StringP("container-tool", "c", "", "tool to interact with container images (save, build, etc.). One of: [docker, podman]")
+	indexCmd.Flags().StringP("build-tool", "u", "", "tool to build container images. One of: [docker, podman]. Defaults to podman. Overrides part of container-tool.")
+	indexCmd.Flags().StringP("pull-tool", "p", "", "tool to pull container images. One of: [none, docker, podman]. Defaults to none. Overrides part of container-tool.")
+	indexCmd.Flags().StringP("tag", "t", "", "custom tag for container image being built")
+	indexCmd.Flags().Bool("permissive", false, "allow registry load errors")
+	if err := indexCmd.Flags().MarkHidden("debug"); err != nil {
+		logrus.Panic(err.Error())
+	}
+
+	return indexCmd
+}
+
+func runIndexUndeprecateCmdFunc(cmd *cobra.Command, args []string) error {
+	generate, err := cmd.Flags().GetBool("generate")
+	if err != nil {
+		return err
+	}
+
+	outDockerfile, err := cmd.Flags().GetString("out-dockerfile")
+	if err != nil {
+		return err
+	}
+
+	fromIndex, err := cmd.Flags().GetString("from-index")
+	if err != nil {
+		return err
+	}
+
+	bundles, err := cmd.Flags().GetStringSlice("bundles")
+	if err != nil {
+		return err
+	}
+
+	restore, err := cmd.Flags().GetStringSlice("restore")
+	if err != nil {
+		return err
+	}
+
+	binaryImage, err := cmd.Flags().GetString("binary-image")
+	if err != nil {
+		return err
+	}
+
+	tag, err := cmd.Flags().GetString("tag")
+	if err != nil {
+		return err
+	}
+
+	permissive, err := cmd.Flags().GetBool("permissive")
+	if err != nil {
+		return err
+	}
+
+	pullTool, buildTool, err := getContainerTools(cmd)
+	if err != nil {
+		return err
+	}
+
+	skipTLS, err := cmd.Flags().GetBool("skip-tls")
+	if err != nil {
+		return err
+	}
+
+	logger := logrus.WithFields(logrus.Fields{"bundles": bundles, "restore": restore})
+
+	logger.Info("undeprecating bundles in the index")
+
+	indexUndeprecator := indexer.NewIndexUndeprecator(
+		containertools.NewContainerTool(buildTool, containertools.PodmanTool),
+		containertools.NewContainerTool(pullTool, containertools.NoneTool),
+		logger)
+
+	request := indexer.UndeprecateFromIndexRequest{
+		Generate:          generate,
+		FromIndex:         fromIndex,
+		BinarySourceImage: binaryImage,
+		OutDockerfile:     outDockerfile,
+		Tag:               tag,
+		Bundles:           bundles,
+		Restore:           restore,
+		Permissive:        permissive,
+		SkipTLS:           skipTLS,
+	}
+
+	return indexUndeprecator.UndeprecateFromIndex(request)
+}
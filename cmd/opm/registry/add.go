@@ -3,6 +3,9 @@ package registry
 import (
 	"errors"
 	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -36,6 +39,8 @@ func newRegistryAddCmd() *cobra.Command {
 	rootCmd.Flags().String("ca-file", "", "the root certificates to use when --container-tool=none; see docker/podman docs for certificate loading instructions")
 	rootCmd.Flags().StringP("mode", "", "replaces", "graph update mode that defines how channel graphs are updated. One of: [replaces, semver, semver-skippatch]")
 	rootCmd.Flags().StringP("container-tool", "c", "none", "tool to interact with container images (save, build, etc.). One of: [none, docker, podman]")
+	rootCmd.Flags().Bool("dry-run", false, "report the packages and channels adding the bundles would change, without changing the database")
+	rootCmd.Flags().Bool("strict", false, "add all of the bundles or, if any of them fails to be added, none of them")
 
 	return rootCmd
 }
@@ -66,6 +71,17 @@ func addFunc(cmd *cobra.Command, args []string) error {
 		return err
 	}
 	containerTool := containertools.NewContainerTool(containerToolStr, containertools.NoneTool)
+	dryRun, err := cmd.Flags().GetBool("dry-run")
+	if err != nil {
+		return err
+	}
+	strict, err := cmd.Flags().GetBool("strict")
+	if err != nil {
+		return err
+	}
+	if strict && permissive {
+		return errors.New("--strict and --permissive are mutually exclusive")
+	}
 	mode, err := cmd.Flags().GetString("mode")
 	if err != nil {
 		return err
@@ -94,6 +110,7 @@ func addFunc(cmd *cobra.Command, args []string) error {
 		Mode:          modeEnum,
 		ContainerTool: containerTool,
 		Overwrite:     false,
+		Strict:        strict,
 	}
 
 	logger := logrus.WithFields(logrus.Fields{"bundles": bundleImages})
@@ -102,6 +119,16 @@ func addFunc(cmd *cobra.Command, args []string) error {
 		logger.Warn("--skip-tls flag is set: this mode is insecure and meant for development purposes only.")
 	}
 
+	if dryRun {
+		logger.Info("planning additions to the registry")
+
+		changes, err := registry.NewRegistryAddPlanner(logger).PlanAddToRegistry(request)
+		if err != nil {
+			return err
+		}
+		return writePackageChanges(cmd.OutOrStdout(), changes)
+	}
+
 	logger.Info("adding to the registry")
 
 	registryAdder := registry.NewRegistryAdder(logger)
@@ -112,3 +139,37 @@ func addFunc(cmd *cobra.Command, args []string) error {
 	}
 	return nil
 }
+
+func writePackageChanges(out io.Writer, changes []registry.PackageChange) error {
+	if len(changes) == 0 {
+		_, err := fmt.Fprintln(out, "no packages would change")
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "PACKAGE\tDEFAULT CHANNEL\tCHANNEL\tHEAD\tPREVIOUS HEAD\tADDED BUNDLES")
+	for _, change := range changes {
+		pkg := change.Package
+		if change.New {
+			pkg += " (new)"
+		}
+		defaultChannel := change.DefaultChannel
+		if !change.New && change.DefaultChannel != change.PreviousDefaultChannel {
+			defaultChannel = fmt.Sprintf("%s (was %s)", change.DefaultChannel, change.PreviousDefaultChannel)
+		}
+		if len(change.Channels) == 0 {
+			fmt.Fprintf(w, "%s\t%s\t-\t-\t-\t-\n", pkg, defaultChannel)
+		}
+		for _, ch := range change.Channels {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", pkg, defaultChannel, ch.Channel, orNone(ch.Head), orNone(ch.PreviousHead), orNone(strings.Join(ch.Bundles, ",")))
+		}
+	}
+	return w.Flush()
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,127 @@
+package registry
+
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/operator-framework/operator-registry/pkg/lib/registry"
+)
+
+func newRegistryChannelCmd() *cobra.Command {
+	rootCmd := &cobra.Command{
+		Use:   "channel",
+		Short: "edit the channels of a package in operator registry DB",
+		Long: `Edit the channels of a package in operator registry DB
+
+A channel is made of its head bundle and the bundles it replaces, so bundles are added to a
+channel as its new head, and any of its bundles can be removed by linking the channel around it.`,
+
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if debug, _ := cmd.Flags().GetBool("debug"); debug {
+				logrus.SetLevel(logrus.DebugLevel)
+			}
+			// cobra only runs the nearest persistent pre-run, so run the root's too
+			if root := cmd.Root(); root != cmd && root.PersistentPreRunE != nil {
+				return root.PersistentPreRunE(cmd, args)
+			}
+			return nil
+		},
+	}
+
+	rootCmd.PersistentFlags().Bool("debug", false, "enable debug logging")
+	rootCmd.PersistentFlags().StringP("database", "d", "bundles.db", "relative path to database file")
+	rootCmd.PersistentFlags().StringP("package", "p", "", "package whose channels to edit")
+	if err := rootCmd.MarkPersistentFlagRequired("package"); err != nil {
+		logrus.Panic("Failed to set required `package` flag for `registry channel`")
+	}
+	rootCmd.PersistentFlags().StringP("channel", "c", "", "channel to edit")
+	if err := rootCmd.MarkPersistentFlagRequired("channel"); err != nil {
+		logrus.Panic("Failed to set required `channel` flag for `registry channel`")
+	}
+
+	addCmd := newRegistryChannelEditCmd(registry.AddBundleToChannel, "add a bundle to a channel",
+		`Add a bundle of the package to a channel, creating the channel if it doesn't exist
+
+The bundle becomes the head of the channel, so it must replace or skip the current head, directly or
+through the bundles it replaces. For example, promote the head of a candidate channel to stable:
+
+  opm registry channel add -p etcd -c stable -b etcdoperator.v0.9.2`)
+	addCmd.Flags().StringP("bundle", "b", "", "name of the bundle to add")
+	if err := addCmd.MarkFlagRequired("bundle"); err != nil {
+		logrus.Panic("Failed to set required `bundle` flag for `registry channel add`")
+	}
+
+	rmCmd := newRegistryChannelEditCmd(registry.RemoveBundleFromChannel, "remove a bundle from a channel",
+		`Remove a bundle from a channel, leaving it in its other channels
+
+The channel is linked around the bundle. If it is the head, the bundle it replaces becomes the head,
+or the newest bundle it skips if it replaces none, and the channel is removed if there is neither.
+Otherwise, the bundles of the channel that replace or skip it replace or skip what it did instead,
+so they must be in no other channel. The default channel of the package can't be removed, and a
+bundle can't be removed from its last channel.`)
+	rmCmd.Flags().StringP("bundle", "b", "", "name of the bundle to remove")
+	if err := rmCmd.MarkFlagRequired("bundle"); err != nil {
+		logrus.Panic("Failed to set required `bundle` flag for `registry channel rm`")
+	}
+
+	renameCmd := newRegistryChannelEditCmd(registry.RenameChannel, "rename a channel",
+		`Rename a channel of the package, keeping it the default channel if it is`)
+	renameCmd.Flags().String("name", "", "new name of the channel")
+	if err := renameCmd.MarkFlagRequired("name"); err != nil {
+		logrus.Panic("Failed to set required `name` flag for `registry channel rename`")
+	}
+
+	defaultCmd := newRegistryChannelEditCmd(registry.UpdateDefaultChannel, "make a channel the default channel",
+		`Make a channel of the package its default channel`)
+
+	rootCmd.AddCommand(addCmd, rmCmd, renameCmd, defaultCmd)
+
+	return rootCmd
+}
+
+func newRegistryChannelEditCmd(op registry.ChannelOperation, short, long string) *cobra.Command {
+	return &cobra.Command{
+		Use:   string(op),
+		Short: short,
+		Long:  long,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return channelEditFunc(cmd, op)
+		},
+	}
+}
+
+func channelEditFunc(cmd *cobra.Command, op registry.ChannelOperation) error {
+	fromFilename, err := cmd.Flags().GetString("database")
+	if err != nil {
+		return err
+	}
+	request := registry.EditChannelsRequest{
+		InputDatabase: fromFilename,
+		Operation:     op,
+	}
+	if request.Package, err = cmd.Flags().GetString("package"); err != nil {
+		return err
+	}
+	if request.Channel, err = cmd.Flags().GetString("channel"); err != nil {
+		return err
+	}
+	if cmd.Flags().Lookup("bundle") != nil {
+		if request.Bundle, err = cmd.Flags().GetString("bundle"); err != nil {
+			return err
+		}
+	}
+	if cmd.Flags().Lookup("name") != nil {
+		if request.NewName, err = cmd.Flags().GetString("name"); err != nil {
+			return err
+		}
+	}
+
+	// the flags are valid, don't print usage for errors editing the channel
+	cmd.SilenceUsage = true
+
+	logger := logrus.WithFields(logrus.Fields{"package": request.Package, "channel": request.Channel})
+	logger.Infof("editing channel: %s", op)
+
+	return registry.NewRegistryChannelEditor(logger).EditChannels(request)
+}
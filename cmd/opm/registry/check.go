@@ -0,0 +1,129 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/operator-framework/operator-registry/pkg/sqlite"
+)
+
+func newRegistryCheckCmd() *cobra.Command {
+	rootCmd := &cobra.Command{
+		Use:   "check",
+		Short: "check the consistency of an operator registry DB",
+		Long: `Check the consistency of an operator registry DB
+
+Every table of the database is checked for rows referencing packages, channels, bundles
+or APIs that don't exist. With --repair, violations that can be fixed without losing
+data the catalog needs, such as rows describing bundles that no longer exist, are fixed
+in a single transaction. The command fails if any violation remains.`,
+		Args: cobra.NoArgs,
+
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if debug, _ := cmd.Flags().GetBool("debug"); debug {
+				logrus.SetLevel(logrus.DebugLevel)
+			}
+			return nil
+		},
+
+		RunE: checkFunc,
+	}
+
+	rootCmd.Flags().Bool("debug", false, "enable debug logging")
+	rootCmd.Flags().StringP("database", "d", "bundles.db", "relative path to database file")
+	rootCmd.Flags().Bool("repair", false, "repair the violations that can be repaired safely")
+	rootCmd.Flags().StringP("output", "o", "text", "output format (text|json)")
+
+	return rootCmd
+}
+
+func checkFunc(cmd *cobra.Command, _ []string) error {
+	dbName, err := cmd.Flags().GetString("database")
+	if err != nil {
+		return err
+	}
+	repair, err := cmd.Flags().GetBool("repair")
+	if err != nil {
+		return err
+	}
+	output, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return err
+	}
+	var write func(io.Writer, []sqlite.CheckResult) error
+	switch output {
+	case "text":
+		write = writeCheckText
+	case "json":
+		write = writeCheckJSON
+	default:
+		return fmt.Errorf("invalid --output value %q, expected (text|json)", output)
+	}
+
+	// don't create a database that doesn't exist
+	if _, err := os.Stat(dbName); err != nil {
+		return err
+	}
+	db, err := sqlite.Open(dbName)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	results, err := sqlite.CheckDB(context.TODO(), db, repair)
+	if err != nil {
+		return err
+	}
+	if err := write(os.Stdout, results); err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.Failed() {
+			failed++
+		}
+	}
+	if failed > 0 {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("%d of %d checks failed", failed, len(results))
+	}
+	return nil
+}
+
+func writeCheckText(out io.Writer, results []sqlite.CheckResult) error {
+	for _, r := range results {
+		status := "ok"
+		switch {
+		case r.Skipped:
+			status = "skipped"
+		case r.Repaired:
+			status = fmt.Sprintf("repaired %d violations", len(r.Violations))
+		case len(r.Violations) > 0 && r.Repairable:
+			status = fmt.Sprintf("%d violations, repairable", len(r.Violations))
+		case len(r.Violations) > 0:
+			status = fmt.Sprintf("%d violations", len(r.Violations))
+		}
+		if _, err := fmt.Fprintf(out, "%s: %s: %s\n", r.Name, r.Description, status); err != nil {
+			return err
+		}
+		for _, v := range r.Violations {
+			if _, err := fmt.Fprintf(out, "  %s\n", v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func writeCheckJSON(out io.Writer, results []sqlite.CheckResult) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "    ")
+	return enc.Encode(results)
+}
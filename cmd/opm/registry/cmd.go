@@ -25,6 +25,11 @@ func NewOpmRegistryCmd() *cobra.Command {
 	rootCmd.AddCommand(newRegistryRmCmd())
 	rootCmd.AddCommand(newRegistryPruneCmd())
 	rootCmd.AddCommand(newRegistryPruneStrandedCmd())
+	rootCmd.AddCommand(newRegistryUndeprecateCmd())
+	rootCmd.AddCommand(newRegistryChannelCmd())
+	rootCmd.AddCommand(newRegistryMigrateCmd())
+	rootCmd.AddCommand(newRegistryCheckCmd())
+	rootCmd.AddCommand(newRegistryCompactCmd())
 
 	return rootCmd
 }
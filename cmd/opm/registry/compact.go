@@ -0,0 +1,80 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/operator-framework/operator-registry/pkg/sqlite"
+)
+
+func newRegistryCompactCmd() *cobra.Command {
+	rootCmd := &cobra.Command{
+		Use:   "compact",
+		Short: "compact an operator registry DB",
+		Long: `Compact an operator registry DB
+
+The database is migrated to the latest version, which stores the objects of bundles once
+no matter how many bundles share them. Objects no bundle references any longer, which
+removing or overwriting bundles leaves behind, are deleted, and the database file is
+rebuilt to reclaim the space they took up.`,
+		Args: cobra.NoArgs,
+
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if debug, _ := cmd.Flags().GetBool("debug"); debug {
+				logrus.SetLevel(logrus.DebugLevel)
+			}
+			return nil
+		},
+
+		RunE: compactFunc,
+	}
+
+	rootCmd.Flags().Bool("debug", false, "enable debug logging")
+	rootCmd.Flags().StringP("database", "d", "bundles.db", "relative path to database file")
+
+	return rootCmd
+}
+
+func compactFunc(cmd *cobra.Command, _ []string) error {
+	dbName, err := cmd.Flags().GetString("database")
+	if err != nil {
+		return err
+	}
+
+	// don't create a database that doesn't exist
+	info, err := os.Stat(dbName)
+	if err != nil {
+		return err
+	}
+	before := info.Size()
+
+	db, err := sqlite.Open(dbName)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := context.TODO()
+	migrator, err := sqlite.NewSQLLiteMigrator(db)
+	if err != nil {
+		return err
+	}
+	if err := migrator.Migrate(ctx); err != nil {
+		return err
+	}
+
+	removed, err := sqlite.Compact(ctx, db)
+	if err != nil {
+		return err
+	}
+	if info, err = os.Stat(dbName); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(cmd.OutOrStdout(), "removed %d unreferenced blobs, %d bytes before, %d bytes after\n", removed, before, info.Size())
+	return err
+}
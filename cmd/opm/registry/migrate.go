@@ -0,0 +1,168 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/operator-framework/operator-registry/pkg/sqlite"
+)
+
+func newRegistryMigrateCmd() *cobra.Command {
+	rootCmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "migrate an operator registry DB up or down",
+		Long: `Migrate an operator registry DB up or down to a version
+
+Without --to, the database is migrated to the latest version. A database can be migrated
+down to serve it with an older registry server, provided every migration being reverted
+has a working down migration, as reported by 'opm registry migrate status'.`,
+		Args: cobra.NoArgs,
+
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if debug, _ := cmd.Flags().GetBool("debug"); debug {
+				logrus.SetLevel(logrus.DebugLevel)
+			}
+			// cobra only runs the nearest persistent pre-run, so run the root's too
+			if root := cmd.Root(); root != cmd && root.PersistentPreRunE != nil {
+				return root.PersistentPreRunE(cmd, args)
+			}
+			return nil
+		},
+
+		RunE:         migrateFunc,
+		SilenceUsage: true,
+	}
+
+	rootCmd.PersistentFlags().Bool("debug", false, "enable debug logging")
+	rootCmd.PersistentFlags().StringP("database", "d", "bundles.db", "relative path to database file")
+	rootCmd.Flags().Int("to", 0, "version to migrate to, the latest version if unset")
+
+	rootCmd.AddCommand(newRegistryMigrateStatusCmd())
+
+	return rootCmd
+}
+
+func newRegistryMigrateStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "show the migration status of an operator registry DB",
+		Long: `Show the version of an operator registry DB and the status of every migration
+
+Each migration is listed as applied or pending, along with whether its down migration works.
+Down migrations are checked against a copy of the given database, migrated to the latest
+version and then down, so the database itself is never modified.`,
+		Args:         cobra.NoArgs,
+		RunE:         migrateStatusFunc,
+		SilenceUsage: true,
+	}
+}
+
+func openMigrator(cmd *cobra.Command) (sqlite.VersionedMigrator, func(), error) {
+	dbName, err := cmd.Flags().GetString("database")
+	if err != nil {
+		return nil, nil, err
+	}
+	// don't create a database that doesn't exist
+	if _, err := os.Stat(dbName); err != nil {
+		return nil, nil, err
+	}
+	db, err := sqlite.Open(dbName)
+	if err != nil {
+		return nil, nil, err
+	}
+	m, err := sqlite.NewSQLLiteMigrator(db)
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	migrator, ok := m.(sqlite.VersionedMigrator)
+	if !ok {
+		db.Close()
+		return nil, nil, fmt.Errorf("migrator %T can't migrate to a version", m)
+	}
+	return migrator, func() { db.Close() }, nil
+}
+
+func migrateFunc(cmd *cobra.Command, _ []string) error {
+	migrator, closeDB, err := openMigrator(cmd)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	ctx := context.TODO()
+	from, err := migrator.Version(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !cmd.Flags().Changed("to") {
+		if err := migrator.Migrate(ctx); err != nil {
+			return err
+		}
+	} else {
+		to, err := cmd.Flags().GetInt("to")
+		if err != nil {
+			return err
+		}
+		if err := migrator.MigrateTo(ctx, to); err != nil {
+			return err
+		}
+	}
+
+	to, err := migrator.Version(ctx)
+	if err != nil {
+		return err
+	}
+	logrus.WithFields(logrus.Fields{"from": from, "to": to}).Info("migrated database")
+	return nil
+}
+
+func migrateStatusFunc(cmd *cobra.Command, _ []string) error {
+	migrator, closeDB, err := openMigrator(cmd)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	ctx := context.TODO()
+	version, err := migrator.Version(ctx)
+	if err != nil {
+		return err
+	}
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+	return writeMigrationStatus(os.Stdout, version, statuses)
+}
+
+func writeMigrationStatus(out io.Writer, version int, statuses []sqlite.MigrationStatus) error {
+	latest := sqlite.NilVersion
+	if len(statuses) > 0 {
+		latest = statuses[len(statuses)-1].Id
+	}
+	fmt.Fprintf(out, "current version: %d\n", version)
+	fmt.Fprintf(out, "latest version: %d\n\n", latest)
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "MIGRATION\tSTATUS\tDOWN")
+	for _, s := range statuses {
+		status := "pending"
+		if s.Applied {
+			status = "applied"
+		}
+		down := "ok"
+		if s.DownErr != nil {
+			down = fmt.Sprintf("failed: %v", s.DownErr)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", s.Id, status, down)
+	}
+	return w.Flush()
+}
@@ -1,6 +1,8 @@
 package registry
 
 import (
+	"fmt"
+
 	"github.com/operator-framework/operator-registry/pkg/lib/registry"
 
 	"github.com/sirupsen/logrus"
@@ -10,8 +12,12 @@ import (
 func newRegistryRmCmd() *cobra.Command {
 	rootCmd := &cobra.Command{
 		Use:   "rm",
-		Short: "remove operator from operator registry DB",
-		Long:  `Remove operator from operator registry DB`,
+		Short: "remove operators or bundles from operator registry DB",
+		Long: `Remove entire operator packages, or individual bundle images, from operator registry DB
+
+Bundles that replace a removed bundle are updated to replace the bundle it replaced, and to skip
+it. Channels headed by a removed bundle are headed by the bundle it replaced, or are removed if
+there is none. Removing the last bundle of a package's default channel is refused.`,
 
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			if debug, _ := cmd.Flags().GetBool("debug"); debug {
@@ -26,9 +32,7 @@ func newRegistryRmCmd() *cobra.Command {
 	rootCmd.Flags().Bool("debug", false, "enable debug logging")
 	rootCmd.Flags().StringP("database", "d", "bundles.db", "relative path to database file")
 	rootCmd.Flags().StringSliceP("packages", "o", nil, "comma separated list of package names to be deleted")
-	if err := rootCmd.MarkFlagRequired("packages"); err != nil {
-		logrus.Panic("Failed to set required `packages` flag for `registry rm`")
-	}
+	rootCmd.Flags().StringSliceP("bundles", "b", nil, "comma separated list of bundle images to be deleted")
 	rootCmd.Flags().Bool("permissive", false, "allow registry load errors")
 
 	return rootCmd
@@ -43,6 +47,13 @@ func rmFunc(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	bundles, err := cmd.Flags().GetStringSlice("bundles")
+	if err != nil {
+		return err
+	}
+	if (len(packages) == 0) == (len(bundles) == 0) {
+		return fmt.Errorf("exactly one of --packages or --bundles must be set")
+	}
 	permissive, err := cmd.Flags().GetBool("permissive")
 	if err != nil {
 		return err
@@ -50,11 +61,12 @@ func rmFunc(cmd *cobra.Command, args []string) error {
 
 	request := registry.DeleteFromRegistryRequest{
 		Packages:      packages,
+		Bundles:       bundles,
 		InputDatabase: fromFilename,
 		Permissive:    permissive,
 	}
 
-	logger := logrus.WithFields(logrus.Fields{"packages": packages})
+	logger := logrus.WithFields(logrus.Fields{"packages": packages, "bundles": bundles})
 
 	logger.Info("removing from the registry")
 
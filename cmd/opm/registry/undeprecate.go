@@ -0,0 +1,110 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/operator-framework/operator-registry/pkg/containertools"
+	"github.com/operator-framework/operator-registry/pkg/lib/registry"
+)
+
+func newRegistryUndeprecateCmd() *cobra.Command {
+	rootCmd := &cobra.Command{
+		Use:   "undeprecate",
+		Short: "undeprecate operator bundles in operator registry DB",
+		Long: `Undeprecate operator bundles in operator registry DB
+
+Undeprecated bundles are installable again, along with the channels they headed. The bundles
+truncated when a bundle was deprecated are not in the database anymore; to restore them into
+their channels, list their images with --restore. Without them, an undeprecated bundle remains
+the tail of its channels.`,
+
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if debug, _ := cmd.Flags().GetBool("debug"); debug {
+				logrus.SetLevel(logrus.DebugLevel)
+			}
+			return nil
+		},
+
+		RunE: undeprecateFunc,
+	}
+
+	rootCmd.Flags().Bool("debug", false, "enable debug logging")
+	rootCmd.Flags().StringP("database", "d", "bundles.db", "relative path to database file")
+	rootCmd.Flags().StringSliceP("bundles", "b", nil, "comma separated list of deprecated bundle images to undeprecate")
+	if err := rootCmd.MarkFlagRequired("bundles"); err != nil {
+		logrus.Panic("Failed to set required `bundles` flag for `registry undeprecate`")
+	}
+	rootCmd.Flags().StringSlice("restore", nil, "comma separated list of truncated bundle images to add back")
+	rootCmd.Flags().Bool("permissive", false, "allow registry load errors")
+	rootCmd.Flags().Bool("skip-tls", false, "skip TLS certificate verification for container image registries while pulling bundles")
+	rootCmd.Flags().String("ca-file", "", "the root certificates to use when --container-tool=none; see docker/podman docs for certificate loading instructions")
+	rootCmd.Flags().StringP("container-tool", "c", "none", "tool to interact with container images (save, build, etc.). One of: [none, docker, podman]")
+
+	return rootCmd
+}
+
+func undeprecateFunc(cmd *cobra.Command, _ []string) error {
+	fromFilename, err := cmd.Flags().GetString("database")
+	if err != nil {
+		return err
+	}
+	bundles, err := cmd.Flags().GetStringSlice("bundles")
+	if err != nil {
+		return err
+	}
+	restore, err := cmd.Flags().GetStringSlice("restore")
+	if err != nil {
+		return err
+	}
+	permissive, err := cmd.Flags().GetBool("permissive")
+	if err != nil {
+		return err
+	}
+	skipTLS, err := cmd.Flags().GetBool("skip-tls")
+	if err != nil {
+		return err
+	}
+	caFile, err := cmd.Flags().GetString("ca-file")
+	if err != nil {
+		return err
+	}
+	containerToolStr, err := cmd.Flags().GetString("container-tool")
+	if err != nil {
+		return err
+	}
+	containerTool := containertools.NewContainerTool(containerToolStr, containertools.NoneTool)
+
+	if caFile != "" {
+		if skipTLS {
+			return errors.New("--skip-tls must be false when --ca-file is set")
+		}
+		if containerTool != containertools.NoneTool {
+			return fmt.Errorf("--ca-file cannot be set with --container-tool=%[1]s; "+
+				"certificates must be configured specifically for %[1]s", containerTool)
+		}
+	}
+
+	request := registry.UndeprecateFromRegistryRequest{
+		Permissive:    permissive,
+		SkipTLS:       skipTLS,
+		CaFile:        caFile,
+		InputDatabase: fromFilename,
+		Bundles:       bundles,
+		Restore:       restore,
+		ContainerTool: containerTool,
+	}
+
+	logger := logrus.WithFields(logrus.Fields{"bundles": bundles, "restore": restore})
+
+	if skipTLS {
+		logger.Warn("--skip-tls flag is set: this mode is insecure and meant for development purposes only.")
+	}
+
+	logger.Info("undeprecating bundles in the registry")
+
+	return registry.NewRegistryUndeprecator(logger).UndeprecateFromRegistry(request)
+}
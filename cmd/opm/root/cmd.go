@@ -1,16 +1,24 @@
 package root
 
 import (
+	"context"
+
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
 	"github.com/operator-framework/operator-registry/cmd/opm/alpha"
 	"github.com/operator-framework/operator-registry/cmd/opm/index"
 	"github.com/operator-framework/operator-registry/cmd/opm/registry"
+	"github.com/operator-framework/operator-registry/cmd/opm/serve"
 	"github.com/operator-framework/operator-registry/cmd/opm/version"
+	"github.com/operator-framework/operator-registry/pkg/lib/tracing"
 )
 
 func NewCmd() *cobra.Command {
+	var (
+		traceOutput string
+		stopTracing = func(context.Context) error { return nil }
+	)
 	cmd := &cobra.Command{
 		Use:   "opm",
 		Short: "operator package manager",
@@ -21,12 +29,24 @@ func NewCmd() *cobra.Command {
 			}
 			return nil
 		},
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			stop, err := tracing.Setup(traceOutput, "opm")
+			if err != nil {
+				return err
+			}
+			stopTracing = stop
+			return nil
+		},
+		PersistentPostRunE: func(cmd *cobra.Command, args []string) error {
+			return stopTracing(context.Background())
+		},
 	}
 
-	cmd.AddCommand(registry.NewOpmRegistryCmd(), alpha.NewCmd())
+	cmd.AddCommand(registry.NewOpmRegistryCmd(), alpha.NewCmd(), serve.NewCmd())
 	index.AddCommand(cmd)
 	version.AddCommand(cmd)
 
+	cmd.PersistentFlags().StringVar(&traceOutput, "trace-output", "", "write OpenTelemetry spans as JSON to a file, or to standard output if \"stdout\"")
 	cmd.Flags().Bool("debug", false, "enable debug logging")
 	if err := cmd.Flags().MarkHidden("debug"); err != nil {
 		logrus.Panic(err.Error())
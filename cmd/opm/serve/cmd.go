@@ -0,0 +1,39 @@
+package serve
+
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/operator-framework/operator-registry/pkg/lib/serve"
+)
+
+func NewCmd() *cobra.Command {
+	var (
+		opts  serve.Options
+		debug bool
+	)
+	logger := logrus.New()
+	cmd := &cobra.Command{
+		Use:   "serve <sqlite_file | config_dir | index_image>",
+		Short: "serve a catalog via grpc",
+		Long: `serve a catalog via grpc
+
+The source is detected automatically. It is either a sqlite database file, a directory
+of declarative configs, or a reference to an index image holding either of them. With
+--poll-interval set, an image reference is periodically resolved and the catalog is
+reloaded whenever it points to a new digest.`,
+		Args: cobra.ExactArgs(1),
+		PreRun: func(_ *cobra.Command, _ []string) {
+			if debug {
+				logger.SetLevel(logrus.DebugLevel)
+			}
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return serve.Serve(cmd.Context(), args[0], opts, logrus.NewEntry(logger))
+		},
+	}
+
+	cmd.Flags().BoolVar(&debug, "debug", false, "enable debug logging")
+	opts.AddFlags(cmd.Flags())
+	return cmd
+}
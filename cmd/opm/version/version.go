@@ -5,16 +5,8 @@ import (
 	"runtime"
 
 	"github.com/spf13/cobra"
-)
 
-var (
-	// opmVersion is the constant representing the version of the opm binary
-	opmVersion = "unknown"
-	// gitCommit is a constant representing the source version that
-	// generated this build. It should be set during build via -ldflags.
-	gitCommit string
-	// buildDate in ISO8601 format, output of $(date -u +'%Y-%m-%dT%H:%M:%SZ')
-	buildDate string
+	"github.com/operator-framework/operator-registry/pkg/version"
 )
 
 type Version struct {
@@ -27,9 +19,9 @@ type Version struct {
 
 func getVersion() Version {
 	return Version{
-		OpmVersion: opmVersion,
-		GitCommit:  gitCommit,
-		BuildDate:  buildDate,
+		OpmVersion: version.OpmVersion,
+		GitCommit:  version.GitCommit,
+		BuildDate:  version.BuildDate,
 		GoOs:       runtime.GOOS,
 		GoArch:     runtime.GOARCH,
 	}
@@ -0,0 +1,35 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/operator-framework/operator-registry/internal/declcfg"
+	"github.com/operator-framework/operator-registry/pkg/image"
+)
+
+// IndexImage loads the catalog held by an index image, which may contain either a
+// declarative config directory or a sqlite database.
+type IndexImage struct {
+	Ref      string
+	Registry image.Registry
+}
+
+func (i IndexImage) Run(ctx context.Context) (*declcfg.DeclarativeConfig, error) {
+	ref := image.SimpleReference(i.Ref)
+	labels, tmpDir, err := unpackImage(ctx, i.Registry, ref)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	cfg, ok, err := indexToDeclcfg(ctx, labels, tmpDir)
+	if !ok {
+		return nil, fmt.Errorf("load index image %q: %v", ref, unknownImageTypeError(labels))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load index image %q: %v", ref, err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,59 @@
+package action_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/internal/action"
+	"github.com/operator-framework/operator-registry/internal/declcfg"
+)
+
+func TestIndexImage(t *testing.T) {
+	type spec struct {
+		name      string
+		ref       string
+		assertion require.ErrorAssertionFunc
+	}
+
+	registry, err := newRegistry()
+	require.NoError(t, err)
+
+	specs := []spec{
+		{
+			name:      "Success/SqliteIndexImage",
+			ref:       "test.registry/foo-operator/foo-index-sqlite:v0.2.0",
+			assertion: require.NoError,
+		},
+		{
+			name:      "Success/DeclcfgIndexImage",
+			ref:       "test.registry/foo-operator/foo-index-declcfg:v0.2.0",
+			assertion: require.NoError,
+		},
+		{
+			name:      "Fail/BundleImage",
+			ref:       "test.registry/foo-operator/foo-bundle:v0.2.0",
+			assertion: require.Error,
+		},
+		{
+			name:      "Fail/MissingImage",
+			ref:       "test.registry/foo-operator/foo-index:missing",
+			assertion: require.Error,
+		},
+	}
+
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			cfg, err := action.IndexImage{Ref: s.ref, Registry: registry}.Run(context.Background())
+			s.assertion(t, err)
+			if err != nil {
+				return
+			}
+			m, err := declcfg.ConvertToModel(context.TODO(), *cfg)
+			require.NoError(t, err)
+			require.Contains(t, m, "foo")
+			require.Len(t, m["foo"].Channels["beta"].Bundles, 2)
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -51,7 +52,7 @@ func (r Render) Run(ctx context.Context) (*declcfg.DeclarativeConfig, error) {
 		)
 		// TODO(joelanford): Add support for detecting and rendering sqlite files.
 		if stat, serr := os.Stat(ref); serr == nil && stat.IsDir() {
-			cfg, err = declcfg.LoadFS(os.DirFS(ref))
+			cfg, err = declcfg.LoadFS(ctx, os.DirFS(ref))
 		} else {
 			cfg, err = r.imageToDeclcfg(ctx, ref)
 		}
@@ -87,57 +88,71 @@ func (r Render) createRegistry() (*containerdregistry.Registry, error) {
 
 func (r Render) imageToDeclcfg(ctx context.Context, imageRef string) (*declcfg.DeclarativeConfig, error) {
 	ref := image.SimpleReference(imageRef)
-	if err := r.Registry.Pull(ctx, ref); err != nil {
+	labels, tmpDir, err := unpackImage(ctx, r.Registry, ref)
+	if err != nil {
 		return nil, err
 	}
-	labels, err := r.Registry.Labels(ctx, ref)
+	defer os.RemoveAll(tmpDir)
+
+	if cfg, ok, err := indexToDeclcfg(ctx, labels, tmpDir); ok {
+		return cfg, err
+	}
+	if _, ok := labels[bundle.PackageLabel]; ok {
+		img, err := registry.NewImageInput(ref, tmpDir)
+		if err != nil {
+			return nil, err
+		}
+
+		return bundleToDeclcfg(img.Bundle)
+	}
+	return nil, fmt.Errorf("render %q: %v", ref, unknownImageTypeError(labels))
+}
+
+// unpackImage pulls ref and unpacks it into a new temporary directory, returning the image labels and the directory.
+// The caller is responsible for removing the directory.
+func unpackImage(ctx context.Context, reg image.Registry, ref image.Reference) (map[string]string, string, error) {
+	if err := reg.Pull(ctx, ref); err != nil {
+		return nil, "", err
+	}
+	labels, err := reg.Labels(ctx, ref)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	tmpDir, err := ioutil.TempDir("", "render-unpack-")
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
-	defer os.RemoveAll(tmpDir)
-	if err := r.Registry.Unpack(ctx, ref, tmpDir); err != nil {
-		return nil, err
+	if err := reg.Unpack(ctx, ref, tmpDir); err != nil {
+		os.RemoveAll(tmpDir)
+		return nil, "", err
 	}
+	return labels, tmpDir, nil
+}
 
-	var cfg *declcfg.DeclarativeConfig
+// indexToDeclcfg loads the catalog of an index image unpacked at root, using the image labels to locate either
+// a sqlite database or a declarative config directory. It returns false if the labels locate neither.
+func indexToDeclcfg(ctx context.Context, labels map[string]string, root string) (*declcfg.DeclarativeConfig, bool, error) {
 	if dbFile, ok := labels[containertools.DbLocationLabel]; ok {
-		cfg, err = sqliteToDeclcfg(ctx, filepath.Join(tmpDir, dbFile))
-		if err != nil {
-			return nil, err
-		}
-	} else if configsDir, ok := labels["operators.operatorframework.io.index.configs.v1"]; ok {
-		// TODO(joelanford): Make a constant for above configs location label
-		cfg, err = declcfg.LoadFS(os.DirFS(filepath.Join(tmpDir, configsDir)))
-		if err != nil {
-			return nil, err
-		}
-	} else if _, ok := labels[bundle.PackageLabel]; ok {
-		img, err := registry.NewImageInput(ref, tmpDir)
-		if err != nil {
-			return nil, err
-		}
+		cfg, err := sqliteToDeclcfg(ctx, filepath.Join(root, dbFile))
+		return cfg, true, err
+	}
+	if configsDir, ok := labels[containertools.ConfigsLocationLabel]; ok {
+		cfg, err := declcfg.LoadFS(ctx, os.DirFS(filepath.Join(root, configsDir)))
+		return cfg, true, err
+	}
+	return nil, false, nil
+}
 
-		cfg, err = bundleToDeclcfg(img.Bundle)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		labelKeys := sets.StringKeySet(labels)
-		labelVals := []string{}
-		for _, k := range labelKeys.List() {
-			labelVals = append(labelVals, fmt.Sprintf("  %s=%s", k, labels[k]))
-		}
-		if len(labelVals) > 0 {
-			return nil, fmt.Errorf("render %q: image type could not be determined, found labels\n%s", ref, strings.Join(labelVals, "\n"))
-		} else {
-			return nil, fmt.Errorf("render %q: image type could not be determined: image has no labels", ref)
-		}
+func unknownImageTypeError(labels map[string]string) error {
+	labelKeys := sets.StringKeySet(labels)
+	labelVals := []string{}
+	for _, k := range labelKeys.List() {
+		labelVals = append(labelVals, fmt.Sprintf("  %s=%s", k, labels[k]))
+	}
+	if len(labelVals) > 0 {
+		return fmt.Errorf("image type could not be determined, found labels\n%s", strings.Join(labelVals, "\n"))
 	}
-	return cfg, nil
+	return fmt.Errorf("image type could not be determined: image has no labels")
 }
 
 func sqliteToDeclcfg(ctx context.Context, dbFile string) (*declcfg.DeclarativeConfig, error) {
@@ -165,6 +180,17 @@ func sqliteToDeclcfg(ctx context.Context, dbFile string) (*declcfg.DeclarativeCo
 	}
 
 	cfg := declcfg.ConvertFromModel(m)
+
+	// provenance is carried by renders only, since it isn't part of the catalog that is served
+	provenance, err := q.ListBundleProvenance(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i, b := range cfg.Bundles {
+		for _, r := range provenance[b.Name] {
+			cfg.Bundles[i].Properties = append(cfg.Bundles[i].Properties, property.MustBuildProvenance(r.AddedAt.UTC().Format(time.RFC3339), r.SourceDigest, r.OpmVersion, string(r.Operation)))
+		}
+	}
 	return &cfg, nil
 }
 
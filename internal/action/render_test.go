@@ -4,6 +4,9 @@ import (
 	"context"
 	"embed"
 	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -15,6 +18,7 @@ import (
 	"github.com/operator-framework/operator-registry/pkg/containertools"
 	"github.com/operator-framework/operator-registry/pkg/image"
 	"github.com/operator-framework/operator-registry/pkg/lib/bundle"
+	"github.com/operator-framework/operator-registry/pkg/sqlite"
 )
 
 func TestRender(t *testing.T) {
@@ -229,6 +233,55 @@ func TestRender(t *testing.T) {
 	}
 }
 
+func TestRenderProvenance(t *testing.T) {
+	ctx := context.TODO()
+	dir, err := ioutil.TempDir("", "render-provenance-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	index, err := sqliteImage.ReadFile("testdata/foo-index-v0.2.0-sqlite/database/index.db")
+	require.NoError(t, err)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "database"), 0755))
+	dbFile := filepath.Join(dir, "database", "index.db")
+	require.NoError(t, ioutil.WriteFile(dbFile, index, 0644))
+
+	db, err := sqlite.Open(dbFile)
+	require.NoError(t, err)
+	migrator, err := sqlite.NewSQLLiteMigrator(db)
+	require.NoError(t, err)
+	require.NoError(t, migrator.Migrate(ctx))
+	_, err = db.Exec(`INSERT INTO provenance(operatorbundle_name, added_at, source_digest, opm_version, operation) VALUES (?, ?, ?, ?, ?)`,
+		"foo.v0.1.0", "2021-01-01T00:00:00Z", "sha256:abc", "v1.17.0", "add")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	render := action.Render{
+		Refs: []string{"test.registry/foo-operator/foo-index-sqlite:v0.2.0"},
+		Registry: &image.MockRegistry{
+			RemoteImages: map[image.Reference]*image.MockImage{
+				image.SimpleReference("test.registry/foo-operator/foo-index-sqlite:v0.2.0"): {
+					Labels: map[string]string{containertools.DbLocationLabel: "/database/index.db"},
+					FS:     os.DirFS(dir),
+				},
+			},
+		},
+	}
+	cfg, err := render.Run(ctx)
+	require.NoError(t, err)
+	require.Len(t, cfg.Bundles, 2)
+	for _, b := range cfg.Bundles {
+		props, err := property.Parse(b.Properties)
+		require.NoError(t, err)
+		if b.Name != "foo.v0.1.0" {
+			require.Empty(t, props.Provenances)
+			continue
+		}
+		require.Equal(t, []property.Provenance{
+			{AddedAt: "2021-01-01T00:00:00Z", SourceDigest: "sha256:abc", OpmVersion: "v1.17.0", Operation: "add"},
+		}, props.Provenances)
+	}
+}
+
 //go:embed testdata/foo-bundle-v0.1.0/manifests/*
 //go:embed testdata/foo-bundle-v0.1.0/metadata/*
 var bundleImageV1 embed.FS
@@ -270,7 +323,7 @@ func newRegistry() (image.Registry, error) {
 			},
 			image.SimpleReference("test.registry/foo-operator/foo-index-declcfg:v0.2.0"): &image.MockImage{
 				Labels: map[string]string{
-					"operators.operatorframework.io.index.configs.v1": "/foo",
+					containertools.ConfigsLocationLabel: "/foo",
 				},
 				FS: subDeclcfgImage,
 			},
@@ -1,13 +1,21 @@
 package declcfg
 
 import (
+	"context"
 	"fmt"
 
+	"go.opentelemetry.io/otel/attribute"
+
 	"github.com/operator-framework/operator-registry/internal/model"
 	"github.com/operator-framework/operator-registry/internal/property"
+	"github.com/operator-framework/operator-registry/pkg/lib/tracing"
 )
 
-func ConvertToModel(cfg DeclarativeConfig) (model.Model, error) {
+func ConvertToModel(ctx context.Context, cfg DeclarativeConfig) (_ model.Model, err error) {
+	_, span := tracing.Start(ctx, "declcfg.ConvertToModel",
+		attribute.Int("packages", len(cfg.Packages)), attribute.Int("bundles", len(cfg.Bundles)))
+	defer func() { tracing.End(span, err) }()
+
 	mpkgs := model.Model{}
 	defaultChannels := map[string]string{}
 	for _, p := range cfg.Packages {
@@ -1,6 +1,7 @@
 package declcfg
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -91,7 +92,7 @@ func TestConvertToModel(t *testing.T) {
 
 	for _, s := range specs {
 		t.Run(s.name, func(t *testing.T) {
-			_, err := ConvertToModel(s.cfg)
+			_, err := ConvertToModel(context.TODO(), s.cfg)
 			s.assertion(t, err)
 		})
 	}
@@ -100,7 +101,7 @@ func TestConvertToModel(t *testing.T) {
 func TestConvertToModelRoundtrip(t *testing.T) {
 	expected := buildValidDeclarativeConfig(true)
 
-	m, err := ConvertToModel(expected)
+	m, err := ConvertToModel(context.TODO(), expected)
 	require.NoError(t, err)
 	actual := ConvertFromModel(m)
 
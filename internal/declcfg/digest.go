@@ -0,0 +1,67 @@
+package declcfg
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/operator-framework/operator-registry/internal/model"
+	"github.com/operator-framework/operator-registry/internal/property"
+)
+
+// Digest returns a digest of the content of cfg that a server loading it would serve. It doesn't depend
+// on how the config is split into files or ordered, nor on whether bundle objects are inlined or
+// referenced. Blobs of unknown schemas aren't served, so they don't contribute to it.
+// Nor do provenance records, which describe how the catalog was built rather than what it serves.
+func Digest(ctx context.Context, cfg DeclarativeConfig) (digest.Digest, error) {
+	m, err := ConvertToModel(ctx, cfg)
+	if err != nil {
+		return "", err
+	}
+	return DigestModel(m)
+}
+
+// DigestModel returns a digest of the content of m. Two catalogs have the same digest if and only if
+// they serve the same content, however each was loaded. Properties are hashed normalized, as
+// model.Normalize normalizes them, but m itself isn't modified, so it may be served concurrently.
+func DigestModel(m model.Model) (digest.Digest, error) {
+	cfg := ConvertFromModel(m)
+	for i, b := range cfg.Bundles {
+		props := make([]property.Property, 0, len(b.Properties)+len(b.Objects))
+		for _, p := range b.Properties {
+			if p.Type != property.TypeBundleObject && p.Type != property.TypeProvenance {
+				props = append(props, p)
+			}
+		}
+		model.NormalizeProperties(props)
+		for _, obj := range b.Objects {
+			props = append(props, property.MustBuildBundleObjectData([]byte(obj)))
+		}
+		sort.Slice(props, func(i, j int) bool {
+			if props[i].Type != props[j].Type {
+				return props[i].Type < props[j].Type
+			}
+			return string(props[i].Value) < string(props[j].Value)
+		})
+		cfg.Bundles[i].Properties = props
+
+		relatedImages := append([]RelatedImage(nil), b.RelatedImages...)
+		sort.Slice(relatedImages, func(i, j int) bool {
+			if relatedImages[i].Name != relatedImages[j].Name {
+				return relatedImages[i].Name < relatedImages[j].Name
+			}
+			return relatedImages[i].Image < relatedImages[j].Image
+		})
+		cfg.Bundles[i].RelatedImages = relatedImages
+	}
+
+	digester := digest.Canonical.Digester()
+	enc := json.NewEncoder(digester.Hash())
+	enc.SetEscapeHTML(false)
+	if err := writeToEncoder(cfg, enc); err != nil {
+		return "", err
+	}
+	return digester.Digest(), nil
+}
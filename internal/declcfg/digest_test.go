@@ -0,0 +1,119 @@
+package declcfg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/internal/property"
+)
+
+func TestDigest(t *testing.T) {
+	expected, err := Digest(context.TODO(), buildValidDeclarativeConfig(false))
+	require.NoError(t, err)
+	require.NoError(t, expected.Validate())
+
+	type spec struct {
+		name   string
+		modify func(*DeclarativeConfig)
+		same   bool
+	}
+	specs := []spec{
+		{
+			name:   "Unchanged",
+			modify: func(*DeclarativeConfig) {},
+			same:   true,
+		},
+		{
+			name: "ReorderedBlobs",
+			modify: func(cfg *DeclarativeConfig) {
+				for i, j := 0, len(cfg.Bundles)-1; i < j; i, j = i+1, j-1 {
+					cfg.Bundles[i], cfg.Bundles[j] = cfg.Bundles[j], cfg.Bundles[i]
+				}
+				cfg.Packages[0], cfg.Packages[1] = cfg.Packages[1], cfg.Packages[0]
+			},
+			same: true,
+		},
+		{
+			name: "ReorderedProperties",
+			modify: func(cfg *DeclarativeConfig) {
+				props := cfg.Bundles[1].Properties
+				for i, j := 0, len(props)-1; i < j; i, j = i+1, j-1 {
+					props[i], props[j] = props[j], props[i]
+				}
+			},
+			same: true,
+		},
+		{
+			name: "InlinedBundleObjects",
+			modify: func(cfg *DeclarativeConfig) {
+				for i, b := range cfg.Bundles {
+					var props []property.Property
+					for _, p := range b.Properties {
+						if p.Type != property.TypeBundleObject {
+							props = append(props, p)
+						}
+					}
+					for _, obj := range b.Objects {
+						props = append(props, property.MustBuildBundleObjectData([]byte(obj)))
+					}
+					cfg.Bundles[i].Properties = props
+				}
+			},
+			same: true,
+		},
+		{
+			name: "UnrecognizedSchemas",
+			modify: func(cfg *DeclarativeConfig) {
+				cfg.Others = buildValidDeclarativeConfig(true).Others
+			},
+			same: true,
+		},
+		{
+			name: "ChangedImage",
+			modify: func(cfg *DeclarativeConfig) {
+				cfg.Bundles[0].Image = "anakin-bundle:v0.0.1-1"
+			},
+		},
+		{
+			name: "ChangedDefaultChannel",
+			modify: func(cfg *DeclarativeConfig) {
+				cfg.Packages[0].DefaultChannel = "light"
+			},
+		},
+		{
+			name: "ChangedObject",
+			modify: func(cfg *DeclarativeConfig) {
+				cfg.Bundles[0].Objects[1] = `{"kind": "CustomResourceDefinition", "apiVersion": "apiextensions.k8s.io/v1beta1"}`
+			},
+		},
+		{
+			name: "RemovedBundle",
+			modify: func(cfg *DeclarativeConfig) {
+				cfg.Bundles = cfg.Bundles[:len(cfg.Bundles)-1]
+			},
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			cfg := buildValidDeclarativeConfig(false)
+			s.modify(&cfg)
+			actual, err := Digest(context.TODO(), cfg)
+			require.NoError(t, err)
+			if s.same {
+				require.Equal(t, expected, actual)
+			} else {
+				require.NotEqual(t, expected, actual)
+			}
+		})
+	}
+}
+
+func TestDigestModelDoesNotModify(t *testing.T) {
+	m := buildTestModel()
+	before := ConvertFromModel(m)
+	_, err := DigestModel(m)
+	require.NoError(t, err)
+	equalsDeclarativeConfig(t, before, ConvertFromModel(m))
+}
@@ -1,6 +1,7 @@
 package declcfg
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -11,10 +12,12 @@ import (
 
 	"github.com/joelanford/ignore"
 	"github.com/operator-framework/api/pkg/operators"
+	"go.opentelemetry.io/otel/attribute"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/util/yaml"
 
 	"github.com/operator-framework/operator-registry/internal/property"
+	"github.com/operator-framework/operator-registry/pkg/lib/tracing"
 )
 
 // LoadFS loads a declarative config from the provided root FS. LoadFS walks the
@@ -22,7 +25,10 @@ import (
 // that match patterns found in .indexignore files found throughout the filesystem.
 // If LoadFS encounters an error loading or parsing any file, the error will be
 // immedidately returned.
-func LoadFS(root fs.FS) (*DeclarativeConfig, error) {
+func LoadFS(ctx context.Context, root fs.FS) (_ *DeclarativeConfig, err error) {
+	_, span := tracing.Start(ctx, "declcfg.LoadFS")
+	defer func() { tracing.End(span, err) }()
+
 	if root == nil {
 		return nil, fmt.Errorf("no declarative config filesystem provided")
 	}
@@ -52,6 +58,7 @@ func LoadFS(root fs.FS) (*DeclarativeConfig, error) {
 	}); err != nil {
 		return nil, fmt.Errorf("failed to read declarative configs dir: %v", err)
 	}
+	span.SetAttributes(attribute.Int("packages", len(cfg.Packages)), attribute.Int("bundles", len(cfg.Bundles)))
 	return cfg, nil
 }
 
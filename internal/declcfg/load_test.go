@@ -1,6 +1,7 @@
 package declcfg
 
 import (
+	"context"
 	"encoding/json"
 	"io/fs"
 	"os"
@@ -270,7 +271,7 @@ func TestLoadFS(t *testing.T) {
 
 	for _, s := range specs {
 		t.Run(s.name, func(t *testing.T) {
-			cfg, err := LoadFS(s.fsys)
+			cfg, err := LoadFS(context.TODO(), s.fsys)
 			s.assertion(t, err)
 			if err == nil {
 				require.NotNil(t, cfg)
@@ -289,17 +289,21 @@ func (m Model) Normalize() {
 	for _, pkg := range m {
 		for _, ch := range pkg.Channels {
 			for _, b := range ch.Bundles {
-				for i := range b.Properties {
-					// Ensure property value is encoded in a standard way.
-					if normalized, err := property.Build(&b.Properties[i]); err == nil {
-						b.Properties[i] = *normalized
-					}
-				}
+				NormalizeProperties(b.Properties)
 			}
 		}
 	}
 }
 
+// NormalizeProperties encodes the value of each of props in a standard way, in place.
+func NormalizeProperties(props []property.Property) {
+	for i := range props {
+		if normalized, err := property.Build(&props[i]); err == nil {
+			props[i] = *normalized
+		}
+	}
+}
+
 func (m Model) AddBundle(b Bundle) {
 	if _, present := m[b.Package.Name]; !present {
 		m[b.Package.Name] = b.Package
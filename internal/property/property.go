@@ -68,6 +68,14 @@ type BundleObject struct {
 	File `json:",inline"`
 }
 
+// Provenance is an operation on a bundle recorded by an index database.
+type Provenance struct {
+	AddedAt      string `json:"addedAt"`
+	SourceDigest string `json:"sourceDigest,omitempty"`
+	OpmVersion   string `json:"opmVersion,omitempty"`
+	Operation    string `json:"operation"`
+}
+
 type File struct {
 	ref  string
 	data []byte
@@ -129,6 +137,7 @@ type Properties struct {
 	Skips            []Skips
 	SkipRanges       []SkipRange
 	BundleObjects    []BundleObject
+	Provenances      []Provenance
 
 	Others []Property
 }
@@ -142,6 +151,7 @@ const (
 	TypeSkips           = "olm.skips"
 	TypeSkipRange       = "olm.skipRange"
 	TypeBundleObject    = "olm.bundle.object"
+	TypeProvenance      = "olm.provenance"
 )
 
 func Parse(in []Property) (*Properties, error) {
@@ -196,6 +206,12 @@ func Parse(in []Property) (*Properties, error) {
 				return nil, ParseError{Idx: i, Typ: prop.Type, Err: err}
 			}
 			out.BundleObjects = append(out.BundleObjects, p)
+		case TypeProvenance:
+			var p Provenance
+			if err := json.Unmarshal(prop.Value, &p); err != nil {
+				return nil, ParseError{Idx: i, Typ: prop.Type, Err: err}
+			}
+			out.Provenances = append(out.Provenances, p)
 		default:
 			var p json.RawMessage
 			if err := json.Unmarshal(prop.Value, &p); err != nil {
@@ -308,3 +324,7 @@ func MustBuildBundleObjectRef(ref string) Property {
 func MustBuildBundleObjectData(data []byte) Property {
 	return MustBuild(&BundleObject{File: File{data: data}})
 }
+
+func MustBuildProvenance(addedAt, sourceDigest, opmVersion, operation string) Property {
+	return MustBuild(&Provenance{AddedAt: addedAt, SourceDigest: sourceDigest, OpmVersion: opmVersion, Operation: operation})
+}
@@ -288,6 +288,13 @@ func TestParse(t *testing.T) {
 			},
 			assertion: assert.Error,
 		},
+		{
+			name: "Error/InvalidProvenance",
+			input: []Property{
+				{Type: TypeProvenance, Value: json.RawMessage(`{`)},
+			},
+			assertion: assert.Error,
+		},
 		{
 			name: "Error/InvalidOther",
 			input: []Property{
@@ -314,6 +321,7 @@ func TestParse(t *testing.T) {
 				MustBuildSkipRange("<0.2.0-0"),
 				MustBuildBundleObjectRef("testref1"),
 				MustBuildBundleObjectData([]byte("testdata2")),
+				MustBuildProvenance("2021-01-01T00:00:00Z", "sha256:abc", "v1.17.0", "add"),
 				{Type: "otherType1", Value: json.RawMessage(`{"v":"otherValue1"}`)},
 				{Type: "otherType2", Value: json.RawMessage(`["otherValue2"]`)},
 			},
@@ -350,6 +358,9 @@ func TestParse(t *testing.T) {
 					{File: File{ref: "testref1"}},
 					{File: File{data: []byte("testdata2")}},
 				},
+				Provenances: []Provenance{
+					{"2021-01-01T00:00:00Z", "sha256:abc", "v1.17.0", "add"},
+				},
 				Others: []Property{
 					{Type: "otherType1", Value: json.RawMessage(`{"v":"otherValue1"}`)},
 					{Type: "otherType2", Value: json.RawMessage(`["otherValue2"]`)},
@@ -24,6 +24,8 @@ func TestConvertModelBundleToAPIBundle(t *testing.T) {
 	modelBundle.Package = &model.Package{Name: "etcd"}
 	modelBundle.Channel = &model.Channel{Name: "singlenamespace-alpha"}
 	expected := testAPIBundle()
+	// plural names are read from the bundle's CRDs
+	expected.ProvidedApis[0].Plural = "etcdbackups"
 	expected.Properties = append(expected.Properties,
 		&Property{Type: "olm.channel", Value: "{\"name\":\"singlenamespace-alpha\",\"replaces\":\"etcdoperator.v0.9.2\"}"},
 		&Property{Type: "olm.package.required", Value: "{\"packageName\":\"test\",\"versionRange\":\">=1.2.3 <2.0.0-0\"}"},
@@ -3,6 +3,9 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
 
 	"github.com/operator-framework/operator-registry/internal/model"
 	"github.com/operator-framework/operator-registry/internal/property"
@@ -22,13 +25,14 @@ func ConvertModelBundleToAPIBundle(b model.Bundle) (*Bundle, error) {
 	if err != nil {
 		return nil, fmt.Errorf("convert model properties to api dependencies: %v", err)
 	}
+	plurals := apiPlurals(b.CsvJSON, b.Objects)
 	return &Bundle{
 		CsvName:      b.Name,
 		PackageName:  b.Package.Name,
 		ChannelName:  b.Channel.Name,
 		BundlePath:   b.Image,
-		ProvidedApis: gvksProvidedtoAPIGVKs(props.GVKs),
-		RequiredApis: gvksRequirestoAPIGVKs(props.GVKsRequired),
+		ProvidedApis: gvksProvidedtoAPIGVKs(props.GVKs, plurals),
+		RequiredApis: gvksRequirestoAPIGVKs(props.GVKsRequired, plurals),
 		Version:      props.Packages[0].Version,
 		SkipRange:    skipRange,
 		Dependencies: apiDeps,
@@ -57,29 +61,89 @@ func parseProperties(in []property.Property) (*property.Properties, error) {
 	return props, nil
 }
 
-func gvksProvidedtoAPIGVKs(in []property.GVK) []*GroupVersionKind {
+func gvksProvidedtoAPIGVKs(in []property.GVK, plurals map[property.GVK]string) []*GroupVersionKind {
 	var out []*GroupVersionKind
 	for _, gvk := range in {
 		out = append(out, &GroupVersionKind{
 			Group:   gvk.Group,
 			Version: gvk.Version,
 			Kind:    gvk.Kind,
+			Plural:  plurals[gvk],
 		})
 	}
 	return out
 }
-func gvksRequirestoAPIGVKs(in []property.GVKRequired) []*GroupVersionKind {
+func gvksRequirestoAPIGVKs(in []property.GVKRequired, plurals map[property.GVK]string) []*GroupVersionKind {
 	var out []*GroupVersionKind
 	for _, gvk := range in {
 		out = append(out, &GroupVersionKind{
 			Group:   gvk.Group,
 			Version: gvk.Version,
 			Kind:    gvk.Kind,
+			Plural:  plurals[property.GVK{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind}],
 		})
 	}
 	return out
 }
 
+// apiPlurals returns the plural names of the APIs a bundle provides and requires, read from its CRDs and
+// CSV the way a sqlite database records them when the bundle is added. Properties don't carry plural
+// names, so bundles without objects have none; objects that can't be decoded are skipped.
+func apiPlurals(csvJSON string, objects []string) map[property.GVK]string {
+	plurals := map[property.GVK]string{}
+	for _, obj := range objects {
+		var crd struct {
+			Kind string `json:"kind"`
+			Spec struct {
+				Group    string `json:"group"`
+				Version  string `json:"version"`
+				Versions []struct {
+					Name string `json:"name"`
+				} `json:"versions"`
+				Names struct {
+					Kind   string `json:"kind"`
+					Plural string `json:"plural"`
+				} `json:"names"`
+			} `json:"spec"`
+		}
+		if err := json.Unmarshal([]byte(obj), &crd); err != nil || crd.Kind != "CustomResourceDefinition" {
+			continue
+		}
+		versions := []string{crd.Spec.Version}
+		for _, v := range crd.Spec.Versions {
+			versions = append(versions, v.Name)
+		}
+		for _, v := range versions {
+			if v != "" {
+				plurals[property.GVK{Group: crd.Spec.Group, Version: v, Kind: crd.Spec.Names.Kind}] = crd.Spec.Names.Plural
+			}
+		}
+	}
+
+	var csv v1alpha1.ClusterServiceVersion
+	if err := json.Unmarshal([]byte(csvJSON), &csv); err != nil {
+		return plurals
+	}
+	for _, crd := range csv.Spec.CustomResourceDefinitions.Required {
+		parts := strings.SplitN(crd.Name, ".", 2)
+		if len(parts) < 2 {
+			continue
+		}
+		gvk := property.GVK{Group: parts[1], Version: crd.Version, Kind: crd.Kind}
+		if _, ok := plurals[gvk]; !ok {
+			plurals[gvk] = parts[0]
+		}
+	}
+	apis := append(csv.Spec.APIServiceDefinitions.Owned, csv.Spec.APIServiceDefinitions.Required...)
+	for _, api := range apis {
+		gvk := property.GVK{Group: api.Group, Version: api.Version, Kind: api.Kind}
+		if _, ok := plurals[gvk]; !ok {
+			plurals[gvk] = api.Name
+		}
+	}
+	return plurals
+}
+
 func convertModelPropertiesToAPIProperties(props []property.Property) []*Property {
 	var out []*Property
 	for _, prop := range props {
@@ -0,0 +1,493 @@
+package client
+
+import (
+	"container/list"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/grpc/status"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+)
+
+// Names of the cached CatalogInterface methods, used to configure per-method TTLs.
+const (
+	MethodGetBundle                            = "GetBundle"
+	MethodGetBundleInPackageChannel            = "GetBundleInPackageChannel"
+	MethodGetReplacementBundleInPackageChannel = "GetReplacementBundleInPackageChannel"
+	MethodGetBundleThatProvides                = "GetBundleThatProvides"
+	MethodListBundles                          = "ListBundles"
+	MethodListPackages                         = "ListPackages"
+	MethodGetPackage                           = "GetPackage"
+	MethodGetChannelEntriesThatReplace         = "GetChannelEntriesThatReplace"
+	MethodGetChannelEntriesThatProvide         = "GetChannelEntriesThatProvide"
+	MethodGetLatestChannelEntriesThatProvide   = "GetLatestChannelEntriesThatProvide"
+)
+
+type CacheOptions struct {
+	// DefaultTTL is how long results are cached for methods without a TTL of their own.
+	DefaultTTL time.Duration
+	// TTLs overrides DefaultTTL per method name. A TTL of zero disables caching for the method.
+	TTLs map[string]time.Duration
+	// BundleCacheSize bounds the number of GetBundle results kept, evicting the least recently used.
+	BundleCacheSize int
+	// CatalogDigest returns an identifier of the catalog the server is serving, such as Client.CatalogDigest.
+	// When set, HealthCheck invalidates the cache whenever the identifier changes.
+	CatalogDigest func(ctx context.Context) (string, error)
+	// FetchTimeout bounds each call made on behalf of coalesced lookups, which doesn't end when one of
+	// the callers waiting for it gives up.
+	FetchTimeout time.Duration
+}
+
+type CacheOption func(*CacheOptions)
+
+func defaultCacheOptions() *CacheOptions {
+	return &CacheOptions{
+		DefaultTTL:      time.Minute,
+		TTLs:            map[string]time.Duration{},
+		BundleCacheSize: 1024,
+		FetchTimeout:    time.Minute,
+	}
+}
+
+func WithDefaultTTL(ttl time.Duration) CacheOption {
+	return func(o *CacheOptions) {
+		o.DefaultTTL = ttl
+	}
+}
+
+func WithTTL(method string, ttl time.Duration) CacheOption {
+	return func(o *CacheOptions) {
+		o.TTLs[method] = ttl
+	}
+}
+
+func WithBundleCacheSize(size int) CacheOption {
+	return func(o *CacheOptions) {
+		o.BundleCacheSize = size
+	}
+}
+
+func WithCatalogDigest(digest func(ctx context.Context) (string, error)) CacheOption {
+	return func(o *CacheOptions) {
+		o.CatalogDigest = digest
+	}
+}
+
+func WithFetchTimeout(timeout time.Duration) CacheOption {
+	return func(o *CacheOptions) {
+		o.FetchTimeout = timeout
+	}
+}
+
+// CachingClient is a CatalogInterface that caches the results of another.
+//
+// Results are cached per method for a TTL, and GetBundle results are additionally bounded in number.
+// Concurrent identical lookups are coalesced into a single call. The call keeps the values of the first caller's
+// context but not its cancellation, so that it completes for the remaining callers if the first one gives up;
+// each caller stops waiting when its own context is done.
+// Errors are never cached. Cached messages are shared between callers and must not be modified.
+type CachingClient struct {
+	client  CatalogInterface
+	options *CacheOptions
+	now     func() time.Time
+	group   singleflight.Group
+
+	mu           sync.Mutex
+	generation   uint64
+	entries      map[string]cacheEntry
+	bundles      *list.List
+	bundleIndex  map[string]*list.Element
+	disconnected bool
+	digest       string
+}
+
+var _ CatalogInterface = &CachingClient{}
+
+type cacheEntry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+// NewCachingClient returns a client caching the results of c.
+func NewCachingClient(c CatalogInterface, opts ...CacheOption) *CachingClient {
+	options := defaultCacheOptions()
+	for _, o := range opts {
+		o(options)
+	}
+	return &CachingClient{
+		client:      c,
+		options:     options,
+		now:         time.Now,
+		entries:     map[string]cacheEntry{},
+		bundles:     list.New(),
+		bundleIndex: map[string]*list.Element{},
+	}
+}
+
+// Invalidate drops every cached result.
+func (c *CachingClient) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.invalidate()
+}
+
+func (c *CachingClient) invalidate() {
+	c.generation++
+	c.entries = map[string]cacheEntry{}
+	c.bundles.Init()
+	c.bundleIndex = map[string]*list.Element{}
+}
+
+func (c *CachingClient) ttl(method string) time.Duration {
+	if ttl, ok := c.options.TTLs[method]; ok {
+		return ttl
+	}
+	return c.options.DefaultTTL
+}
+
+func (c *CachingClient) lookup(method, key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var entry cacheEntry
+	if method == MethodGetBundle {
+		e, ok := c.bundleIndex[key]
+		if !ok {
+			return nil, false
+		}
+		entry = e.Value.(cacheEntry)
+		if !c.now().Before(entry.expires) {
+			c.bundles.Remove(e)
+			delete(c.bundleIndex, key)
+			return nil, false
+		}
+		c.bundles.MoveToFront(e)
+		return entry.value, true
+	}
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !c.now().Before(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// store caches value unless the cache was invalidated since generation was read.
+func (c *CachingClient) store(method, key string, generation uint64, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if generation != c.generation {
+		return
+	}
+
+	entry := cacheEntry{key: key, value: value, expires: c.now().Add(c.ttl(method))}
+	if method != MethodGetBundle {
+		c.entries[key] = entry
+		return
+	}
+
+	if e, ok := c.bundleIndex[key]; ok {
+		e.Value = entry
+		c.bundles.MoveToFront(e)
+		return
+	}
+	c.bundleIndex[key] = c.bundles.PushFront(entry)
+	for c.bundles.Len() > c.options.BundleCacheSize {
+		oldest := c.bundles.Back()
+		c.bundles.Remove(oldest)
+		delete(c.bundleIndex, oldest.Value.(cacheEntry).key)
+	}
+}
+
+// get returns the cached result of method called with args, or calls fetch to get and cache it.
+func (c *CachingClient) get(ctx context.Context, method string, args []string, fetch func(context.Context) (interface{}, error)) (interface{}, error) {
+	if c.ttl(method) <= 0 || (method == MethodGetBundle && c.options.BundleCacheSize <= 0) {
+		return fetch(ctx)
+	}
+
+	key := method + "\x00" + strings.Join(args, "\x00")
+	if value, ok := c.lookup(method, key); ok {
+		return value, nil
+	}
+
+	result := c.group.DoChan(key, func() (interface{}, error) {
+		c.mu.Lock()
+		generation := c.generation
+		c.mu.Unlock()
+
+		fetchCtx, cancel := context.WithTimeout(detachedContext{ctx}, c.options.FetchTimeout)
+		defer cancel()
+		value, err := fetch(fetchCtx)
+		if err != nil {
+			return nil, err
+		}
+		c.store(method, key, generation, value)
+		return value, nil
+	})
+	select {
+	case <-ctx.Done():
+		return nil, status.FromContextError(ctx.Err()).Err()
+	case r := <-result:
+		return r.Val, r.Err
+	}
+}
+
+// detachedContext keeps the values of a context, such as its trace, without its deadline or cancellation.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (c *CachingClient) getBundle(ctx context.Context, method string, args []string, fetch func(context.Context) (*api.Bundle, error)) (*api.Bundle, error) {
+	value, err := c.get(ctx, method, args, func(ctx context.Context) (interface{}, error) {
+		return fetch(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*api.Bundle), nil
+}
+
+func (c *CachingClient) GetBundle(ctx context.Context, packageName, channelName, csvName string) (*api.Bundle, error) {
+	return c.getBundle(ctx, MethodGetBundle, []string{packageName, channelName, csvName}, func(ctx context.Context) (*api.Bundle, error) {
+		return c.client.GetBundle(ctx, packageName, channelName, csvName)
+	})
+}
+
+func (c *CachingClient) GetBundleInPackageChannel(ctx context.Context, packageName, channelName string) (*api.Bundle, error) {
+	return c.getBundle(ctx, MethodGetBundleInPackageChannel, []string{packageName, channelName}, func(ctx context.Context) (*api.Bundle, error) {
+		return c.client.GetBundleInPackageChannel(ctx, packageName, channelName)
+	})
+}
+
+func (c *CachingClient) GetReplacementBundleInPackageChannel(ctx context.Context, currentName, packageName, channelName string) (*api.Bundle, error) {
+	return c.getBundle(ctx, MethodGetReplacementBundleInPackageChannel, []string{currentName, packageName, channelName}, func(ctx context.Context) (*api.Bundle, error) {
+		return c.client.GetReplacementBundleInPackageChannel(ctx, currentName, packageName, channelName)
+	})
+}
+
+func (c *CachingClient) GetBundleThatProvides(ctx context.Context, group, version, kind string) (*api.Bundle, error) {
+	return c.getBundle(ctx, MethodGetBundleThatProvides, []string{group, version, kind}, func(ctx context.Context) (*api.Bundle, error) {
+		return c.client.GetBundleThatProvides(ctx, group, version, kind)
+	})
+}
+
+func (c *CachingClient) GetPackage(ctx context.Context, packageName string) (*api.Package, error) {
+	value, err := c.get(ctx, MethodGetPackage, []string{packageName}, func(ctx context.Context) (interface{}, error) {
+		return c.client.GetPackage(ctx, packageName)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*api.Package), nil
+}
+
+// Streamed results are collected before they're cached. A stream that fails part way through is not
+// cached; its iterator yields the messages received before the failure and then the error.
+
+// partialError carries the messages a stream received before it failed.
+type partialError struct {
+	received interface{}
+	err      error
+}
+
+func (e *partialError) Error() string {
+	return e.err.Error()
+}
+
+type bundleSlice struct {
+	bundles []*api.Bundle
+	err     error
+}
+
+func (s *bundleSlice) Recv() (*api.Bundle, error) {
+	if len(s.bundles) == 0 {
+		if s.err != nil {
+			return nil, s.err
+		}
+		return nil, io.EOF
+	}
+	next := s.bundles[0]
+	s.bundles = s.bundles[1:]
+	return next, nil
+}
+
+func (c *CachingClient) ListBundles(ctx context.Context) (*BundleIterator, error) {
+	value, err := c.get(ctx, MethodListBundles, nil, func(ctx context.Context) (interface{}, error) {
+		it, err := c.client.ListBundles(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var bundles []*api.Bundle
+		for b := it.Next(); b != nil; b = it.Next() {
+			bundles = append(bundles, b)
+		}
+		if err := it.Error(); err != nil {
+			return nil, &partialError{received: bundles, err: err}
+		}
+		return bundles, nil
+	})
+	if partial, ok := err.(*partialError); ok {
+		return NewBundleIterator(&bundleSlice{bundles: partial.received.([]*api.Bundle), err: partial.err}), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return NewBundleIterator(&bundleSlice{bundles: value.([]*api.Bundle)}), nil
+}
+
+type packageSlice struct {
+	packages []*api.PackageName
+	err      error
+}
+
+func (s *packageSlice) Recv() (*api.PackageName, error) {
+	if len(s.packages) == 0 {
+		if s.err != nil {
+			return nil, s.err
+		}
+		return nil, io.EOF
+	}
+	next := s.packages[0]
+	s.packages = s.packages[1:]
+	return next, nil
+}
+
+func (c *CachingClient) ListPackages(ctx context.Context) (*PackageIterator, error) {
+	value, err := c.get(ctx, MethodListPackages, nil, func(ctx context.Context) (interface{}, error) {
+		it, err := c.client.ListPackages(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var packages []*api.PackageName
+		for p := it.Next(); p != nil; p = it.Next() {
+			packages = append(packages, p)
+		}
+		if err := it.Error(); err != nil {
+			return nil, &partialError{received: packages, err: err}
+		}
+		return packages, nil
+	})
+	if partial, ok := err.(*partialError); ok {
+		return NewPackageIterator(&packageSlice{packages: partial.received.([]*api.PackageName), err: partial.err}), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return NewPackageIterator(&packageSlice{packages: value.([]*api.PackageName)}), nil
+}
+
+type channelEntrySlice struct {
+	entries []*api.ChannelEntry
+	err     error
+}
+
+func (s *channelEntrySlice) Recv() (*api.ChannelEntry, error) {
+	if len(s.entries) == 0 {
+		if s.err != nil {
+			return nil, s.err
+		}
+		return nil, io.EOF
+	}
+	next := s.entries[0]
+	s.entries = s.entries[1:]
+	return next, nil
+}
+
+func (c *CachingClient) channelEntries(ctx context.Context, method string, args []string, list func(context.Context) (*ChannelEntryIterator, error)) (*ChannelEntryIterator, error) {
+	value, err := c.get(ctx, method, args, func(ctx context.Context) (interface{}, error) {
+		it, err := list(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var entries []*api.ChannelEntry
+		for e := it.Next(); e != nil; e = it.Next() {
+			entries = append(entries, e)
+		}
+		if err := it.Error(); err != nil {
+			return nil, &partialError{received: entries, err: err}
+		}
+		return entries, nil
+	})
+	if partial, ok := err.(*partialError); ok {
+		return NewChannelEntryIterator(&channelEntrySlice{entries: partial.received.([]*api.ChannelEntry), err: partial.err}), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return NewChannelEntryIterator(&channelEntrySlice{entries: value.([]*api.ChannelEntry)}), nil
+}
+
+func (c *CachingClient) GetChannelEntriesThatReplace(ctx context.Context, csvName string) (*ChannelEntryIterator, error) {
+	return c.channelEntries(ctx, MethodGetChannelEntriesThatReplace, []string{csvName}, func(ctx context.Context) (*ChannelEntryIterator, error) {
+		return c.client.GetChannelEntriesThatReplace(ctx, csvName)
+	})
+}
+
+func (c *CachingClient) GetChannelEntriesThatProvide(ctx context.Context, group, version, kind string) (*ChannelEntryIterator, error) {
+	return c.channelEntries(ctx, MethodGetChannelEntriesThatProvide, []string{group, version, kind}, func(ctx context.Context) (*ChannelEntryIterator, error) {
+		return c.client.GetChannelEntriesThatProvide(ctx, group, version, kind)
+	})
+}
+
+func (c *CachingClient) GetLatestChannelEntriesThatProvide(ctx context.Context, group, version, kind string) (*ChannelEntryIterator, error) {
+	return c.channelEntries(ctx, MethodGetLatestChannelEntriesThatProvide, []string{group, version, kind}, func(ctx context.Context) (*ChannelEntryIterator, error) {
+		return c.client.GetLatestChannelEntriesThatProvide(ctx, group, version, kind)
+	})
+}
+
+// HealthCheck checks the health of the underlying client. The cache is invalidated when a check
+// succeeds after a failed one, since the client may have reconnected to a server with a different
+// catalog, and when the catalog digest differs from the one seen by the previous check.
+func (c *CachingClient) HealthCheck(ctx context.Context, reconnectTimeout time.Duration) (bool, error) {
+	healthy, err := c.client.HealthCheck(ctx, reconnectTimeout)
+	if err != nil || !healthy {
+		c.mu.Lock()
+		c.disconnected = true
+		c.mu.Unlock()
+		return healthy, err
+	}
+
+	var digest string
+	if c.options.CatalogDigest != nil {
+		if digest, err = c.options.CatalogDigest(ctx); err != nil {
+			return healthy, nil
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.disconnected || (c.options.CatalogDigest != nil && c.digest != "" && digest != c.digest) {
+		c.invalidate()
+	}
+	c.disconnected = false
+	if c.options.CatalogDigest != nil {
+		c.digest = digest
+	}
+	return healthy, nil
+}
+
+func (c *CachingClient) Close() error {
+	return c.client.Close()
+}
@@ -0,0 +1,273 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+)
+
+// countingClient counts the calls made to it. Calls to GetPackage block until release is closed, if set.
+type countingClient struct {
+	CatalogInterface
+
+	calls    map[string]*int32
+	release  chan struct{}
+	healthy  bool
+	packages []*api.PackageName
+	listErr  error
+}
+
+func newCountingClient() *countingClient {
+	calls := map[string]*int32{}
+	for _, m := range []string{MethodGetBundle, MethodGetPackage, MethodListPackages, "HealthCheck"} {
+		calls[m] = new(int32)
+	}
+	return &countingClient{
+		calls:    calls,
+		healthy:  true,
+		packages: []*api.PackageName{{Name: "etcd"}, {Name: "prometheus"}},
+	}
+}
+
+func (c *countingClient) count(method string) int {
+	return int(atomic.LoadInt32(c.calls[method]))
+}
+
+func (c *countingClient) GetBundle(ctx context.Context, packageName, channelName, csvName string) (*api.Bundle, error) {
+	atomic.AddInt32(c.calls[MethodGetBundle], 1)
+	return &api.Bundle{PackageName: packageName, ChannelName: channelName, CsvName: csvName}, nil
+}
+
+func (c *countingClient) GetPackage(ctx context.Context, packageName string) (*api.Package, error) {
+	atomic.AddInt32(c.calls[MethodGetPackage], 1)
+	if c.release != nil {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-c.release:
+		}
+	}
+	if packageName == "missing" {
+		return nil, errors.New("not found")
+	}
+	return &api.Package{Name: packageName}, nil
+}
+
+func (c *countingClient) ListPackages(ctx context.Context) (*PackageIterator, error) {
+	atomic.AddInt32(c.calls[MethodListPackages], 1)
+	return NewPackageIterator(&packageSlice{packages: c.packages, err: c.listErr}), nil
+}
+
+func (c *countingClient) HealthCheck(ctx context.Context, reconnectTimeout time.Duration) (bool, error) {
+	atomic.AddInt32(c.calls["HealthCheck"], 1)
+	return c.healthy, nil
+}
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func TestCachingClientTTL(t *testing.T) {
+	inner := newCountingClient()
+	clock := &fakeClock{now: time.Now()}
+	c := NewCachingClient(inner, WithDefaultTTL(time.Minute), WithTTL(MethodGetBundle, 0))
+	c.now = clock.Now
+
+	for i := 0; i < 3; i++ {
+		pkg, err := c.GetPackage(context.TODO(), "etcd")
+		require.NoError(t, err)
+		require.Equal(t, "etcd", pkg.Name)
+	}
+	require.Equal(t, 1, inner.count(MethodGetPackage))
+
+	clock.now = clock.now.Add(time.Minute)
+	_, err := c.GetPackage(context.TODO(), "etcd")
+	require.NoError(t, err)
+	require.Equal(t, 2, inner.count(MethodGetPackage), "expired")
+
+	// errors are not cached
+	for i := 0; i < 2; i++ {
+		_, err := c.GetPackage(context.TODO(), "missing")
+		require.Error(t, err)
+	}
+	require.Equal(t, 4, inner.count(MethodGetPackage))
+
+	// a zero TTL disables caching
+	for i := 0; i < 2; i++ {
+		_, err := c.GetBundle(context.TODO(), "etcd", "alpha", "etcdoperator.v0.9.2")
+		require.NoError(t, err)
+	}
+	require.Equal(t, 2, inner.count(MethodGetBundle))
+}
+
+func TestCachingClientBundleLRU(t *testing.T) {
+	inner := newCountingClient()
+	c := NewCachingClient(inner, WithBundleCacheSize(2))
+
+	get := func(csvName string) {
+		b, err := c.GetBundle(context.TODO(), "etcd", "alpha", csvName)
+		require.NoError(t, err)
+		require.Equal(t, csvName, b.CsvName)
+	}
+	get("a")
+	get("b")
+	get("a")
+	get("c") // evicts b, the least recently used
+	require.Equal(t, 3, inner.count(MethodGetBundle))
+
+	get("a")
+	require.Equal(t, 3, inner.count(MethodGetBundle))
+	get("b")
+	require.Equal(t, 4, inner.count(MethodGetBundle))
+}
+
+func TestCachingClientCoalescing(t *testing.T) {
+	inner := newCountingClient()
+	inner.release = make(chan struct{})
+	c := NewCachingClient(inner)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pkg, err := c.GetPackage(context.TODO(), "etcd")
+			require.NoError(t, err)
+			require.Equal(t, "etcd", pkg.Name)
+		}()
+	}
+	require.Eventually(t, func() bool {
+		return inner.count(MethodGetPackage) == 1
+	}, time.Second, time.Millisecond)
+	// give the remaining lookups a chance to join the call in flight
+	time.Sleep(10 * time.Millisecond)
+	close(inner.release)
+	wg.Wait()
+	require.Equal(t, 1, inner.count(MethodGetPackage))
+}
+
+func TestCachingClientCoalescingCancel(t *testing.T) {
+	inner := newCountingClient()
+	inner.release = make(chan struct{})
+	c := NewCachingClient(inner)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error)
+	go func() {
+		_, err := c.GetPackage(ctx, "etcd")
+		first <- err
+	}()
+	require.Eventually(t, func() bool {
+		return inner.count(MethodGetPackage) == 1
+	}, time.Second, time.Millisecond)
+
+	second := make(chan error)
+	go func() {
+		_, err := c.GetPackage(context.Background(), "etcd")
+		second <- err
+	}()
+	// give the second lookup a chance to join the call in flight
+	time.Sleep(10 * time.Millisecond)
+
+	// the first caller giving up doesn't fail the call the second is waiting for
+	cancel()
+	require.Equal(t, codes.Canceled, status.Code(<-first))
+	close(inner.release)
+	require.NoError(t, <-second)
+	require.Equal(t, 1, inner.count(MethodGetPackage))
+}
+
+func TestCachingClientStreams(t *testing.T) {
+	inner := newCountingClient()
+	c := NewCachingClient(inner)
+
+	list := func() ([]string, error) {
+		it, err := c.ListPackages(context.TODO())
+		require.NoError(t, err)
+		var names []string
+		for p := it.Next(); p != nil; p = it.Next() {
+			names = append(names, p.Name)
+		}
+		return names, it.Error()
+	}
+	for i := 0; i < 2; i++ {
+		names, err := list()
+		require.NoError(t, err)
+		require.Equal(t, []string{"etcd", "prometheus"}, names)
+	}
+	require.Equal(t, 1, inner.count(MethodListPackages))
+
+	// a stream failing part way through yields what it received, then the error, and is not cached
+	c.Invalidate()
+	inner.listErr = errors.New("stream broken")
+	for i := 0; i < 2; i++ {
+		names, err := list()
+		require.Equal(t, inner.listErr, err)
+		require.Equal(t, []string{"etcd", "prometheus"}, names)
+	}
+	require.Equal(t, 3, inner.count(MethodListPackages))
+}
+
+func TestCachingClientInvalidation(t *testing.T) {
+	t.Run("Reconnect", func(t *testing.T) {
+		inner := newCountingClient()
+		c := NewCachingClient(inner)
+
+		_, err := c.GetPackage(context.TODO(), "etcd")
+		require.NoError(t, err)
+		healthy, err := c.HealthCheck(context.TODO(), time.Second)
+		require.NoError(t, err)
+		require.True(t, healthy)
+		_, err = c.GetPackage(context.TODO(), "etcd")
+		require.NoError(t, err)
+		require.Equal(t, 1, inner.count(MethodGetPackage), "healthy connection keeps the cache")
+
+		inner.healthy = false
+		healthy, err = c.HealthCheck(context.TODO(), time.Second)
+		require.NoError(t, err)
+		require.False(t, healthy)
+		inner.healthy = true
+		_, err = c.HealthCheck(context.TODO(), time.Second)
+		require.NoError(t, err)
+
+		_, err = c.GetPackage(context.TODO(), "etcd")
+		require.NoError(t, err)
+		require.Equal(t, 2, inner.count(MethodGetPackage), "reconnect invalidates the cache")
+	})
+
+	t.Run("CatalogDigest", func(t *testing.T) {
+		inner := newCountingClient()
+		digest := "sha256:a"
+		c := NewCachingClient(inner, WithCatalogDigest(func(context.Context) (string, error) {
+			return digest, nil
+		}))
+
+		_, err := c.GetPackage(context.TODO(), "etcd")
+		require.NoError(t, err)
+		_, err = c.HealthCheck(context.TODO(), time.Second)
+		require.NoError(t, err)
+		_, err = c.GetPackage(context.TODO(), "etcd")
+		require.NoError(t, err)
+		require.Equal(t, 1, inner.count(MethodGetPackage))
+
+		digest = "sha256:b"
+		_, err = c.HealthCheck(context.TODO(), time.Second)
+		require.NoError(t, err)
+		_, err = c.GetPackage(context.TODO(), "etcd")
+		require.NoError(t, err)
+		require.Equal(t, 2, inner.count(MethodGetPackage), "digest change invalidates the cache")
+	})
+}
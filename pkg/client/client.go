@@ -2,11 +2,13 @@ package client
 
 import (
 	"context"
+	"crypto/tls"
 	"io"
 	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
 
 	"github.com/operator-framework/operator-registry/pkg/api"
 	"github.com/operator-framework/operator-registry/pkg/api/grpc_health_v1"
@@ -23,13 +25,23 @@ type Interface interface {
 	Close() error
 }
 
+// CatalogInterface is an Interface that also covers the Registry RPCs Interface doesn't.
+// It is separate from Interface so that existing implementations of Interface remain valid.
+type CatalogInterface interface {
+	Interface
+	ListPackages(ctx context.Context) (*PackageIterator, error)
+	GetChannelEntriesThatReplace(ctx context.Context, csvName string) (*ChannelEntryIterator, error)
+	GetChannelEntriesThatProvide(ctx context.Context, group, version, kind string) (*ChannelEntryIterator, error)
+	GetLatestChannelEntriesThatProvide(ctx context.Context, group, version, kind string) (*ChannelEntryIterator, error)
+}
+
 type Client struct {
 	Registry api.RegistryClient
 	Health   grpc_health_v1.HealthClient
 	Conn     *grpc.ClientConn
 }
 
-var _ Interface = &Client{}
+var _ CatalogInterface = &Client{}
 
 type BundleStream interface {
 	Recv() (*api.Bundle, error)
@@ -62,6 +74,68 @@ func (it *BundleIterator) Error() error {
 	return it.error
 }
 
+type PackageStream interface {
+	Recv() (*api.PackageName, error)
+}
+
+type PackageIterator struct {
+	stream PackageStream
+	error  error
+}
+
+func NewPackageIterator(stream PackageStream) *PackageIterator {
+	return &PackageIterator{stream: stream}
+}
+
+func (it *PackageIterator) Next() *api.PackageName {
+	if it.error != nil {
+		return nil
+	}
+	next, err := it.stream.Recv()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		it.error = err
+	}
+	return next
+}
+
+func (it *PackageIterator) Error() error {
+	return it.error
+}
+
+type ChannelEntryStream interface {
+	Recv() (*api.ChannelEntry, error)
+}
+
+type ChannelEntryIterator struct {
+	stream ChannelEntryStream
+	error  error
+}
+
+func NewChannelEntryIterator(stream ChannelEntryStream) *ChannelEntryIterator {
+	return &ChannelEntryIterator{stream: stream}
+}
+
+func (it *ChannelEntryIterator) Next() *api.ChannelEntry {
+	if it.error != nil {
+		return nil
+	}
+	next, err := it.stream.Recv()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		it.error = err
+	}
+	return next
+}
+
+func (it *ChannelEntryIterator) Error() error {
+	return it.error
+}
+
 func (c *Client) GetBundle(ctx context.Context, packageName, channelName, csvName string) (*api.Bundle, error) {
 	return c.Registry.GetBundle(ctx, &api.GetBundleRequest{PkgName: packageName, ChannelName: channelName, CsvName: csvName})
 }
@@ -86,10 +160,57 @@ func (c *Client) ListBundles(ctx context.Context) (*BundleIterator, error) {
 	return NewBundleIterator(stream), nil
 }
 
+func (c *Client) ListPackages(ctx context.Context) (*PackageIterator, error) {
+	stream, err := c.Registry.ListPackages(ctx, &api.ListPackageRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return NewPackageIterator(stream), nil
+}
+
 func (c *Client) GetPackage(ctx context.Context, packageName string) (*api.Package, error) {
 	return c.Registry.GetPackage(ctx, &api.GetPackageRequest{Name: packageName})
 }
 
+func (c *Client) GetChannelEntriesThatReplace(ctx context.Context, csvName string) (*ChannelEntryIterator, error) {
+	stream, err := c.Registry.GetChannelEntriesThatReplace(ctx, &api.GetAllReplacementsRequest{CsvName: csvName})
+	if err != nil {
+		return nil, err
+	}
+	return NewChannelEntryIterator(stream), nil
+}
+
+func (c *Client) GetChannelEntriesThatProvide(ctx context.Context, group, version, kind string) (*ChannelEntryIterator, error) {
+	stream, err := c.Registry.GetChannelEntriesThatProvide(ctx, &api.GetAllProvidersRequest{Group: group, Version: version, Kind: kind})
+	if err != nil {
+		return nil, err
+	}
+	return NewChannelEntryIterator(stream), nil
+}
+
+func (c *Client) GetLatestChannelEntriesThatProvide(ctx context.Context, group, version, kind string) (*ChannelEntryIterator, error) {
+	stream, err := c.Registry.GetLatestChannelEntriesThatProvide(ctx, &api.GetLatestProvidersRequest{Group: group, Version: version, Kind: kind})
+	if err != nil {
+		return nil, err
+	}
+	return NewChannelEntryIterator(stream), nil
+}
+
+// GetCatalogInfo returns the digest and size of the catalog the server is serving, and when it was loaded.
+func (c *Client) GetCatalogInfo(ctx context.Context) (*api.CatalogInfo, error) {
+	return c.Registry.GetCatalogInfo(ctx, &api.GetCatalogInfoRequest{})
+}
+
+// CatalogDigest returns the digest of the catalog the server is serving. Pass it to WithCatalogDigest
+// to invalidate a CachingClient whenever the server's catalog changes.
+func (c *Client) CatalogDigest(ctx context.Context) (string, error) {
+	info, err := c.GetCatalogInfo(ctx)
+	if err != nil {
+		return "", err
+	}
+	return info.GetDigest(), nil
+}
+
 func (c *Client) Close() error {
 	if c.Conn == nil {
 		return nil
@@ -115,14 +236,47 @@ func (c *Client) HealthCheck(ctx context.Context, reconnectTimeout time.Duration
 	return true, nil
 }
 
-func NewClient(address string) (*Client, error) {
-	conn, err := grpc.Dial(address, grpc.WithInsecure())
+// NewClient returns a client for the registry server at address, dialed with any additional opts. It connects
+// insecurely unless opts include WithTransportCredentials.
+// Calls that fail because the server is Unavailable are retried according to DefaultRetryPolicy.
+func NewClient(address string, opts ...grpc.DialOption) (*Client, error) {
+	defaults := RetryDialOptions(DefaultRetryPolicy)
+	if !hasTransportCredentials(opts) {
+		defaults = append([]grpc.DialOption{grpc.WithInsecure()}, defaults...)
+	}
+	conn, err := grpc.Dial(address, append(defaults, opts...)...)
 	if err != nil {
 		return nil, err
 	}
 	return NewClientFromConn(conn), nil
 }
 
+// NewTLSClient returns a client for the registry server at address that connects using the given TLS config,
+// dialed with any additional opts. Use certs.ClientTLSConfig to build a config for servers requiring mutual TLS.
+func NewTLSClient(address string, config *tls.Config, opts ...grpc.DialOption) (*Client, error) {
+	return NewClient(address, append([]grpc.DialOption{WithTransportCredentials(credentials.NewTLS(config))}, opts...)...)
+}
+
+// WithTransportCredentials returns a dial option that connects NewClient with creds instead of insecurely.
+// It must be used rather than grpc.WithTransportCredentials, which gRPC refuses to combine with the insecure default.
+func WithTransportCredentials(creds credentials.TransportCredentials) grpc.DialOption {
+	return transportCredentials{grpc.WithTransportCredentials(creds)}
+}
+
+// transportCredentials marks the dial option setting the transport credentials, which grpc.DialOption doesn't reveal.
+type transportCredentials struct {
+	grpc.DialOption
+}
+
+func hasTransportCredentials(opts []grpc.DialOption) bool {
+	for _, opt := range opts {
+		if _, ok := opt.(transportCredentials); ok {
+			return true
+		}
+	}
+	return false
+}
+
 func NewClientFromConn(conn *grpc.ClientConn) *Client {
 	return &Client{
 		Registry: api.NewRegistryClient(conn),
@@ -2,8 +2,18 @@ package client
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"errors"
+	"io"
+	"math/big"
+	"net"
 	"testing"
+	"time"
 
 	"github.com/operator-framework/operator-registry/pkg/api"
 	"github.com/operator-framework/operator-registry/pkg/api/grpc_health_v1"
@@ -11,17 +21,22 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 type RegistryClientStub struct {
-	ListBundlesClient api.Registry_ListBundlesClient
-	PackageName       string
-	Package           *api.Package
-	Error             error
+	ListBundlesClient  api.Registry_ListBundlesClient
+	ListPackagesClient api.Registry_ListPackagesClient
+	ProvidersClient    api.Registry_GetChannelEntriesThatProvideClient
+	ProvidersRequest   *api.GetAllProvidersRequest
+	PackageName        string
+	Package            *api.Package
+	CatalogInfo        *api.CatalogInfo
+	Error              error
 }
 
 func (s *RegistryClientStub) ListPackages(ctx context.Context, in *api.ListPackageRequest, opts ...grpc.CallOption) (api.Registry_ListPackagesClient, error) {
-	return nil, nil
+	return s.ListPackagesClient, s.Error
 }
 
 func (s *RegistryClientStub) GetPackage(ctx context.Context, in *api.GetPackageRequest, opts ...grpc.CallOption) (*api.Package, error) {
@@ -46,7 +61,8 @@ func (s *RegistryClientStub) GetBundleThatReplaces(ctx context.Context, in *api.
 }
 
 func (s *RegistryClientStub) GetChannelEntriesThatProvide(ctx context.Context, in *api.GetAllProvidersRequest, opts ...grpc.CallOption) (api.Registry_GetChannelEntriesThatProvideClient, error) {
-	return nil, nil
+	s.ProvidersRequest = in
+	return s.ProvidersClient, s.Error
 }
 
 func (s *RegistryClientStub) GetLatestChannelEntriesThatProvide(ctx context.Context, in *api.GetLatestProvidersRequest, opts ...grpc.CallOption) (api.Registry_GetLatestChannelEntriesThatProvideClient, error) {
@@ -61,6 +77,10 @@ func (s *RegistryClientStub) ListBundles(ctx context.Context, in *api.ListBundle
 	return s.ListBundlesClient, s.Error
 }
 
+func (s *RegistryClientStub) GetCatalogInfo(ctx context.Context, in *api.GetCatalogInfoRequest, opts ...grpc.CallOption) (*api.CatalogInfo, error) {
+	return s.CatalogInfo, s.Error
+}
+
 func (s *RegistryClientStub) Check(ctx context.Context, in *grpc_health_v1.HealthCheckRequest, opts ...grpc.CallOption) (*grpc_health_v1.HealthCheckResponse, error) {
 	return nil, nil
 }
@@ -75,6 +95,38 @@ func (s *BundleReceiverStub) Recv() (*api.Bundle, error) {
 	return s.Bundle, s.Error
 }
 
+type PackageReceiverStub struct {
+	Packages []*api.PackageName
+	Error    error
+	grpc.ClientStream
+}
+
+func (s *PackageReceiverStub) Recv() (*api.PackageName, error) {
+	if len(s.Packages) == 0 {
+		if s.Error != nil {
+			return nil, s.Error
+		}
+		return nil, io.EOF
+	}
+	next := s.Packages[0]
+	s.Packages = s.Packages[1:]
+	return next, nil
+}
+
+type ChannelEntryReceiverStub struct {
+	Entries []*api.ChannelEntry
+	grpc.ClientStream
+}
+
+func (s *ChannelEntryReceiverStub) Recv() (*api.ChannelEntry, error) {
+	if len(s.Entries) == 0 {
+		return nil, io.EOF
+	}
+	next := s.Entries[0]
+	s.Entries = s.Entries[1:]
+	return next, nil
+}
+
 func TestListBundlesError(t *testing.T) {
 	expected := errors.New("test error")
 	stub := &RegistryClientStub{
@@ -170,3 +222,154 @@ func TestGetPackage(t *testing.T) {
 		})
 	}
 }
+
+func TestListPackages(t *testing.T) {
+	expected := errors.New("test error")
+	rstub := &PackageReceiverStub{
+		Packages: []*api.PackageName{{Name: "etcd"}, {Name: "prometheus"}},
+		Error:    expected,
+	}
+	cstub := &RegistryClientStub{
+		ListPackagesClient: rstub,
+	}
+	c := Client{
+		Registry: cstub,
+		Health:   cstub,
+	}
+
+	it, err := c.ListPackages(context.TODO())
+	require.NoError(t, err)
+
+	var names []string
+	for p := it.Next(); p != nil; p = it.Next() {
+		names = append(names, p.Name)
+	}
+	require.Equal(t, []string{"etcd", "prometheus"}, names)
+	require.Equal(t, expected, it.Error())
+}
+
+func TestGetChannelEntriesThatProvide(t *testing.T) {
+	expected := []*api.ChannelEntry{
+		{PackageName: "etcd", ChannelName: "alpha", BundleName: "etcdoperator.v0.9.2", Replaces: "etcdoperator.v0.9.0"},
+		{PackageName: "etcd", ChannelName: "alpha", BundleName: "etcdoperator.v0.9.0"},
+	}
+	cstub := &RegistryClientStub{
+		ProvidersClient: &ChannelEntryReceiverStub{Entries: append([]*api.ChannelEntry{}, expected...)},
+	}
+	c := Client{
+		Registry: cstub,
+		Health:   cstub,
+	}
+
+	it, err := c.GetChannelEntriesThatProvide(context.TODO(), "etcd.database.coreos.com", "v1beta2", "EtcdCluster")
+	require.NoError(t, err)
+	require.Equal(t, &api.GetAllProvidersRequest{Group: "etcd.database.coreos.com", Version: "v1beta2", Kind: "EtcdCluster"}, cstub.ProvidersRequest)
+
+	var actual []*api.ChannelEntry
+	for e := it.Next(); e != nil; e = it.Next() {
+		actual = append(actual, e)
+	}
+	require.NoError(t, it.Error())
+	require.Equal(t, expected, actual)
+}
+
+func TestCatalogDigest(t *testing.T) {
+	stub := &RegistryClientStub{
+		CatalogInfo: &api.CatalogInfo{Digest: "sha256:abc", PackageCount: 1, BundleCount: 2},
+	}
+	c := Client{Registry: stub, Health: stub}
+
+	dgst, err := c.CatalogDigest(context.TODO())
+	require.NoError(t, err)
+	require.Equal(t, "sha256:abc", dgst)
+
+	expected := errors.New("test error")
+	stub.Error = expected
+	_, err = c.CatalogDigest(context.TODO())
+	require.Equal(t, expected, err)
+}
+
+func TestNewClientKeepsDefaults(t *testing.T) {
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	s := grpc.NewServer()
+	grpc_health_v1.RegisterHealthServer(s, &healthyServer{})
+	go s.Serve(lis)
+	defer s.Stop()
+
+	// options are added to the insecure transport rather than replacing it
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c, err := NewClient(lis.Addr().String(), grpc.WithBlock(), grpc.WithUserAgent("test"))
+	require.NoError(t, err)
+	defer c.Close()
+	healthy, err := c.HealthCheck(ctx, time.Second)
+	require.NoError(t, err)
+	require.True(t, healthy)
+}
+
+// selfSignedConfigs returns a server config with a self-signed certificate for 127.0.0.1, and a client config trusting it.
+func selfSignedConfigs(t *testing.T) (*tls.Config, *tls.Config) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "registry"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}, &tls.Config{RootCAs: pool}
+}
+
+func TestNewClientTransportCredentials(t *testing.T) {
+	serverConfig, clientConfig := selfSignedConfigs(t)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer(grpc.Creds(credentials.NewTLS(serverConfig)))
+	grpc_health_v1.RegisterHealthServer(s, &healthyServer{})
+	go s.Serve(lis)
+	defer s.Stop()
+
+	dials := map[string]func() (*Client, error){
+		"NewClient": func() (*Client, error) {
+			// the given credentials replace the insecure transport rather than conflicting with it
+			return NewClient(lis.Addr().String(), WithTransportCredentials(credentials.NewTLS(clientConfig)), grpc.WithBlock())
+		},
+		"NewTLSClient": func() (*Client, error) {
+			return NewTLSClient(lis.Addr().String(), clientConfig, grpc.WithBlock())
+		},
+	}
+	for name, dial := range dials {
+		dial := dial
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			c, err := dial()
+			require.NoError(t, err)
+			defer c.Close()
+			healthy, err := c.HealthCheck(ctx, time.Second)
+			require.NoError(t, err)
+			require.True(t, healthy)
+		})
+	}
+}
+
+type healthyServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+}
+
+func (*healthyServer) Check(context.Context, *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+}
@@ -1,9 +1,15 @@
 package client
 
 import (
+	"errors"
 	"fmt"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/operator-framework/operator-registry/pkg/registry"
 )
 
 const (
@@ -59,3 +65,41 @@ func reasonForError(err error) HealthErrorReason {
 	}
 	return HealthErrReasonUnknown
 }
+
+// IsNotFound reports whether err is a registry server's answer that what was asked for isn't in its catalog.
+func IsNotFound(err error) bool {
+	return grpcStatus(err).Code() == codes.NotFound
+}
+
+// IsInvalidArgument reports whether err is a registry server rejecting a request as malformed.
+func IsInvalidArgument(err error) bool {
+	return grpcStatus(err).Code() == codes.InvalidArgument
+}
+
+// ErrorResource returns the package, channel, bundle or API named by the error details of a failed
+// request, if the registry server attached them.
+func ErrorResource(err error) (registry.Resource, bool) {
+	for _, d := range grpcStatus(err).Details() {
+		info, ok := d.(*errdetails.ErrorInfo)
+		if !ok || info.GetDomain() != registry.ErrorDomain {
+			continue
+		}
+		md := info.GetMetadata()
+		return registry.Resource{
+			Package: md[registry.MetadataPackage],
+			Channel: md[registry.MetadataChannel],
+			Bundle:  md[registry.MetadataBundle],
+			API:     md[registry.MetadataAPI],
+		}, true
+	}
+	return registry.Resource{}, false
+}
+
+// grpcStatus returns the status of err, or of the first error it wraps that has one.
+func grpcStatus(err error) *status.Status {
+	var se interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &se) {
+		return se.GRPCStatus()
+	}
+	return status.Convert(err)
+}
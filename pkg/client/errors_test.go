@@ -0,0 +1,44 @@
+package client
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+func TestStatusErrors(t *testing.T) {
+	st, err := status.New(codes.NotFound, `package "etcd", channel "alpha" not found`).WithDetails(&errdetails.ErrorInfo{
+		Reason: registry.ReasonNotFound,
+		Domain: registry.ErrorDomain,
+		Metadata: map[string]string{
+			registry.MetadataPackage: "etcd",
+			registry.MetadataChannel: "alpha",
+		},
+	})
+	require.NoError(t, err)
+	notFound := st.Err()
+
+	require.True(t, IsNotFound(notFound))
+	require.True(t, IsNotFound(fmt.Errorf("get bundle: %w", notFound)))
+	require.False(t, IsInvalidArgument(notFound))
+	r, ok := ErrorResource(notFound)
+	require.True(t, ok)
+	require.Equal(t, registry.Resource{Package: "etcd", Channel: "alpha"}, r)
+
+	invalid := status.Error(codes.InvalidArgument, "package name is required")
+	require.True(t, IsInvalidArgument(invalid))
+	require.False(t, IsNotFound(invalid))
+	_, ok = ErrorResource(invalid)
+	require.False(t, ok)
+
+	require.False(t, IsNotFound(nil))
+	require.False(t, IsNotFound(fmt.Errorf("package not found")))
+	_, ok = ErrorResource(nil)
+	require.False(t, ok)
+}
@@ -0,0 +1,156 @@
+package client
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RetryPolicy configures how calls that fail because the server is Unavailable are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times a call is made, including the first attempt.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between retries.
+	MaxBackoff time.Duration
+	// Multiplier grows the delay after each retry.
+	Multiplier float64
+}
+
+// DefaultRetryPolicy is the retry policy of clients returned by NewClient.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    5,
+	InitialBackoff: 100 * time.Millisecond,
+	MaxBackoff:     5 * time.Second,
+	Multiplier:     2,
+}
+
+// healthServicePrefix is the method prefix of the health service, whose checks are never retried
+// so that HealthCheck reports connection problems promptly.
+const healthServicePrefix = "/grpc.health.v1.Health/"
+
+// RetryDialOptions returns dial options that retry unary and server streaming calls failing with Unavailable.
+// Streams are only retried until their first message is received, so no message is delivered twice.
+// Retries stop early when the call's context is done.
+func RetryDialOptions(policy RetryPolicy) []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(policy.unaryInterceptor),
+		grpc.WithChainStreamInterceptor(policy.streamInterceptor),
+	}
+}
+
+func (p RetryPolicy) retryable(method string, attempt int, err error) bool {
+	return attempt < p.MaxAttempts && status.Code(err) == codes.Unavailable && !strings.HasPrefix(method, healthServicePrefix)
+}
+
+// wait sleeps for the backoff before the given retry, returning early with an error if ctx is done.
+func (p RetryPolicy) wait(ctx context.Context, retry int) error {
+	backoff := float64(p.InitialBackoff)
+	for i := 1; i < retry; i++ {
+		backoff *= p.Multiplier
+	}
+	if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+
+	timer := time.NewTimer(time.Duration(backoff))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (p RetryPolicy) unaryInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	for attempt := 1; p.retryable(method, attempt, err); attempt++ {
+		if p.wait(ctx, attempt) != nil {
+			break
+		}
+		err = invoker(ctx, method, req, reply, cc, opts...)
+	}
+	return err
+}
+
+func (p RetryPolicy) streamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	stream, err := streamer(ctx, desc, cc, method, opts...)
+	if desc.ClientStreams || !desc.ServerStreams {
+		return stream, err
+	}
+	for attempt := 1; p.retryable(method, attempt, err); attempt++ {
+		if p.wait(ctx, attempt) != nil {
+			break
+		}
+		stream, err = streamer(ctx, desc, cc, method, opts...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &retryStream{
+		ClientStream: stream,
+		policy:       p,
+		ctx:          ctx,
+		open: func() (grpc.ClientStream, error) {
+			return streamer(ctx, desc, cc, method, opts...)
+		},
+		method: method,
+	}, nil
+}
+
+// retryStream reopens a server stream that fails with Unavailable before it receives its first message,
+// resending the request the stream was opened with.
+type retryStream struct {
+	grpc.ClientStream
+	policy RetryPolicy
+	ctx    context.Context
+	open   func() (grpc.ClientStream, error)
+	method string
+
+	req      interface{}
+	closed   bool
+	received bool
+}
+
+func (s *retryStream) SendMsg(m interface{}) error {
+	s.req = m
+	return s.ClientStream.SendMsg(m)
+}
+
+func (s *retryStream) CloseSend() error {
+	s.closed = true
+	return s.ClientStream.CloseSend()
+}
+
+func (s *retryStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	for attempt := 1; !s.received && s.policy.retryable(s.method, attempt, err); attempt++ {
+		if s.policy.wait(s.ctx, attempt) != nil {
+			break
+		}
+		stream, oerr := s.open()
+		if oerr != nil {
+			err = oerr
+			continue
+		}
+		s.ClientStream = stream
+		// Errors sending on a broken stream are reported by RecvMsg, so they're safe to ignore here.
+		if s.req != nil {
+			_ = stream.SendMsg(s.req)
+		}
+		if s.closed {
+			_ = stream.CloseSend()
+		}
+		err = stream.RecvMsg(m)
+	}
+	if err == nil {
+		s.received = true
+	}
+	return err
+}
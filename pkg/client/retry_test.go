@@ -0,0 +1,120 @@
+package client
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+)
+
+// flakyServer fails the first failures calls to each RPC with Unavailable.
+type flakyServer struct {
+	api.UnimplementedRegistryServer
+	failures int32
+
+	packageCalls int32
+	listCalls    int32
+}
+
+func (s *flakyServer) GetPackage(ctx context.Context, req *api.GetPackageRequest) (*api.Package, error) {
+	if atomic.AddInt32(&s.packageCalls, 1) <= s.failures {
+		return nil, status.Error(codes.Unavailable, "try again")
+	}
+	return &api.Package{Name: req.GetName()}, nil
+}
+
+func (s *flakyServer) ListPackages(req *api.ListPackageRequest, stream api.Registry_ListPackagesServer) error {
+	if atomic.AddInt32(&s.listCalls, 1) <= s.failures {
+		return status.Error(codes.Unavailable, "try again")
+	}
+	for _, name := range []string{"etcd", "prometheus"} {
+		if err := stream.Send(&api.PackageName{Name: name}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *flakyServer) GetBundle(ctx context.Context, req *api.GetBundleRequest) (*api.Bundle, error) {
+	return nil, status.Error(codes.NotFound, "no such bundle")
+}
+
+func serveFlaky(t *testing.T, srv *flakyServer, policy RetryPolicy) *Client {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer()
+	api.RegisterRegistryServer(s, srv)
+	go s.Serve(lis)
+
+	conn, err := grpc.Dial(lis.Addr().String(), append(RetryDialOptions(policy), grpc.WithInsecure())...)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		conn.Close()
+		s.Stop()
+	})
+	return NewClientFromConn(conn)
+}
+
+func TestRetries(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 4, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond, Multiplier: 2}
+
+	t.Run("Unary", func(t *testing.T) {
+		srv := &flakyServer{failures: 3}
+		c := serveFlaky(t, srv, policy)
+
+		pkg, err := c.GetPackage(context.TODO(), "etcd")
+		require.NoError(t, err)
+		require.Equal(t, "etcd", pkg.Name)
+		require.EqualValues(t, 4, srv.packageCalls)
+	})
+
+	t.Run("UnaryExhausted", func(t *testing.T) {
+		srv := &flakyServer{failures: 4}
+		c := serveFlaky(t, srv, policy)
+
+		_, err := c.GetPackage(context.TODO(), "etcd")
+		require.Equal(t, codes.Unavailable, status.Code(err))
+		require.EqualValues(t, 4, srv.packageCalls)
+	})
+
+	t.Run("NotRetryable", func(t *testing.T) {
+		c := serveFlaky(t, &flakyServer{}, policy)
+
+		_, err := c.GetBundle(context.TODO(), "etcd", "alpha", "etcdoperator.v0.9.2")
+		require.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("Stream", func(t *testing.T) {
+		srv := &flakyServer{failures: 2}
+		c := serveFlaky(t, srv, policy)
+
+		it, err := c.ListPackages(context.TODO())
+		require.NoError(t, err)
+		var names []string
+		for p := it.Next(); p != nil; p = it.Next() {
+			names = append(names, p.Name)
+		}
+		require.NoError(t, it.Error())
+		require.Equal(t, []string{"etcd", "prometheus"}, names)
+		require.EqualValues(t, 3, srv.listCalls)
+	})
+
+	t.Run("ContextDone", func(t *testing.T) {
+		srv := &flakyServer{failures: 10}
+		c := serveFlaky(t, srv, RetryPolicy{MaxAttempts: 10, InitialBackoff: time.Hour, Multiplier: 1})
+
+		ctx, cancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+		defer cancel()
+		_, err := c.GetPackage(ctx, "etcd")
+		require.Equal(t, codes.Unavailable, status.Code(err))
+		require.EqualValues(t, 1, srv.packageCalls)
+	})
+}
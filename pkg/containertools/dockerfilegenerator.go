@@ -11,6 +11,8 @@ const (
 	defaultBinarySourceImage = "quay.io/operator-framework/upstream-opm-builder"
 	DefaultDbLocation        = "/database/index.db"
 	DbLocationLabel          = "operators.operatorframework.io.index.database.v1"
+	ConfigsLocationLabel     = "operators.operatorframework.io.index.configs.v1"
+	DigestLabel              = "operators.operatorframework.io.index.digest.v1"
 )
 
 // DockerfileGenerator defines functions to generate index dockerfiles
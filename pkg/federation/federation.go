@@ -0,0 +1,410 @@
+package federation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+	"github.com/operator-framework/operator-registry/pkg/client"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+// DefaultHealthTimeout bounds how long an upstream health check waits for a connection to recover.
+const DefaultHealthTimeout = 5 * time.Second
+
+// Upstream is a registry server whose catalog is federated.
+type Upstream struct {
+	// Name identifies the upstream in health checks and errors, typically its address.
+	Name   string
+	Client *client.Client
+}
+
+type upstreamStatus struct {
+	checked  bool
+	err      error
+	packages []string
+}
+
+// Querier is a GRPCQuery that federates the catalogs of several upstream registry servers.
+//
+// Upstreams are given in priority order. Each package is served by the highest priority upstream
+// that is healthy and has it, so a package present in several upstreams is never mixed between them.
+// Package ownership is computed by Refresh, which also records the health of every upstream.
+type Querier struct {
+	upstreams     []Upstream
+	healthTimeout time.Duration
+
+	mu       sync.RWMutex
+	statuses []upstreamStatus
+	owners   map[string]int
+}
+
+var _ registry.GRPCQuery = &Querier{}
+
+// NewQuerier returns a querier federating upstreams, the first taking priority over the rest.
+func NewQuerier(upstreams ...Upstream) *Querier {
+	return &Querier{
+		upstreams:     upstreams,
+		healthTimeout: DefaultHealthTimeout,
+		statuses:      make([]upstreamStatus, len(upstreams)),
+	}
+}
+
+// Refresh checks the health of every upstream and recomputes which upstream serves each package.
+func (q *Querier) Refresh(ctx context.Context) {
+	statuses := make([]upstreamStatus, len(q.upstreams))
+	var wg sync.WaitGroup
+	for i := range q.upstreams {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			statuses[i] = q.check(ctx, q.upstreams[i])
+		}(i)
+	}
+	wg.Wait()
+
+	owners := map[string]int{}
+	for i := len(statuses) - 1; i >= 0; i-- {
+		if statuses[i].err != nil {
+			continue
+		}
+		for _, p := range statuses[i].packages {
+			owners[p] = i
+		}
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.statuses = statuses
+	q.owners = owners
+}
+
+// Watch refreshes the querier at the given interval until ctx is done.
+func (q *Querier) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			q.Refresh(ctx)
+		}
+	}
+}
+
+func (q *Querier) check(ctx context.Context, upstream Upstream) upstreamStatus {
+	healthy, err := upstream.Client.HealthCheck(ctx, q.healthTimeout)
+	if err != nil {
+		return upstreamStatus{checked: true, err: err}
+	}
+	if !healthy {
+		return upstreamStatus{checked: true, err: fmt.Errorf("upstream %s is not serving", upstream.Name)}
+	}
+
+	it, err := upstream.Client.ListPackages(ctx)
+	if err != nil {
+		return upstreamStatus{checked: true, err: fmt.Errorf("list packages from %s: %v", upstream.Name, err)}
+	}
+	var packages []string
+	for p := it.Next(); p != nil; p = it.Next() {
+		packages = append(packages, p.GetName())
+	}
+	if err := it.Error(); err != nil {
+		return upstreamStatus{checked: true, err: fmt.Errorf("list packages from %s: %v", upstream.Name, err)}
+	}
+	return upstreamStatus{checked: true, packages: packages}
+}
+
+// Ready returns nil when at least one upstream is serving, or the reason none is.
+func (q *Querier) Ready() error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.owners == nil {
+		return fmt.Errorf("upstreams not yet checked")
+	}
+	var errs []string
+	for i, s := range q.statuses {
+		if s.err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Sprintf("%s: %v", q.upstreams[i].Name, s.err))
+	}
+	return fmt.Errorf("no upstream is serving: %s", strings.Join(errs, ", "))
+}
+
+// UpstreamReady reports the health of the upstream with the given name as of the last Refresh.
+// It returns false if there is no such upstream.
+func (q *Querier) UpstreamReady(name string) (bool, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	for i, u := range q.upstreams {
+		if u.Name != name {
+			continue
+		}
+		if !q.statuses[i].checked {
+			return true, fmt.Errorf("upstream %s not yet checked", name)
+		}
+		return true, q.statuses[i].err
+	}
+	return false, nil
+}
+
+// index returns the current package owners, refreshing first if the upstreams have never been checked.
+func (q *Querier) index(ctx context.Context) map[string]int {
+	q.mu.RLock()
+	owners := q.owners
+	q.mu.RUnlock()
+	if owners != nil {
+		return owners
+	}
+	q.Refresh(ctx)
+
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.owners
+}
+
+func (q *Querier) owner(ctx context.Context, pkgName string) (*client.Client, error) {
+	i, ok := q.index(ctx)[pkgName]
+	if !ok {
+		return nil, registry.NewNotFoundError(registry.Resource{Package: pkgName}, "package %q not found", pkgName)
+	}
+	return q.upstreams[i].Client, nil
+}
+
+// owning returns the indexes of upstreams that serve at least one package.
+func owning(owners map[string]int) []int {
+	seen := map[int]struct{}{}
+	var upstreams []int
+	for _, i := range owners {
+		if _, ok := seen[i]; ok {
+			continue
+		}
+		seen[i] = struct{}{}
+		upstreams = append(upstreams, i)
+	}
+	sort.Ints(upstreams)
+	return upstreams
+}
+
+// unavailable reports whether err means an upstream could not be reached, as opposed to it having no result.
+func unavailable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Canceled:
+		return true
+	}
+	return false
+}
+
+// fromUpstream converts an upstream's answer that it doesn't have what was asked for, or that the request
+// is invalid, back to the registry error it was served from, so the federated server answers the same way.
+func fromUpstream(err error) error {
+	if err == nil {
+		return nil
+	}
+	r, _ := client.ErrorResource(err)
+	switch {
+	case client.IsNotFound(err):
+		return registry.NotFoundError{Resource: r, Err: errors.New(status.Convert(err).Message())}
+	case client.IsInvalidArgument(err):
+		return registry.InvalidArgumentError{Resource: r, Err: errors.New(status.Convert(err).Message())}
+	}
+	return err
+}
+
+func (q *Querier) ListPackages(ctx context.Context) ([]string, error) {
+	owners := q.index(ctx)
+	packages := make([]string, 0, len(owners))
+	for p := range owners {
+		packages = append(packages, p)
+	}
+	sort.Strings(packages)
+	return packages, nil
+}
+
+func (q *Querier) ListBundles(ctx context.Context) ([]*api.Bundle, error) {
+	owners := q.index(ctx)
+	upstreams := owning(owners)
+	results := make([][]*api.Bundle, len(upstreams))
+	errs := make([]error, len(upstreams))
+
+	var wg sync.WaitGroup
+	for n, i := range upstreams {
+		wg.Add(1)
+		go func(n, i int) {
+			defer wg.Done()
+			it, err := q.upstreams[i].Client.ListBundles(ctx)
+			if err != nil {
+				errs[n] = fmt.Errorf("list bundles from %s: %v", q.upstreams[i].Name, err)
+				return
+			}
+			for b := it.Next(); b != nil; b = it.Next() {
+				if owner, ok := owners[b.GetPackageName()]; ok && owner == i {
+					results[n] = append(results[n], b)
+				}
+			}
+			if err := it.Error(); err != nil {
+				errs[n] = fmt.Errorf("list bundles from %s: %v", q.upstreams[i].Name, err)
+			}
+		}(n, i)
+	}
+	wg.Wait()
+
+	var bundles []*api.Bundle
+	for n := range upstreams {
+		if errs[n] != nil {
+			return nil, errs[n]
+		}
+		bundles = append(bundles, results[n]...)
+	}
+	return bundles, nil
+}
+
+func (q *Querier) GetPackage(ctx context.Context, name string) (*registry.PackageManifest, error) {
+	c, err := q.owner(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	pkg, err := c.GetPackage(ctx, name)
+	if err != nil {
+		return nil, fromUpstream(err)
+	}
+	return registry.APIPackageToPackageManifest(pkg), nil
+}
+
+func (q *Querier) GetBundle(ctx context.Context, pkgName, channelName, csvName string) (*api.Bundle, error) {
+	c, err := q.owner(ctx, pkgName)
+	if err != nil {
+		return nil, err
+	}
+	bundle, err := c.GetBundle(ctx, pkgName, channelName, csvName)
+	return bundle, fromUpstream(err)
+}
+
+func (q *Querier) GetBundleForChannel(ctx context.Context, pkgName string, channelName string) (*api.Bundle, error) {
+	c, err := q.owner(ctx, pkgName)
+	if err != nil {
+		return nil, err
+	}
+	bundle, err := c.GetBundleInPackageChannel(ctx, pkgName, channelName)
+	return bundle, fromUpstream(err)
+}
+
+func (q *Querier) GetBundleThatReplaces(ctx context.Context, name, pkgName, channelName string) (*api.Bundle, error) {
+	c, err := q.owner(ctx, pkgName)
+	if err != nil {
+		return nil, err
+	}
+	bundle, err := c.GetReplacementBundleInPackageChannel(ctx, name, pkgName, channelName)
+	return bundle, fromUpstream(err)
+}
+
+// channelEntries fans a channel entry query out to every upstream serving a package, keeping only the
+// entries of packages each upstream serves. Upstreams that are unavailable or have no matching entries
+// are skipped, and any other upstream error fails the query.
+func (q *Querier) channelEntries(ctx context.Context, list func(c *client.Client) (*client.ChannelEntryIterator, error)) ([]*registry.ChannelEntry, error) {
+	owners := q.index(ctx)
+	upstreams := owning(owners)
+	results := make([][]*registry.ChannelEntry, len(upstreams))
+	errs := make([]error, len(upstreams))
+
+	var wg sync.WaitGroup
+	for n, i := range upstreams {
+		wg.Add(1)
+		go func(n, i int) {
+			defer wg.Done()
+			it, err := list(q.upstreams[i].Client)
+			if err == nil {
+				for e := it.Next(); e != nil; e = it.Next() {
+					if owner, ok := owners[e.GetPackageName()]; ok && owner == i {
+						results[n] = append(results[n], registry.APIChannelEntryToChannelEntry(e))
+					}
+				}
+				err = it.Error()
+			}
+			if err != nil && !unavailable(err) && !client.IsNotFound(err) {
+				errs[n] = fmt.Errorf("query %s: %v", q.upstreams[i].Name, err)
+			}
+		}(n, i)
+	}
+	wg.Wait()
+
+	var entries []*registry.ChannelEntry
+	for n := range upstreams {
+		if errs[n] != nil {
+			return nil, errs[n]
+		}
+		entries = append(entries, results[n]...)
+	}
+	return entries, nil
+}
+
+func (q *Querier) GetChannelEntriesThatReplace(ctx context.Context, name string) ([]*registry.ChannelEntry, error) {
+	entries, err := q.channelEntries(ctx, func(c *client.Client) (*client.ChannelEntryIterator, error) {
+		return c.GetChannelEntriesThatReplace(ctx, name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, registry.NewNotFoundError(registry.Resource{Bundle: name}, "no channel entries found that replace %s", name)
+	}
+	return entries, nil
+}
+
+func (q *Querier) GetChannelEntriesThatProvide(ctx context.Context, group, version, kind string) ([]*registry.ChannelEntry, error) {
+	entries, err := q.channelEntries(ctx, func(c *client.Client) (*client.ChannelEntryIterator, error) {
+		return c.GetChannelEntriesThatProvide(ctx, group, version, kind)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, registry.NewNotFoundError(registry.APIResource(group, version, kind), "no channel entries found that provide group:%q version:%q kind:%q", group, version, kind)
+	}
+	return entries, nil
+}
+
+func (q *Querier) GetLatestChannelEntriesThatProvide(ctx context.Context, group, version, kind string) ([]*registry.ChannelEntry, error) {
+	entries, err := q.channelEntries(ctx, func(c *client.Client) (*client.ChannelEntryIterator, error) {
+		return c.GetLatestChannelEntriesThatProvide(ctx, group, version, kind)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, registry.NewNotFoundError(registry.APIResource(group, version, kind), "no channel entries found that provide group:%q version:%q kind:%q", group, version, kind)
+	}
+	return entries, nil
+}
+
+// GetBundleThatProvides returns the latest bundle providing the API in its package's default channel,
+// from the first such package by name, as a single catalog would.
+func (q *Querier) GetBundleThatProvides(ctx context.Context, group, version, kind string) (*api.Bundle, error) {
+	entries, err := q.GetLatestChannelEntriesThatProvide(ctx, group, version, kind)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].PackageName < entries[j].PackageName
+	})
+	for _, e := range entries {
+		pkg, err := q.GetPackage(ctx, e.PackageName)
+		if err != nil {
+			return nil, err
+		}
+		if e.ChannelName == pkg.DefaultChannelName {
+			return q.GetBundle(ctx, e.PackageName, e.ChannelName, e.BundleName)
+		}
+	}
+	return nil, registry.NewNotFoundError(registry.APIResource(group, version, kind), "no entry found that provides group:%q version:%q kind:%q", group, version, kind)
+}
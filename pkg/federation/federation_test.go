@@ -0,0 +1,186 @@
+package federation
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+
+	health "github.com/operator-framework/operator-registry/pkg/api/grpc_health_v1"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+	"github.com/operator-framework/operator-registry/pkg/registrytest"
+	"github.com/operator-framework/operator-registry/pkg/server"
+	"github.com/operator-framework/operator-registry/pkg/sqlite"
+)
+
+// catalog returns a catalog with a single-bundle stable channel for each package, named <package>.<version>.
+func catalog(version string, packages ...string) fstest.MapFS {
+	fsys := fstest.MapFS{}
+	for _, p := range packages {
+		fsys[p+".json"] = &fstest.MapFile{Data: []byte(fmt.Sprintf(`{
+    "schema": "olm.package",
+    "name": %[1]q,
+    "defaultChannel": "stable"
+}
+{
+    "schema": "olm.bundle",
+    "name": "%[1]s.%[2]s",
+    "package": %[1]q,
+    "image": "quay.io/example/%[1]s:%[2]s",
+    "properties": [
+        {"type": "olm.channel", "value": {"name": "stable"}},
+        {"type": "olm.package", "value": {"packageName": %[1]q, "version": "%[2]s"}},
+        {"type": "olm.gvk", "value": {"group": "%[1]s.example.com", "version": "v1", "kind": "Widget"}}
+    ]
+}`, p, version))}
+	}
+	return fsys
+}
+
+// upstream serves the catalog in fsys in-process and returns it along with a func that stops the server.
+func upstream(t *testing.T, name string, fsys fstest.MapFS) (Upstream, func()) {
+	s, err := registrytest.NewServerFromFS(fsys)
+	require.NoError(t, err)
+	t.Cleanup(s.Close)
+	return Upstream{Name: name, Client: s.Client}, s.Close
+}
+
+func TestQuerier(t *testing.T) {
+	ctx := context.TODO()
+	a, stopA := upstream(t, "a", catalog("v1.0.0", "foo", "shared"))
+	b, _ := upstream(t, "b", catalog("v2.0.0", "bar", "shared"))
+
+	q := NewQuerier(a, b)
+	q.healthTimeout = 0
+	require.Error(t, q.Ready(), "not yet checked")
+
+	packages, err := q.ListPackages(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"bar", "foo", "shared"}, packages)
+	require.NoError(t, q.Ready())
+
+	t.Run("Priority", func(t *testing.T) {
+		pkg, err := q.GetPackage(ctx, "shared")
+		require.NoError(t, err)
+		require.Equal(t, "shared.v1.0.0", pkg.Channels[0].CurrentCSVName)
+
+		bundle, err := q.GetBundleForChannel(ctx, "shared", "stable")
+		require.NoError(t, err)
+		require.Equal(t, "shared.v1.0.0", bundle.CsvName)
+
+		_, err = q.GetBundle(ctx, "shared", "stable", "shared.v2.0.0")
+		require.Error(t, err, "shadowed by a higher priority upstream")
+
+		bundle, err = q.GetBundle(ctx, "bar", "stable", "bar.v2.0.0")
+		require.NoError(t, err)
+		require.Equal(t, "bar", bundle.PackageName)
+	})
+
+	t.Run("ListBundles", func(t *testing.T) {
+		bundles, err := q.ListBundles(ctx)
+		require.NoError(t, err)
+		var names []string
+		for _, b := range bundles {
+			names = append(names, b.CsvName)
+		}
+		require.ElementsMatch(t, []string{"foo.v1.0.0", "shared.v1.0.0", "bar.v2.0.0"}, names)
+	})
+
+	t.Run("Providers", func(t *testing.T) {
+		entries, err := q.GetChannelEntriesThatProvide(ctx, "shared.example.com", "v1", "Widget")
+		require.NoError(t, err)
+		require.Equal(t, []*registry.ChannelEntry{{PackageName: "shared", ChannelName: "stable", BundleName: "shared.v1.0.0"}}, entries)
+
+		bundle, err := q.GetBundleThatProvides(ctx, "bar.example.com", "v1", "Widget")
+		require.NoError(t, err)
+		require.Equal(t, "bar.v2.0.0", bundle.CsvName)
+
+		_, err = q.GetBundleThatProvides(ctx, "missing.example.com", "v1", "Widget")
+		require.Error(t, err)
+	})
+
+	t.Run("UpstreamHealth", func(t *testing.T) {
+		known, err := q.UpstreamReady("a")
+		require.True(t, known)
+		require.NoError(t, err)
+		known, _ = q.UpstreamReady("c")
+		require.False(t, known)
+
+		stopA()
+		q.Refresh(ctx)
+		known, err = q.UpstreamReady("a")
+		require.True(t, known)
+		require.Error(t, err)
+		require.NoError(t, q.Ready(), "b is still serving")
+
+		res, err := server.NewStoreHealthServer(q).Check(ctx, &health.HealthCheckRequest{Service: "a"})
+		require.NoError(t, err)
+		require.Equal(t, health.HealthCheckResponse_NOT_SERVING, res.Status)
+		res, err = server.NewStoreHealthServer(q).Check(ctx, &health.HealthCheckRequest{Service: "b"})
+		require.NoError(t, err)
+		require.Equal(t, health.HealthCheckResponse_SERVING, res.Status)
+
+		// packages fail over to the next upstream that has them
+		packages, err := q.ListPackages(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []string{"bar", "shared"}, packages)
+		bundle, err := q.GetBundleForChannel(ctx, "shared", "stable")
+		require.NoError(t, err)
+		require.Equal(t, "shared.v2.0.0", bundle.CsvName)
+	})
+}
+
+func TestGRPCQueryConformance(t *testing.T) {
+	registrytest.TestGRPCQuery(t, registrytest.ConformanceCatalog(), func(t *testing.T, dir string) registry.GRPCQuery {
+		// each package is served by its own upstream
+		var upstreams []Upstream
+		for _, pkg := range []string{"foo", "bar"} {
+			db, err := sqlite.Open(filepath.Join(t.TempDir(), "index.db"))
+			require.NoError(t, err)
+			t.Cleanup(func() { db.Close() })
+			load, err := sqlite.NewSQLLiteLoader(db)
+			require.NoError(t, err)
+			require.NoError(t, load.Migrate(context.TODO()))
+			require.NoError(t, sqlite.NewSQLLoaderForDirectory(load, filepath.Join(dir, pkg)).Populate())
+
+			s, err := registrytest.NewServer(sqlite.NewSQLLiteQuerierFromDb(db))
+			require.NoError(t, err)
+			t.Cleanup(s.Close)
+			upstreams = append(upstreams, Upstream{Name: pkg, Client: s.Client})
+		}
+		q := NewQuerier(upstreams...)
+		q.Refresh(context.TODO())
+		require.NoError(t, q.Ready())
+		return q
+	})
+}
+
+func TestQuerierUpstreamErrors(t *testing.T) {
+	ctx := context.TODO()
+	a, err := registrytest.NewServerFromFS(catalog("v1.0.0", "foo"))
+	require.NoError(t, err)
+	t.Cleanup(a.Close)
+	b, err := registrytest.NewServerFromFS(catalog("v1.0.0", "bar"))
+	require.NoError(t, err)
+	t.Cleanup(b.Close)
+
+	q := NewQuerier(Upstream{Name: "a", Client: a.Client}, Upstream{Name: "b", Client: b.Client})
+	q.healthTimeout = 0
+	q.Refresh(ctx)
+
+	// an upstream without entries is skipped
+	b.Inject("GetChannelEntriesThatProvide", registrytest.Fault{Code: codes.NotFound})
+	entries, err := q.GetChannelEntriesThatProvide(ctx, "foo.example.com", "v1", "Widget")
+	require.NoError(t, err)
+	require.Equal(t, []*registry.ChannelEntry{{PackageName: "foo", ChannelName: "stable", BundleName: "foo.v1.0.0"}}, entries)
+
+	// any other failure would make the answer incomplete
+	b.Inject("GetChannelEntriesThatProvide", registrytest.Fault{Code: codes.Internal})
+	_, err = q.GetChannelEntriesThatProvide(ctx, "foo.example.com", "v1", "Widget")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "query b")
+}
@@ -95,8 +95,10 @@ func NewRegistry(options ...RegistryOption) (registry *Registry, err error) {
 		return
 	}
 
+	db := metadata.NewDB(bdb, cs, nil)
 	registry = &Registry{
-		Store:    newStore(metadata.NewDB(bdb, cs, nil)),
+		Store:    newStore(db),
+		db:       db,
 		destroy:  destroy,
 		log:      config.Log,
 		resolver: resolver,
@@ -16,20 +16,25 @@ import (
 	"github.com/containerd/containerd/archive/compression"
 	"github.com/containerd/containerd/errdefs"
 	"github.com/containerd/containerd/images"
+	"github.com/containerd/containerd/metadata"
 	"github.com/containerd/containerd/namespaces"
 	"github.com/containerd/containerd/platforms"
 	"github.com/containerd/containerd/remotes"
+	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/util/retry"
 
 	"github.com/operator-framework/operator-registry/pkg/image"
+	"github.com/operator-framework/operator-registry/pkg/lib/tracing"
 )
 
 // Registry enables manipulation of images via containerd modules.
 type Registry struct {
 	Store
+	db       *metadata.DB
 	destroy  func() error
 	log      *logrus.Entry
 	resolver remotes.Resolver
@@ -41,7 +46,10 @@ var _ image.Registry = &Registry{}
 var nonRetriablePullError = regexp.MustCompile("specified image is a docker schema v1 manifest, which is not supported")
 
 // Pull fetches and stores an image by reference.
-func (r *Registry) Pull(ctx context.Context, ref image.Reference) error {
+func (r *Registry) Pull(ctx context.Context, ref image.Reference) (err error) {
+	ctx, span := tracing.Start(ctx, "image.Pull", attribute.String("image", ref.String()))
+	defer func() { tracing.End(span, err) }()
+
 	// Set the default namespace if unset
 	ctx = ensureNamespace(ctx)
 
@@ -89,9 +97,36 @@ func (r *Registry) Pull(ctx context.Context, ref image.Reference) error {
 	return err
 }
 
+// Digest resolves a reference against its remote registry and returns the digest it currently points to, without pulling it.
+func (r *Registry) Digest(ctx context.Context, ref image.Reference) (digest.Digest, error) {
+	// Set the default namespace if unset
+	ctx = ensureNamespace(ctx)
+
+	name, root, err := r.resolver.Resolve(ctx, ref.String())
+	if err != nil {
+		return "", fmt.Errorf("error resolving name %s: %v", name, err)
+	}
+	return root.Digest, nil
+}
+
+// Remove deletes an image from the cache, along with any content no other image refers to.
+func (r *Registry) Remove(ctx context.Context, ref image.Reference) error {
+	// Set the default namespace if unset
+	ctx = ensureNamespace(ctx)
+
+	if err := r.Images().Delete(ctx, ref.String()); err != nil && !errdefs.IsNotFound(err) {
+		return err
+	}
+	_, err := r.db.GarbageCollect(ctx)
+	return err
+}
+
 // Unpack writes the unpackaged content of an image to a directory.
 // If the referenced image does not exist in the registry, an error is returned.
-func (r *Registry) Unpack(ctx context.Context, ref image.Reference, dir string) error {
+func (r *Registry) Unpack(ctx context.Context, ref image.Reference, dir string) (err error) {
+	ctx, span := tracing.Start(ctx, "image.Unpack", attribute.String("image", ref.String()))
+	defer func() { tracing.End(span, err) }()
+
 	// Set the default namespace if unset
 	ctx = ensureNamespace(ctx)
 
@@ -185,10 +220,12 @@ func (r *Registry) fetch(ctx context.Context, fetcher remotes.Fetcher, root ocis
 		return fmt.Errorf("specified image is a docker schema v1 manifest, which is not supported")
 	}
 
+	// Children are labelled as referenced by their parents, so that garbage collection keeps
+	// all of the content of images that are still stored
 	handler := images.Handlers(
 		visitor,
 		remotes.FetchHandler(r.Content(), fetcher),
-		images.ChildrenHandler(r.Content()),
+		images.SetChildrenLabels(r.Content(), images.ChildrenHandler(r.Content())),
 	)
 
 	return images.Dispatch(ctx, handler, nil, root)
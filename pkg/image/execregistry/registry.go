@@ -4,9 +4,11 @@ import (
 	"context"
 
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/operator-framework/operator-registry/pkg/containertools"
 	"github.com/operator-framework/operator-registry/pkg/image"
+	"github.com/operator-framework/operator-registry/pkg/lib/tracing"
 )
 
 // CommandRunner provides some basic methods for manipulating images via an external container tool.
@@ -34,13 +36,19 @@ func NewRegistry(tool containertools.ContainerTool, logger *logrus.Entry, opts .
 }
 
 // Pull fetches and stores an image by reference.
-func (r *Registry) Pull(ctx context.Context, ref image.Reference) error {
+func (r *Registry) Pull(ctx context.Context, ref image.Reference) (err error) {
+	_, span := tracing.Start(ctx, "image.Pull", attribute.String("image", ref.String()))
+	defer func() { tracing.End(span, err) }()
+
 	return r.cmd.Pull(ref.String())
 }
 
 // Unpack writes the unpackaged content of an image to a directory.
 // If the referenced image does not exist in the registry, an error is returned.
-func (r *Registry) Unpack(ctx context.Context, ref image.Reference, dir string) error {
+func (r *Registry) Unpack(ctx context.Context, ref image.Reference, dir string) (err error) {
+	_, span := tracing.Start(ctx, "image.Unpack", attribute.String("image", ref.String()))
+	defer func() { tracing.End(span, err) }()
+
 	return r.cmd.Unpack(ref.String(), "/.", dir)
 }
 
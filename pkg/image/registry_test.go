@@ -13,6 +13,8 @@ import (
 	"sync"
 	"testing"
 
+	"github.com/containerd/containerd/content"
+	"github.com/containerd/containerd/namespaces"
 	"github.com/docker/distribution"
 	"github.com/docker/distribution/configuration"
 	"github.com/docker/distribution/reference"
@@ -297,3 +299,44 @@ func (f *mockBlobStore) ServeBlob(ctx context.Context, w http.ResponseWriter, r
 func (f *mockBlobStore) Delete(ctx context.Context, dgst digest.Digest) error {
 	return f.base.Delete(ctx, dgst)
 }
+
+func TestContainerdRegistryRemove(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	host, cafile, err := libimage.RunDockerRegistry(ctx, "testdata/golden")
+	require.NoError(t, err)
+
+	r, err := containerdregistry.NewRegistry(
+		containerdregistry.WithLog(logrus.New().WithField("test", t.Name())),
+		containerdregistry.WithCacheDir(fmt.Sprintf("cache-%x", rand.Int())),
+		containerdregistry.WithRootCAs(poolForCertFile(t, cafile)),
+	)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, r.Destroy())
+	}()
+
+	ref := image.SimpleReference(host + "/olmtest/kiali:1.4.2")
+	require.NoError(t, r.Pull(ctx, ref))
+
+	// Removing another image must keep all of the content of the stored one
+	require.NoError(t, r.Remove(ctx, image.SimpleReference(host+"/olmtest/missing:latest")))
+	dir, err := ioutil.TempDir("", "kiali-unpacked-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, r.Unpack(ctx, ref, dir))
+	require.Equal(t, dirChecksum(t, "testdata/golden/bundles/kiali"), dirChecksum(t, dir))
+
+	require.NoError(t, r.Remove(ctx, ref))
+	nsCtx := namespaces.WithNamespace(ctx, namespaces.Default)
+	imgs, err := r.Images().List(nsCtx)
+	require.NoError(t, err)
+	require.Empty(t, imgs)
+	var blobs int
+	require.NoError(t, r.Content().Walk(nsCtx, func(content.Info) error {
+		blobs++
+		return nil
+	}))
+	require.Zero(t, blobs)
+}
@@ -0,0 +1,329 @@
+// Package cache stores a catalog, converted to the form it is served in, so that a server can load it
+// without parsing declarative configs or querying a sqlite database.
+//
+// A cache is a directory of catalogs, each in a file named by the digest of its content. It also records
+// the digest of the source files each catalog was built from, so that a server can find the catalog
+// cached for its source by hashing the source, which is much cheaper than parsing it.
+package cache
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/opencontainers/go-digest"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/operator-framework/operator-registry/internal/declcfg"
+	"github.com/operator-framework/operator-registry/internal/model"
+	"github.com/operator-framework/operator-registry/internal/property"
+	"github.com/operator-framework/operator-registry/pkg/api"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+// FormatVersion is the version of the cache format written by Write. Caches of other versions are never loaded.
+const FormatVersion = 1
+
+// ErrStale is returned by Load when a catalog is cached but can't be used, because the cache has
+// another format version.
+var ErrStale = errors.New("cache is stale")
+
+// header starts a cache file, so that a stale cache can be detected without decoding the catalog.
+type header struct {
+	Version int
+	Digest  string
+}
+
+type catalog struct {
+	Packages []cachedPackage
+}
+
+type cachedPackage struct {
+	Name           string
+	Description    string
+	Icon           *model.Icon
+	DefaultChannel string
+	Channels       []cachedChannel
+}
+
+type cachedChannel struct {
+	Name    string
+	Bundles []cachedBundle
+}
+
+type cachedBundle struct {
+	Name     string
+	Image    string
+	Replaces string
+	Skips    []string
+	// Properties are the bundle's properties but its objects, which are restored from APIBundle.
+	Properties    []property.Property
+	RelatedImages []model.RelatedImage
+	// APIBundle is the protobuf encoding of the bundle's api.Bundle conversion, which holds its objects and CSV.
+	APIBundle []byte
+}
+
+// catalogPath returns the path of the file caching the catalog with the given digest in dir.
+func catalogPath(dir string, dgst digest.Digest) string {
+	return filepath.Join(dir, "catalogs", dgst.Algorithm().String(), dgst.Encoded())
+}
+
+// sourcePath returns the path of the file recording the digest of the catalog built from the source
+// with the given digest in dir.
+func sourcePath(dir string, sourceDigest digest.Digest) string {
+	return filepath.Join(dir, "sources", sourceDigest.Algorithm().String(), sourceDigest.Encoded())
+}
+
+// SourceDigest returns a digest of the raw content of the file or directory at src. Any change to a
+// file, or to which files there are, changes it.
+func SourceDigest(src string) (digest.Digest, error) {
+	digester := digest.Canonical.Digester()
+	h := digester.Hash()
+	// WalkDir visits entries in lexical order, so the digest doesn't depend on the order files were created in
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00", filepath.ToSlash(rel), info.Size())
+		_, err = io.Copy(h, f)
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("digest %s: %v", src, err)
+	}
+	return digester.Digest(), nil
+}
+
+// Write writes a cache of m, built from the source with the given digest, to dir, replacing any cache
+// of the same catalog already there. It returns the digest of m's content, which the cache is keyed by.
+func Write(dir string, m model.Model, sourceDigest digest.Digest) (digest.Digest, error) {
+	if err := sourceDigest.Validate(); err != nil {
+		return "", fmt.Errorf("invalid source digest: %v", err)
+	}
+	dgst, err := declcfg.DigestModel(m)
+	if err != nil {
+		return "", fmt.Errorf("digest catalog: %v", err)
+	}
+
+	var c catalog
+	for _, pkg := range sortedPackages(m) {
+		cp := cachedPackage{
+			Name:           pkg.Name,
+			Description:    pkg.Description,
+			Icon:           pkg.Icon,
+			DefaultChannel: pkg.DefaultChannel.Name,
+		}
+		for _, ch := range sortedChannels(pkg) {
+			cc := cachedChannel{Name: ch.Name}
+			for _, b := range sortedBundles(ch) {
+				apiBundle, err := api.ConvertModelBundleToAPIBundle(*b)
+				if err != nil {
+					return "", fmt.Errorf("convert bundle %q: %v", b.Name, err)
+				}
+				encoded, err := proto.Marshal(apiBundle)
+				if err != nil {
+					return "", fmt.Errorf("encode bundle %q: %v", b.Name, err)
+				}
+				var props []property.Property
+				for _, p := range b.Properties {
+					if p.Type != property.TypeBundleObject {
+						props = append(props, p)
+					}
+				}
+				cc.Bundles = append(cc.Bundles, cachedBundle{
+					Name:          b.Name,
+					Image:         b.Image,
+					Replaces:      b.Replaces,
+					Skips:         b.Skips,
+					Properties:    props,
+					RelatedImages: b.RelatedImages,
+					APIBundle:     encoded,
+				})
+			}
+			cp.Channels = append(cp.Channels, cc)
+		}
+		c.Packages = append(c.Packages, cp)
+	}
+
+	err = writeFile(catalogPath(dir, dgst), func(w io.Writer) error {
+		enc := gob.NewEncoder(w)
+		if err := enc.Encode(header{Version: FormatVersion, Digest: dgst.String()}); err != nil {
+			return err
+		}
+		return enc.Encode(c)
+	})
+	if err != nil {
+		return "", fmt.Errorf("write cache: %v", err)
+	}
+	// record the source only once its catalog is written, so that it never points to a missing catalog
+	err = writeFile(sourcePath(dir, sourceDigest), func(w io.Writer) error {
+		_, err := io.WriteString(w, dgst.String())
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("write cache: %v", err)
+	}
+	return dgst, nil
+}
+
+// writeFile writes a file at path with write, through a temporary file that is renamed, so that a
+// server never reads a partially written file.
+func writeFile(path string, write func(io.Writer) error) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+"-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(f.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// Lookup returns the digest of the catalog cached in dir that was built from the source with the given
+// digest. It returns an error satisfying os.IsNotExist if no catalog was cached for that source.
+func Lookup(dir string, sourceDigest digest.Digest) (digest.Digest, error) {
+	if err := sourceDigest.Validate(); err != nil {
+		return "", fmt.Errorf("invalid source digest: %v", err)
+	}
+	data, err := ioutil.ReadFile(sourcePath(dir, sourceDigest))
+	if err != nil {
+		return "", err
+	}
+	dgst, err := digest.Parse(string(data))
+	if err != nil {
+		return "", fmt.Errorf("read cache: %v", err)
+	}
+	return dgst, nil
+}
+
+// Load returns a querier serving the catalog with the given digest cached in dir. It returns ErrStale if
+// the cache has another format version, and an error satisfying os.IsNotExist if dir doesn't hold it.
+func Load(dir string, dgst digest.Digest) (*registry.Querier, error) {
+	if err := dgst.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid catalog digest: %v", err)
+	}
+	f, err := os.Open(catalogPath(dir, dgst))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	dec := gob.NewDecoder(f)
+	var h header
+	if err := dec.Decode(&h); err != nil {
+		return nil, fmt.Errorf("read cache: %v", err)
+	}
+	if h.Version != FormatVersion {
+		return nil, fmt.Errorf("%w: format version %d, expected %d", ErrStale, h.Version, FormatVersion)
+	}
+	if h.Digest != dgst.String() {
+		return nil, fmt.Errorf("read cache: holds catalog %s, expected %s", h.Digest, dgst)
+	}
+
+	var c catalog
+	if err := dec.Decode(&c); err != nil {
+		return nil, fmt.Errorf("read cache: %v", err)
+	}
+
+	m := model.Model{}
+	var apiBundles []*api.Bundle
+	for _, cp := range c.Packages {
+		pkg := &model.Package{
+			Name:        cp.Name,
+			Description: cp.Description,
+			Icon:        cp.Icon,
+			Channels:    map[string]*model.Channel{},
+		}
+		for _, cc := range cp.Channels {
+			ch := &model.Channel{Package: pkg, Name: cc.Name, Bundles: map[string]*model.Bundle{}}
+			for _, cb := range cc.Bundles {
+				var apiBundle api.Bundle
+				if err := proto.Unmarshal(cb.APIBundle, &apiBundle); err != nil {
+					return nil, fmt.Errorf("read cache: decode bundle %q: %v", cb.Name, err)
+				}
+				apiBundles = append(apiBundles, &apiBundle)
+
+				props := cb.Properties
+				for _, obj := range apiBundle.Object {
+					props = append(props, property.MustBuildBundleObjectData([]byte(obj)))
+				}
+				ch.Bundles[cb.Name] = &model.Bundle{
+					Package:       pkg,
+					Channel:       ch,
+					Name:          cb.Name,
+					Image:         cb.Image,
+					Replaces:      cb.Replaces,
+					Skips:         cb.Skips,
+					Properties:    props,
+					RelatedImages: cb.RelatedImages,
+					Objects:       apiBundle.Object,
+					CsvJSON:       apiBundle.CsvJson,
+				}
+			}
+			pkg.Channels[ch.Name] = ch
+		}
+		pkg.DefaultChannel = pkg.Channels[cp.DefaultChannel]
+		m[pkg.Name] = pkg
+	}
+	return registry.NewQuerier(m, registry.WithAPIBundles(apiBundles), registry.WithDigest(h.Digest)), nil
+}
+
+func sortedPackages(m model.Model) []*model.Package {
+	pkgs := make([]*model.Package, 0, len(m))
+	for _, pkg := range m {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].Name < pkgs[j].Name })
+	return pkgs
+}
+
+func sortedChannels(pkg *model.Package) []*model.Channel {
+	chs := make([]*model.Channel, 0, len(pkg.Channels))
+	for _, ch := range pkg.Channels {
+		chs = append(chs, ch)
+	}
+	sort.Slice(chs, func(i, j int) bool { return chs[i].Name < chs[j].Name })
+	return chs
+}
+
+func sortedBundles(ch *model.Channel) []*model.Bundle {
+	bundles := make([]*model.Bundle, 0, len(ch.Bundles))
+	for _, b := range ch.Bundles {
+		bundles = append(bundles, b)
+	}
+	sort.Slice(bundles, func(i, j int) bool { return bundles[i].Name < bundles[j].Name })
+	return bundles
+}
@@ -0,0 +1,152 @@
+package cache
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/internal/declcfg"
+	"github.com/operator-framework/operator-registry/internal/model"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+	"github.com/operator-framework/operator-registry/pkg/registrytest"
+	"github.com/operator-framework/operator-registry/pkg/sqlite"
+)
+
+const configDir = "../../../internal/action/testdata/foo-index-v0.2.0-declcfg"
+
+func loadConfigs(t *testing.T, dir string) model.Model {
+	cfg, err := declcfg.LoadFS(context.TODO(), os.DirFS(dir))
+	require.NoError(t, err)
+	m, err := declcfg.ConvertToModel(context.TODO(), *cfg)
+	require.NoError(t, err)
+	return m
+}
+
+func TestGRPCQueryConformance(t *testing.T) {
+	registrytest.TestGRPCQuery(t, registrytest.ConformanceCatalog(), func(t *testing.T, dir string) registry.GRPCQuery {
+		tmpDir, err := ioutil.TempDir("", "cache_test-")
+		require.NoError(t, err)
+		t.Cleanup(func() { os.RemoveAll(tmpDir) })
+
+		db, err := sqlite.Open(filepath.Join(tmpDir, "index.db"))
+		require.NoError(t, err)
+		defer db.Close()
+		load, err := sqlite.NewSQLLiteLoader(db)
+		require.NoError(t, err)
+		require.NoError(t, load.Migrate(context.TODO()))
+		require.NoError(t, sqlite.NewSQLLoaderForDirectory(load, dir).Populate())
+		m, err := sqlite.ToModel(context.TODO(), sqlite.NewSQLLiteQuerierFromDb(db))
+		require.NoError(t, err)
+
+		cacheDir := filepath.Join(tmpDir, "cache")
+		dgst, err := Write(cacheDir, m, digest.FromString("source"))
+		require.NoError(t, err)
+		q, err := Load(cacheDir, dgst)
+		require.NoError(t, err)
+		return q
+	})
+}
+
+func TestWriteLoad(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "cache_test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	m := loadConfigs(t, configDir)
+	sourceDigest, err := SourceDigest(configDir)
+	require.NoError(t, err)
+	dgst, err := Write(tmpDir, m, sourceDigest)
+	require.NoError(t, err)
+	expected, err := declcfg.DigestModel(m)
+	require.NoError(t, err)
+	require.Equal(t, expected, dgst)
+
+	cached, err := Lookup(tmpDir, sourceDigest)
+	require.NoError(t, err)
+	require.Equal(t, dgst, cached)
+
+	q, err := Load(tmpDir, dgst)
+	require.NoError(t, err)
+	info, err := q.GetCatalogInfo(context.TODO())
+	require.NoError(t, err)
+	require.Equal(t, dgst.String(), info.Digest)
+
+	// the cached catalog serves the same content as the one it was built from
+	expectedBundles, err := registry.NewQuerier(m).ListBundles(context.TODO())
+	require.NoError(t, err)
+	bundles, err := q.ListBundles(context.TODO())
+	require.NoError(t, err)
+	require.ElementsMatch(t, expectedBundles, bundles)
+
+	t.Run("OtherSource", func(t *testing.T) {
+		_, err := Lookup(tmpDir, digest.FromString("other"))
+		require.True(t, os.IsNotExist(err), err)
+	})
+
+	t.Run("SameCatalogOtherSource", func(t *testing.T) {
+		// the same catalog built from another source is cached once, under its digest
+		other := digest.FromString("other source")
+		otherDgst, err := Write(tmpDir, m, other)
+		require.NoError(t, err)
+		require.Equal(t, dgst, otherDgst)
+		cached, err := Lookup(tmpDir, other)
+		require.NoError(t, err)
+		require.Equal(t, dgst, cached)
+		entries, err := os.ReadDir(filepath.Dir(catalogPath(tmpDir, dgst)))
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		_, err := Load(filepath.Join(tmpDir, "missing"), dgst)
+		require.True(t, os.IsNotExist(err), err)
+	})
+
+	t.Run("OtherVersion", func(t *testing.T) {
+		dir := filepath.Join(tmpDir, "other-version")
+		require.NoError(t, os.MkdirAll(filepath.Dir(catalogPath(dir, dgst)), 0755))
+		f, err := os.Create(catalogPath(dir, dgst))
+		require.NoError(t, err)
+		require.NoError(t, gob.NewEncoder(f).Encode(header{Version: FormatVersion + 1, Digest: dgst.String()}))
+		require.NoError(t, f.Close())
+
+		_, err = Load(dir, dgst)
+		require.True(t, errors.Is(err, ErrStale), err)
+	})
+}
+
+func TestSourceDigest(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "cache_test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	write := func(name, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(tmpDir, name)), 0755))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644))
+	}
+	digest := func() string {
+		d, err := SourceDigest(tmpDir)
+		require.NoError(t, err)
+		return d.String()
+	}
+
+	write("a/index.yaml", "foo")
+	initial := digest()
+	require.Equal(t, initial, digest())
+
+	write("a/index.yaml", "bar")
+	changed := digest()
+	require.NotEqual(t, initial, changed)
+
+	// moving content between files changes the digest
+	write("a/index.yaml", "ba")
+	write("a/more.yaml", "r")
+	require.NotEqual(t, changed, digest())
+}
@@ -0,0 +1,190 @@
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// KeyPairReloader serves a certificate and key pair from disk, reloading them whenever
+// either file is rotated.
+type KeyPairReloader struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	certMod time.Time
+	keyMod  time.Time
+}
+
+// NewKeyPairReloader loads the key pair in certFile and keyFile and returns a reloader for it.
+func NewKeyPairReloader(certFile, keyFile string) (*KeyPairReloader, error) {
+	r := &KeyPairReloader{certFile: certFile, keyFile: keyFile}
+	if _, err := r.certificate(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate.
+func (r *KeyPairReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.certificate()
+}
+
+// GetClientCertificate implements tls.Config.GetClientCertificate.
+func (r *KeyPairReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return r.certificate()
+}
+
+func (r *KeyPairReloader) certificate() (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	certMod, err := modTime(r.certFile)
+	if err != nil {
+		return r.cachedOr(err)
+	}
+	keyMod, err := modTime(r.keyFile)
+	if err != nil {
+		return r.cachedOr(err)
+	}
+	if r.cert != nil && certMod.Equal(r.certMod) && keyMod.Equal(r.keyMod) {
+		return r.cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		// A rotation may be in progress with only one of the files written, keep serving the last good pair
+		return r.cachedOr(fmt.Errorf("unable to load key pair %s, %s: %v", r.certFile, r.keyFile, err))
+	}
+	r.cert, r.certMod, r.keyMod = &cert, certMod, keyMod
+	return r.cert, nil
+}
+
+func (r *KeyPairReloader) cachedOr(err error) (*tls.Certificate, error) {
+	if r.cert != nil {
+		return r.cert, nil
+	}
+	return nil, err
+}
+
+// CertPoolReloader serves a pool of PEM-encoded certificates from disk, reloading it whenever the file is rotated.
+type CertPoolReloader struct {
+	caFile string
+
+	mu   sync.Mutex
+	pool *x509.CertPool
+	mod  time.Time
+}
+
+// NewCertPoolReloader loads the certificates in caFile and returns a reloader for them.
+func NewCertPoolReloader(caFile string) (*CertPoolReloader, error) {
+	r := &CertPoolReloader{caFile: caFile}
+	if _, err := r.Pool(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Pool returns the current certificate pool, reloading it first if the file has changed.
+func (r *CertPoolReloader) Pool() (*x509.CertPool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	mod, err := modTime(r.caFile)
+	if err != nil {
+		return r.cachedOr(err)
+	}
+	if r.pool != nil && mod.Equal(r.mod) {
+		return r.pool, nil
+	}
+
+	pem, err := ioutil.ReadFile(r.caFile)
+	if err != nil {
+		return r.cachedOr(fmt.Errorf("unable to read %s: %v", r.caFile, err))
+	}
+	pool := x509.NewCertPool()
+	if ok := pool.AppendCertsFromPEM(pem); !ok {
+		return r.cachedOr(fmt.Errorf("unable to add certs specified in %s", r.caFile))
+	}
+	r.pool, r.mod = pool, mod
+	return r.pool, nil
+}
+
+func (r *CertPoolReloader) cachedOr(err error) (*x509.CertPool, error) {
+	if r.pool != nil {
+		return r.pool, nil
+	}
+	return nil, err
+}
+
+// ServerTLSConfig returns a TLS config serving the key pair in certFile and keyFile.
+// If clientCAFile is set, clients must present a certificate signed by one of the CAs it contains.
+// All files are reloaded from disk when they change.
+func ServerTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	keyPair, err := NewKeyPairReloader(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: keyPair.GetCertificate,
+	}
+	if len(clientCAFile) == 0 {
+		return config, nil
+	}
+
+	clientCAs, err := NewCertPoolReloader(clientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	config.ClientAuth = tls.RequireAndVerifyClientCert
+	base := config.Clone()
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		pool, err := clientCAs.Pool()
+		if err != nil {
+			return nil, err
+		}
+		c := base.Clone()
+		c.ClientCAs = pool
+		return c, nil
+	}
+	return config, nil
+}
+
+// ClientTLSConfig returns a TLS config that verifies servers against the system roots and the CAs in caFile.
+// If certFile and keyFile are set, the key pair is presented to servers that request a client certificate.
+func ClientTLSConfig(caFile, certFile, keyFile string, insecureSkipVerify bool) (*tls.Config, error) {
+	rootCAs, err := RootCAs(caFile)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		RootCAs:            rootCAs,
+		InsecureSkipVerify: insecureSkipVerify,
+	}
+	if len(certFile) == 0 && len(keyFile) == 0 {
+		return config, nil
+	}
+
+	keyPair, err := NewKeyPairReloader(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	config.GetClientCertificate = keyPair.GetClientCertificate
+	return config, nil
+}
+
+func modTime(path string) (time.Time, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
@@ -0,0 +1,173 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+	kpem []byte
+}
+
+func newTestCert(t *testing.T, cn string, serial int64, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return &testCert{
+		cert: cert,
+		key:  key,
+		pem:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		kpem: pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}),
+	}
+}
+
+func writeFile(t *testing.T, path string, data []byte, mod time.Time) {
+	require.NoError(t, ioutil.WriteFile(path, data, 0600))
+	require.NoError(t, os.Chtimes(path, mod, mod))
+}
+
+// handshake dials a TLS listener using serverConfig with clientConfig and returns the certificate presented by the server
+func handshake(t *testing.T, serverConfig, clientConfig *tls.Config) (*x509.Certificate, error) {
+	lis, err := tls.Listen("tcp", "localhost:0", serverConfig)
+	require.NoError(t, err)
+	defer lis.Close()
+
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if err := conn.(*tls.Conn).Handshake(); err == nil {
+			_, _ = conn.Write([]byte{0})
+		}
+	}()
+
+	conn, err := tls.Dial("tcp", lis.Addr().String(), clientConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	// TLS 1.3 reports client certificate rejections after the client side of the handshake completes,
+	// so wait for the server to acknowledge its side
+	if _, err := conn.Read(make([]byte, 1)); err != nil {
+		return nil, err
+	}
+	return conn.ConnectionState().PeerCertificates[0], nil
+}
+
+func TestServerTLSConfigReloadsKeyPair(t *testing.T) {
+	dir, err := ioutil.TempDir("", "certs-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ca := newTestCert(t, "ca", 1, nil)
+	first := newTestCert(t, "first", 2, ca)
+	second := newTestCert(t, "second", 3, ca)
+
+	caFile, certFile, keyFile := filepath.Join(dir, "ca.crt"), filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeFile(t, caFile, ca.pem, time.Now())
+	writeFile(t, certFile, first.pem, time.Now().Add(-time.Minute))
+	writeFile(t, keyFile, first.kpem, time.Now().Add(-time.Minute))
+
+	serverConfig, err := ServerTLSConfig(certFile, keyFile, "")
+	require.NoError(t, err)
+	clientConfig, err := ClientTLSConfig(caFile, "", "", false)
+	require.NoError(t, err)
+
+	cert, err := handshake(t, serverConfig, clientConfig)
+	require.NoError(t, err)
+	require.Equal(t, "first", cert.Subject.CommonName)
+
+	writeFile(t, certFile, second.pem, time.Now())
+	writeFile(t, keyFile, second.kpem, time.Now())
+
+	cert, err = handshake(t, serverConfig, clientConfig)
+	require.NoError(t, err)
+	require.Equal(t, "second", cert.Subject.CommonName)
+
+	// a partially rotated pair keeps serving the last good certificate
+	writeFile(t, certFile, first.pem, time.Now().Add(time.Minute))
+
+	cert, err = handshake(t, serverConfig, clientConfig)
+	require.NoError(t, err)
+	require.Equal(t, "second", cert.Subject.CommonName)
+}
+
+func TestServerTLSConfigClientCA(t *testing.T) {
+	dir, err := ioutil.TempDir("", "certs-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ca := newTestCert(t, "ca", 1, nil)
+	serving := newTestCert(t, "server", 2, ca)
+	clientCA := newTestCert(t, "client-ca", 3, nil)
+	client := newTestCert(t, "client", 4, clientCA)
+
+	files := map[string][]byte{
+		"ca.crt":        ca.pem,
+		"tls.crt":       serving.pem,
+		"tls.key":       serving.kpem,
+		"client-ca.crt": clientCA.pem,
+		"client.crt":    client.pem,
+		"client.key":    client.kpem,
+	}
+	for name, data := range files {
+		writeFile(t, filepath.Join(dir, name), data, time.Now())
+	}
+
+	serverConfig, err := ServerTLSConfig(filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"), filepath.Join(dir, "client-ca.crt"))
+	require.NoError(t, err)
+
+	anonymous, err := ClientTLSConfig(filepath.Join(dir, "ca.crt"), "", "", false)
+	require.NoError(t, err)
+	_, err = handshake(t, serverConfig, anonymous)
+	require.Error(t, err)
+
+	authenticated, err := ClientTLSConfig(filepath.Join(dir, "ca.crt"), filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key"), false)
+	require.NoError(t, err)
+	cert, err := handshake(t, serverConfig, authenticated)
+	require.NoError(t, err)
+	require.Equal(t, "server", cert.Subject.CommonName)
+}
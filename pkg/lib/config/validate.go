@@ -1,6 +1,7 @@
 package config
 
 import (
+	"context"
 	"io/fs"
 
 	"github.com/operator-framework/operator-registry/internal/declcfg"
@@ -14,8 +15,9 @@ import (
 // Outputs:
 // error: a wrapped error that contains a tree of error strings
 func Validate(root fs.FS) error {
+	ctx := context.TODO()
 	// Load config files and convert them to declcfg objects
-	cfg, err := declcfg.LoadFS(root)
+	cfg, err := declcfg.LoadFS(ctx, root)
 	if err != nil {
 		return err
 	}
@@ -23,7 +25,7 @@ func Validate(root fs.FS) error {
 	// This will convert declcfg objects to intermediate model objects that are
 	// also used for serve and add commands. The conversion process will run
 	// validation for the model objects and ensure they are valid.
-	_, err = declcfg.ConvertToModel(*cfg)
+	_, err = declcfg.ConvertToModel(ctx, *cfg)
 	if err != nil {
 		return err
 	}
@@ -15,14 +15,21 @@ func Shutdown(logger logrus.FieldLogger, run func() error, cleanup func()) error
 	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
 	defer signal.Stop(interrupt)
 
+	// run may also finish without error, e.g. when it is stopped by its caller
+	done := make(chan struct{})
 	g, ctx := errgroup.WithContext(context.Background())
-	g.Go(run)
+	g.Go(func() error {
+		defer close(done)
+		return run()
+	})
 
 	select {
 	case <-interrupt:
 		break
 	case <-ctx.Done():
 		break
+	case <-done:
+		break
 	}
 
 	logger.Info("shutting down...")
@@ -16,6 +16,7 @@ import (
 	"gopkg.in/yaml.v2"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 
+	"github.com/operator-framework/operator-registry/internal/declcfg"
 	"github.com/operator-framework/operator-registry/pkg/containertools"
 	"github.com/operator-framework/operator-registry/pkg/image"
 	"github.com/operator-framework/operator-registry/pkg/image/containerdregistry"
@@ -47,6 +48,8 @@ type ImageIndexer struct {
 	RegistryPruner         registry.RegistryPruner
 	RegistryStrandedPruner registry.RegistryStrandedPruner
 	RegistryDeprecator     registry.RegistryDeprecator
+	RegistryUndeprecator   registry.RegistryUndeprecator
+	RegistryChannelEditor  registry.RegistryChannelEditor
 	BuildTool              containertools.ContainerTool
 	PullTool               containertools.ContainerTool
 	Logger                 *logrus.Entry
@@ -101,7 +104,7 @@ func (i ImageIndexer) AddToIndex(request AddToIndexRequest) error {
 	}
 
 	// generate the dockerfile
-	dockerfile := i.DockerfileGenerator.GenerateIndexDockerfile(request.BinarySourceImage, databasePath)
+	dockerfile := i.generateDockerfile(request.BinarySourceImage, databasePath)
 	err = write(dockerfile, outDockerfile, i.Logger)
 	if err != nil {
 		return err
@@ -129,6 +132,7 @@ type DeleteFromIndexRequest struct {
 	OutDockerfile     string
 	Tag               string
 	Operators         []string
+	Bundles           []string
 	SkipTLS           bool
 	CaFile            string
 }
@@ -150,6 +154,7 @@ func (i ImageIndexer) DeleteFromIndex(request DeleteFromIndexRequest) error {
 	// Run opm registry delete on the database
 	deleteFromRegistryReq := registry.DeleteFromRegistryRequest{
 		Packages:      request.Operators,
+		Bundles:       request.Bundles,
 		InputDatabase: databasePath,
 		Permissive:    request.Permissive,
 	}
@@ -161,7 +166,7 @@ func (i ImageIndexer) DeleteFromIndex(request DeleteFromIndexRequest) error {
 	}
 
 	// generate the dockerfile
-	dockerfile := i.DockerfileGenerator.GenerateIndexDockerfile(request.BinarySourceImage, databasePath)
+	dockerfile := i.generateDockerfile(request.BinarySourceImage, databasePath)
 	err = write(dockerfile, outDockerfile, i.Logger)
 	if err != nil {
 		return err
@@ -217,7 +222,7 @@ func (i ImageIndexer) PruneStrandedFromIndex(request PruneStrandedFromIndexReque
 	}
 
 	// generate the dockerfile
-	dockerfile := i.DockerfileGenerator.GenerateIndexDockerfile(request.BinarySourceImage, databasePath)
+	dockerfile := i.generateDockerfile(request.BinarySourceImage, databasePath)
 	err = write(dockerfile, outDockerfile, i.Logger)
 	if err != nil {
 		return err
@@ -274,7 +279,7 @@ func (i ImageIndexer) PruneFromIndex(request PruneFromIndexRequest) error {
 	}
 
 	// generate the dockerfile
-	dockerfile := i.DockerfileGenerator.GenerateIndexDockerfile(request.BinarySourceImage, databasePath)
+	dockerfile := i.generateDockerfile(request.BinarySourceImage, databasePath)
 	err = write(dockerfile, outDockerfile, i.Logger)
 	if err != nil {
 		return err
@@ -451,6 +456,37 @@ func build(dockerfilePath, imageTag string, commandRunner containertools.Command
 	return nil
 }
 
+// generateDockerfile generates the index dockerfile, labelled with the digest of the content of the
+// database at databasePath when it can be computed.
+func (i ImageIndexer) generateDockerfile(binarySourceImage, databasePath string) string {
+	dockerfile := i.DockerfileGenerator.GenerateIndexDockerfile(binarySourceImage, databasePath)
+
+	dgst, err := databaseDigest(databasePath)
+	if err != nil {
+		i.Logger.WithError(err).Warnf("unable to compute the catalog digest, omitting label %s", containertools.DigestLabel)
+		return dockerfile
+	}
+	return dockerfile + fmt.Sprintf("LABEL %s=%s\n", containertools.DigestLabel, dgst)
+}
+
+func databaseDigest(databasePath string) (string, error) {
+	db, err := sqlite.OpenReadOnly(databasePath)
+	if err != nil {
+		return "", err
+	}
+	defer db.Close()
+
+	m, err := sqlite.ToModel(context.TODO(), sqlite.NewSQLLiteQuerierFromDb(db))
+	if err != nil {
+		return "", err
+	}
+	dgst, err := declcfg.DigestModel(m)
+	if err != nil {
+		return "", err
+	}
+	return dgst.String(), nil
+}
+
 func write(dockerfileText, outDockerfile string, logger *logrus.Entry) error {
 	if outDockerfile == "" {
 		outDockerfile = defaultDockerfileName
@@ -679,7 +715,136 @@ func (i ImageIndexer) DeprecateFromIndex(request DeprecateFromIndexRequest) erro
 	}
 
 	// generate the dockerfile
-	dockerfile := i.DockerfileGenerator.GenerateIndexDockerfile(request.BinarySourceImage, databasePath)
+	dockerfile := i.generateDockerfile(request.BinarySourceImage, databasePath)
+	err = write(dockerfile, outDockerfile, i.Logger)
+	if err != nil {
+		return err
+	}
+
+	if request.Generate {
+		return nil
+	}
+
+	// build the dockerfile with requested tooling
+	err = build(outDockerfile, request.Tag, i.CommandRunner, i.Logger)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UndeprecateFromIndexRequest defines the parameters to send to the UndeprecateFromIndex API
+type UndeprecateFromIndexRequest struct {
+	Generate          bool
+	Permissive        bool
+	BinarySourceImage string
+	FromIndex         string
+	OutDockerfile     string
+	Bundles           []string
+	Restore           []string
+	Tag               string
+	CaFile            string
+	SkipTLS           bool
+}
+
+// UndeprecateFromIndex takes an UndeprecateFromIndexRequest and removes the deprecation
+// of the requested bundles, restoring any truncated bundles given with them.
+func (i ImageIndexer) UndeprecateFromIndex(request UndeprecateFromIndexRequest) error {
+	buildDir, outDockerfile, cleanup, err := buildContext(request.Generate, request.OutDockerfile)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	databasePath, err := i.ExtractDatabase(buildDir, request.FromIndex, request.CaFile, request.SkipTLS)
+	if err != nil {
+		return err
+	}
+
+	// Run opm registry undeprecate on the database
+	undeprecateFromRegistryReq := registry.UndeprecateFromRegistryRequest{
+		Bundles:       request.Bundles,
+		Restore:       request.Restore,
+		InputDatabase: databasePath,
+		Permissive:    request.Permissive,
+		SkipTLS:       request.SkipTLS,
+		CaFile:        request.CaFile,
+		ContainerTool: i.PullTool,
+	}
+
+	err = i.RegistryUndeprecator.UndeprecateFromRegistry(undeprecateFromRegistryReq)
+	if err != nil {
+		return err
+	}
+
+	// generate the dockerfile
+	dockerfile := i.generateDockerfile(request.BinarySourceImage, databasePath)
+	err = write(dockerfile, outDockerfile, i.Logger)
+	if err != nil {
+		return err
+	}
+
+	if request.Generate {
+		return nil
+	}
+
+	// build the dockerfile with requested tooling
+	err = build(outDockerfile, request.Tag, i.CommandRunner, i.Logger)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// EditChannelsInIndexRequest defines the parameters to send to the EditChannelsInIndex API
+type EditChannelsInIndexRequest struct {
+	Generate          bool
+	BinarySourceImage string
+	FromIndex         string
+	OutDockerfile     string
+	Tag               string
+	CaFile            string
+	SkipTLS           bool
+	Operation         registry.ChannelOperation
+	Package           string
+	Channel           string
+	Bundle            string
+	NewName           string
+}
+
+// EditChannelsInIndex takes an EditChannelsInIndexRequest and applies the requested
+// edit to the channels of a package in the index.
+func (i ImageIndexer) EditChannelsInIndex(request EditChannelsInIndexRequest) error {
+	buildDir, outDockerfile, cleanup, err := buildContext(request.Generate, request.OutDockerfile)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	databasePath, err := i.ExtractDatabase(buildDir, request.FromIndex, request.CaFile, request.SkipTLS)
+	if err != nil {
+		return err
+	}
+
+	// Run opm registry channel on the database
+	editChannelsReq := registry.EditChannelsRequest{
+		InputDatabase: databasePath,
+		Operation:     request.Operation,
+		Package:       request.Package,
+		Channel:       request.Channel,
+		Bundle:        request.Bundle,
+		NewName:       request.NewName,
+	}
+
+	err = i.RegistryChannelEditor.EditChannels(editChannelsReq)
+	if err != nil {
+		return err
+	}
+
+	// generate the dockerfile
+	dockerfile := i.generateDockerfile(request.BinarySourceImage, databasePath)
 	err = write(dockerfile, outDockerfile, i.Logger)
 	if err != nil {
 		return err
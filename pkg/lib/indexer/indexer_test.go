@@ -1,14 +1,21 @@
 package indexer
 
 import (
+	"context"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"reflect"
 	"sort"
 	"testing"
 
 	"github.com/ghodss/yaml"
 
+	"github.com/operator-framework/operator-registry/internal/action"
+	"github.com/operator-framework/operator-registry/internal/declcfg"
+	"github.com/operator-framework/operator-registry/internal/property"
+	"github.com/operator-framework/operator-registry/pkg/containertools"
+	"github.com/operator-framework/operator-registry/pkg/image"
 	pregistry "github.com/operator-framework/operator-registry/pkg/registry"
 	"github.com/operator-framework/operator-registry/pkg/sqlite"
 )
@@ -83,3 +90,85 @@ func TestGeneratePackageYaml(t *testing.T) {
 
 	_ = os.RemoveAll("./package.yaml")
 }
+
+func TestDatabaseDigestProvenance(t *testing.T) {
+	ctx := context.TODO()
+	dir := t.TempDir()
+	content, err := ioutil.ReadFile("./testdata/bundles.db")
+	if err != nil {
+		t.Fatalf("reading db: %s", err)
+	}
+	dbFile := filepath.Join(dir, "index.db")
+	if err := ioutil.WriteFile(dbFile, content, 0644); err != nil {
+		t.Fatalf("copying db: %s", err)
+	}
+
+	db, err := sqlite.Open(dbFile)
+	if err != nil {
+		t.Fatalf("opening db: %s", err)
+	}
+	migrator, err := sqlite.NewSQLLiteMigrator(db)
+	if err != nil {
+		t.Fatalf("creating migrator: %s", err)
+	}
+	if err := migrator.Migrate(ctx); err != nil {
+		t.Fatalf("migrating db: %s", err)
+	}
+	if _, err := db.Exec(`INSERT INTO provenance(operatorbundle_name, added_at, source_digest, opm_version, operation) VALUES (?, ?, ?, ?, ?)`,
+		"etcdoperator.v0.9.2", "2021-01-01T00:00:00Z", "sha256:abc", "v1.17.0", "add"); err != nil {
+		t.Fatalf("recording provenance: %s", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("closing db: %s", err)
+	}
+
+	label, err := databaseDigest(dbFile)
+	if err != nil {
+		t.Fatalf("computing label digest: %s", err)
+	}
+
+	store, err := sqlite.NewSQLLiteQuerier(dbFile)
+	if err != nil {
+		t.Fatalf("creating querier: %s", err)
+	}
+	info, err := store.GetCatalogInfo(ctx)
+	if err != nil {
+		t.Fatalf("getting catalog info: %s", err)
+	}
+
+	// the rendered catalog carries the provenance record, which neither digest should depend on
+	render := action.Render{
+		Refs: []string{"test.registry/index:latest"},
+		Registry: &image.MockRegistry{
+			RemoteImages: map[image.Reference]*image.MockImage{
+				image.SimpleReference("test.registry/index:latest"): {
+					Labels: map[string]string{containertools.DbLocationLabel: "/index.db"},
+					FS:     os.DirFS(dir),
+				},
+			},
+		},
+	}
+	cfg, err := render.Run(ctx)
+	if err != nil {
+		t.Fatalf("rendering db: %s", err)
+	}
+	var provenances int
+	for _, b := range cfg.Bundles {
+		props, err := property.Parse(b.Properties)
+		if err != nil {
+			t.Fatalf("parsing properties: %s", err)
+		}
+		provenances += len(props.Provenances)
+	}
+	if provenances != 1 {
+		t.Fatalf("rendering provenance: expected 1 record, got %d", provenances)
+	}
+	rendered, err := declcfg.Digest(ctx, *cfg)
+	if err != nil {
+		t.Fatalf("computing render digest: %s", err)
+	}
+
+	if rendered.String() != info.Digest || label != info.Digest {
+		t.Fatalf("comparing digests: expected render %s and label %s to equal served %s", rendered, label, info.Digest)
+	}
+}
@@ -114,3 +114,37 @@ func NewIndexDeprecator(buildTool, pullTool containertools.ContainerTool, logger
 		Logger:              logger,
 	}
 }
+
+// IndexUndeprecator removes the deprecation of bundles in an index
+type IndexUndeprecator interface {
+	UndeprecateFromIndex(UndeprecateFromIndexRequest) error
+}
+
+func NewIndexUndeprecator(buildTool, pullTool containertools.ContainerTool, logger *logrus.Entry) IndexUndeprecator {
+	return ImageIndexer{
+		DockerfileGenerator:  containertools.NewDockerfileGenerator(logger),
+		CommandRunner:        containertools.NewCommandRunner(buildTool, logger),
+		LabelReader:          containertools.NewLabelReader(pullTool, logger),
+		RegistryUndeprecator: registry.NewRegistryUndeprecator(logger),
+		BuildTool:            buildTool,
+		PullTool:             pullTool,
+		Logger:               logger,
+	}
+}
+
+// IndexChannelEditor edits the channels of packages in an index
+type IndexChannelEditor interface {
+	EditChannelsInIndex(EditChannelsInIndexRequest) error
+}
+
+func NewIndexChannelEditor(buildTool, pullTool containertools.ContainerTool, logger *logrus.Entry) IndexChannelEditor {
+	return ImageIndexer{
+		DockerfileGenerator:   containertools.NewDockerfileGenerator(logger),
+		CommandRunner:         containertools.NewCommandRunner(buildTool, logger),
+		LabelReader:           containertools.NewLabelReader(pullTool, logger),
+		RegistryChannelEditor: registry.NewRegistryChannelEditor(logger),
+		BuildTool:             buildTool,
+		PullTool:              pullTool,
+		Logger:                logger,
+	}
+}
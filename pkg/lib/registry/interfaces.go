@@ -16,6 +16,16 @@ func NewRegistryAdder(logger *logrus.Entry) RegistryAdder {
 	}
 }
 
+type RegistryAddPlanner interface {
+	PlanAddToRegistry(AddToRegistryRequest) ([]PackageChange, error)
+}
+
+func NewRegistryAddPlanner(logger *logrus.Entry) RegistryAddPlanner {
+	return RegistryUpdater{
+		Logger: logger,
+	}
+}
+
 //counterfeiter:generate . RegistryDeleter
 type RegistryDeleter interface {
 	DeleteFromRegistry(DeleteFromRegistryRequest) error
@@ -56,3 +66,23 @@ func NewRegistryDeprecator(logger *logrus.Entry) RegistryDeprecator {
 		Logger: logger,
 	}
 }
+
+type RegistryUndeprecator interface {
+	UndeprecateFromRegistry(UndeprecateFromRegistryRequest) error
+}
+
+func NewRegistryUndeprecator(logger *logrus.Entry) RegistryUndeprecator {
+	return RegistryUpdater{
+		Logger: logger,
+	}
+}
+
+type RegistryChannelEditor interface {
+	EditChannels(EditChannelsRequest) error
+}
+
+func NewRegistryChannelEditor(logger *logrus.Entry) RegistryChannelEditor {
+	return RegistryUpdater{
+		Logger: logger,
+	}
+}
@@ -2,10 +2,16 @@ package registry
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"sort"
+	"time"
 
+	digest "github.com/opencontainers/go-digest"
 	"github.com/sirupsen/logrus"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 
@@ -16,6 +22,7 @@ import (
 	"github.com/operator-framework/operator-registry/pkg/lib/certs"
 	"github.com/operator-framework/operator-registry/pkg/registry"
 	"github.com/operator-framework/operator-registry/pkg/sqlite"
+	"github.com/operator-framework/operator-registry/pkg/version"
 )
 
 type RegistryUpdater struct {
@@ -32,30 +39,196 @@ type AddToRegistryRequest struct {
 	ContainerTool containertools.ContainerTool
 	Overwrite     bool
 	EnableAlpha   bool
+	// Strict applies all of the bundles or, if any of them fails to be added, none of them.
+	Strict bool
 }
 
 func (r RegistryUpdater) AddToRegistry(request AddToRegistryRequest) error {
-	db, err := sqlite.Open(request.InputDatabase)
+	if request.Strict && request.Permissive {
+		return fmt.Errorf("strict mode can't be permissive")
+	}
+	return r.addToRegistry(request)
+}
+
+// stageDatabase copies a database, if it exists, to a new temporary file.
+func stageDatabase(path string) (string, error) {
+	src, err := os.Open(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	if src != nil {
+		defer src.Close()
+	}
+
+	dst, err := ioutil.TempFile("", filepath.Base(path)+".staged-")
 	if err != nil {
-		return err
+		return "", err
 	}
-	defer db.Close()
+	if src != nil {
+		if _, err := io.Copy(dst, src); err != nil {
+			dst.Close()
+			os.Remove(dst.Name())
+			return "", err
+		}
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(dst.Name())
+		return "", err
+	}
+	return dst.Name(), nil
+}
 
-	dbLoader, err := sqlite.NewSQLLiteLoader(db, sqlite.WithEnableAlpha(request.EnableAlpha))
+// PackageChange describes how adding bundles to a database changes one of its packages.
+type PackageChange struct {
+	Package string
+	// New is set if the package isn't in the database yet.
+	New bool
+	// DefaultChannel is the default channel of the package after the change, and PreviousDefaultChannel
+	// the one before it.
+	DefaultChannel         string
+	PreviousDefaultChannel string
+	Channels               []ChannelChange
+}
+
+// ChannelChange describes how adding bundles to a database changes one of the channels of a package.
+type ChannelChange struct {
+	Channel string
+	// Head is the head of the channel after the change, or empty if the channel is removed, and PreviousHead
+	// the one before it, or empty if the channel is new.
+	Head         string
+	PreviousHead string
+	// Bundles are the bundles added to the channel.
+	Bundles []string
+}
+
+// PlanAddToRegistry reports how adding bundles to a database would change its packages, without changing it.
+func (r RegistryUpdater) PlanAddToRegistry(request AddToRegistryRequest) ([]PackageChange, error) {
+	if request.Strict && request.Permissive {
+		return nil, fmt.Errorf("strict mode can't be permissive")
+	}
+
+	staged, err := stageDatabase(request.InputDatabase)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer os.Remove(staged)
 
-	if err := dbLoader.Migrate(context.TODO()); err != nil {
-		return err
+	before, err := snapshotPackages(staged, request.EnableAlpha)
+	if err != nil {
+		return nil, err
 	}
 
-	graphLoader, err := sqlite.NewSQLGraphLoaderFromDB(db)
+	stagedRequest := request
+	stagedRequest.InputDatabase = staged
+	if err := r.addToRegistry(stagedRequest); err != nil {
+		return nil, err
+	}
+
+	after, err := snapshotPackages(staged, request.EnableAlpha)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	return diffPackages(before, after), nil
+}
+
+type packageSnapshot struct {
+	defaultChannel string
+	channels       map[string]channelSnapshot
+}
+
+type channelSnapshot struct {
+	head    string
+	bundles map[string]struct{}
+}
+
+func snapshotPackages(path string, enableAlpha bool) (map[string]packageSnapshot, error) {
+	db, err := sqlite.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	dbLoader, err := sqlite.NewSQLLiteLoader(db, sqlite.WithEnableAlpha(enableAlpha))
+	if err != nil {
+		return nil, err
+	}
+	if err := dbLoader.Migrate(context.TODO()); err != nil {
+		return nil, err
 	}
 	dbQuerier := sqlite.NewSQLLiteQuerierFromDb(db)
 
+	packages, err := dbQuerier.ListPackages(context.TODO())
+	if err != nil {
+		return nil, err
+	}
+	snapshot := make(map[string]packageSnapshot, len(packages))
+	for _, pkg := range packages {
+		manifest, err := dbQuerier.GetPackage(context.TODO(), pkg)
+		if err != nil {
+			return nil, err
+		}
+		entries, err := dbQuerier.GetChannelEntriesFromPackage(context.TODO(), pkg)
+		if err != nil {
+			return nil, err
+		}
+
+		channels := make(map[string]channelSnapshot, len(manifest.Channels))
+		for _, ch := range manifest.Channels {
+			channels[ch.Name] = channelSnapshot{head: ch.CurrentCSVName, bundles: map[string]struct{}{}}
+		}
+		for _, entry := range entries {
+			if ch, ok := channels[entry.ChannelName]; ok {
+				ch.bundles[entry.BundleName] = struct{}{}
+			}
+		}
+		snapshot[pkg] = packageSnapshot{defaultChannel: manifest.DefaultChannelName, channels: channels}
+	}
+	return snapshot, nil
+}
+
+func diffPackages(before, after map[string]packageSnapshot) []PackageChange {
+	var changes []PackageChange
+	for pkg, next := range after {
+		prev, ok := before[pkg]
+		change := PackageChange{
+			Package:                pkg,
+			New:                    !ok,
+			DefaultChannel:         next.defaultChannel,
+			PreviousDefaultChannel: prev.defaultChannel,
+		}
+
+		for name, ch := range next.channels {
+			prevCh := prev.channels[name]
+			var added []string
+			for bundle := range ch.bundles {
+				if _, ok := prevCh.bundles[bundle]; !ok {
+					added = append(added, bundle)
+				}
+			}
+			if ch.head == prevCh.head && len(added) == 0 && len(ch.bundles) == len(prevCh.bundles) {
+				continue
+			}
+			sort.Strings(added)
+			change.Channels = append(change.Channels, ChannelChange{Channel: name, Head: ch.head, PreviousHead: prevCh.head, Bundles: added})
+		}
+		for name, ch := range prev.channels {
+			if _, ok := next.channels[name]; !ok {
+				change.Channels = append(change.Channels, ChannelChange{Channel: name, PreviousHead: ch.head})
+			}
+		}
+
+		if len(change.Channels) == 0 && change.DefaultChannel == change.PreviousDefaultChannel {
+			continue
+		}
+		sort.Slice(change.Channels, func(i, j int) bool { return change.Channels[i].Channel < change.Channels[j].Channel })
+		changes = append(changes, change)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Package < changes[j].Package })
+	return changes
+}
+
+func (r RegistryUpdater) addToRegistry(request AddToRegistryRequest) error {
 	// add custom ca certs to resolver
 
 	var reg image.Registry
@@ -81,24 +254,109 @@ func (r RegistryUpdater) AddToRegistry(request AddToRegistryRequest) error {
 		}
 	}()
 
+	return r.addToDatabase(context.TODO(), reg, request)
+}
+
+// addToDatabase adds the bundle images of a request, pulled from reg, to its database.
+func (r RegistryUpdater) addToDatabase(ctx context.Context, reg image.Registry, request AddToRegistryRequest) error {
+	db, err := sqlite.Open(request.InputDatabase)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// populate fills in the operation of each bundle image before it is added, and the loader records it, with
+	// the digest the pulled image resolved to, in the same transaction as the bundle
+	provenance := make(map[image.Reference]registry.Provenance, len(request.Bundles))
+	addedAt := time.Now()
+	recordProvenance := func(path string) (registry.Provenance, bool) {
+		ref := image.SimpleReference(path)
+		record, ok := provenance[ref]
+		if ok {
+			record.SourceDigest = r.resolveDigest(ctx, reg, ref)
+		}
+		record.AddedAt = addedAt
+		record.OpmVersion = version.OpmVersion
+		return record, ok
+	}
+	opts := []sqlite.DbOption{sqlite.WithEnableAlpha(request.EnableAlpha), sqlite.WithContext(ctx), sqlite.WithProvenance(recordProvenance)}
+	dbLoader, err := sqlite.NewSQLLiteLoader(db, opts...)
+	if err != nil {
+		return err
+	}
+
+	if err := dbLoader.Migrate(ctx); err != nil {
+		return err
+	}
+
+	graphLoader, err := sqlite.NewSQLGraphLoaderFromDB(db)
+	if err != nil {
+		return err
+	}
+	dbQuerier := sqlite.NewSQLLiteQuerierFromDb(db)
+
+	// in strict mode, the bundles are added in one transaction, which is committed only if they all are added
+	var tx *sql.Tx
+	if request.Strict {
+		tx, err = db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		dbLoader, err = sqlite.NewSQLLiteLoader(db, append(opts, sqlite.WithTx(tx))...)
+		if err != nil {
+			return err
+		}
+		dbQuerier = sqlite.NewSQLLiteQuerierFromTx(tx)
+		graphLoader = &sqlite.SQLGraphLoader{Querier: dbQuerier}
+	}
+
 	simpleRefs := make([]image.Reference, 0)
 	for _, ref := range request.Bundles {
 		simpleRefs = append(simpleRefs, image.SimpleReference(ref))
 	}
 
-	if err := populate(context.TODO(), dbLoader, graphLoader, dbQuerier, reg, simpleRefs, request.Mode, request.Overwrite); err != nil {
+	if err := populate(ctx, dbLoader, graphLoader, dbQuerier, reg, simpleRefs, provenance, request.Mode, request.Overwrite); err != nil {
 		r.Logger.Debugf("unable to populate database: %s", err)
 
+		if request.Strict {
+			r.Logger.WithError(err).Error("strict mode enabled, leaving the database unchanged")
+			return err
+		}
 		if !request.Permissive {
 			r.Logger.WithError(err).Error("permissive mode disabled")
 			return err
 		}
 		r.Logger.WithError(err).Warn("permissive mode enabled")
+		return nil
 	}
 
+	if tx != nil {
+		return tx.Commit()
+	}
 	return nil
 }
 
+// digester is implemented by registries that can resolve the digest of an image reference.
+type digester interface {
+	Digest(ctx context.Context, ref image.Reference) (digest.Digest, error)
+}
+
+// resolveDigest returns the digest a reference resolves to, or an empty string when the registry can't resolve it.
+func (r RegistryUpdater) resolveDigest(ctx context.Context, reg image.Registry, ref image.Reference) string {
+	d, ok := reg.(digester)
+	if !ok {
+		return ""
+	}
+	dgst, err := d.Digest(ctx, ref)
+	if err != nil {
+		r.Logger.WithError(err).WithField("image", ref).Debug("unable to resolve image digest")
+		return ""
+	}
+	return dgst.String()
+}
+
 func unpackImage(ctx context.Context, reg image.Registry, ref image.Reference) (image.Reference, string, func(), error) {
 	var errs []error
 	workingDir, err := ioutil.TempDir("./", "bundle_tmp")
@@ -126,7 +384,9 @@ func unpackImage(ctx context.Context, reg image.Registry, ref image.Reference) (
 	return ref, workingDir, cleanup, nil
 }
 
-func populate(ctx context.Context, loader registry.Load, graphLoader registry.GraphLoader, querier registry.Query, reg image.Registry, refs []image.Reference, mode registry.Mode, overwrite bool) error {
+// populate adds the referenced bundle images to the database, filling in the operation of each in provenance
+// before it is added.
+func populate(ctx context.Context, loader registry.Load, graphLoader registry.GraphLoader, querier registry.Query, reg image.Registry, refs []image.Reference, provenance map[image.Reference]registry.Provenance, mode registry.Mode, overwrite bool) error {
 	unpackedImageMap := make(map[image.Reference]string, 0)
 	for _, ref := range refs {
 		to, from, cleanup, err := unpackImage(ctx, reg, ref)
@@ -135,6 +395,7 @@ func populate(ctx context.Context, loader registry.Load, graphLoader registry.Gr
 		}
 		unpackedImageMap[to] = from
 		defer cleanup()
+		provenance[to] = registry.Provenance{Operation: registry.ProvenanceAdd}
 	}
 
 	overwriteImageMap := make(map[string]map[image.Reference]string, 0)
@@ -155,6 +416,9 @@ func populate(ctx context.Context, loader registry.Load, graphLoader registry.Gr
 			if overwritten == "" {
 				return fmt.Errorf("index add --overwrite-latest is only supported when using bundle images")
 			}
+			record := provenance[to]
+			record.Operation = registry.ProvenanceOverwrite
+			provenance[to] = record
 			// get all bundle paths for that package - we will re-add these to regenerate the graph
 			bundles, err := querier.GetBundlesForPackage(ctx, img.Bundle.Package)
 			if err != nil {
@@ -203,6 +467,8 @@ type DeleteFromRegistryRequest struct {
 	Permissive    bool
 	InputDatabase string
 	Packages      []string
+	// Bundles are the images of individual bundles to remove
+	Bundles []string
 }
 
 func (r RegistryUpdater) DeleteFromRegistry(request DeleteFromRegistryRequest) error {
@@ -232,6 +498,18 @@ func (r RegistryUpdater) DeleteFromRegistry(request DeleteFromRegistryRequest) e
 		}
 	}
 
+	if len(request.Bundles) > 0 {
+		remover := sqlite.NewSQLRemoverForBundles(dbLoader, request.Bundles)
+		if err := remover.Remove(); err != nil {
+			err = fmt.Errorf("error deleting bundles from database: %s", err)
+			if !request.Permissive {
+				logrus.WithError(err).Fatal("permissive mode disabled")
+				return err
+			}
+			logrus.WithError(err).Warn("permissive mode enabled")
+		}
+	}
+
 	// remove any stranded bundles from the database
 	// TODO: This is unnecessary if the db schema can prevent this orphaned data from existing
 	remover := sqlite.NewSQLStrandedBundleRemover(dbLoader)
@@ -334,7 +612,12 @@ func (r RegistryUpdater) DeprecateFromRegistry(request DeprecateFromRegistryRequ
 	}
 	defer db.Close()
 
-	dbLoader, err := sqlite.NewSQLLiteLoader(db)
+	record := registry.Provenance{
+		AddedAt:    time.Now(),
+		OpmVersion: version.OpmVersion,
+		Operation:  registry.ProvenanceDeprecate,
+	}
+	dbLoader, err := sqlite.NewSQLLiteLoader(db, sqlite.WithProvenance(func(string) (registry.Provenance, bool) { return record, true }))
 	if err != nil {
 		return err
 	}
@@ -364,6 +647,137 @@ func (r RegistryUpdater) DeprecateFromRegistry(request DeprecateFromRegistryRequ
 			return err
 		}
 		r.Logger.WithError(err).Warn("permissive mode enabled")
+		return nil
+	}
+
+	return nil
+}
+
+type UndeprecateFromRegistryRequest struct {
+	Permissive    bool
+	SkipTLS       bool
+	CaFile        string
+	InputDatabase string
+	// Bundles are the images of the deprecated bundles to undeprecate
+	Bundles []string
+	// Restore are the images of bundles truncated by the deprecations to add back
+	Restore       []string
+	ContainerTool containertools.ContainerTool
+}
+
+// UndeprecateFromRegistry removes the deprecation of bundles. Truncated bundles given to restore are added back
+// first, so that the undeprecated bundles replace them again.
+func (r RegistryUpdater) UndeprecateFromRegistry(request UndeprecateFromRegistryRequest) error {
+	if len(request.Restore) > 0 {
+		addRequest := AddToRegistryRequest{
+			Permissive:    request.Permissive,
+			SkipTLS:       request.SkipTLS,
+			CaFile:        request.CaFile,
+			InputDatabase: request.InputDatabase,
+			Bundles:       request.Restore,
+			Mode:          registry.ReplacesMode,
+			ContainerTool: request.ContainerTool,
+		}
+		if err := r.AddToRegistry(addRequest); err != nil {
+			return fmt.Errorf("unable to restore truncated bundles: %s", err)
+		}
+	}
+
+	db, err := sqlite.Open(request.InputDatabase)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	dbLoader, err := sqlite.NewSQLLiteLoader(db)
+	if err != nil {
+		return err
+	}
+	if err := dbLoader.Migrate(context.TODO()); err != nil {
+		return fmt.Errorf("unable to migrate database: %s", err)
+	}
+
+	dbQuerier := sqlite.NewSQLLiteQuerierFromDb(db)
+	toUndeprecate, _, err := checkForBundlePaths(dbQuerier, request.Bundles)
+	if err != nil {
+		if !request.Permissive {
+			r.Logger.WithError(err).Error("permissive mode disabled")
+			return err
+		}
+		r.Logger.WithError(err).Warn("permissive mode enabled")
+	}
+
+	undeprecator := sqlite.NewSQLUndeprecatorForBundles(dbLoader, toUndeprecate)
+	if err := undeprecator.Undeprecate(); err != nil {
+		r.Logger.Debugf("unable to undeprecate bundles from database: %s", err)
+		if !request.Permissive {
+			r.Logger.WithError(err).Error("permissive mode disabled")
+			return err
+		}
+		r.Logger.WithError(err).Warn("permissive mode enabled")
+	}
+
+	return nil
+}
+
+// ChannelOperation is an edit of the channels of a package.
+type ChannelOperation string
+
+const (
+	// AddBundleToChannel adds Bundle to Channel, as its new head.
+	AddBundleToChannel ChannelOperation = "add"
+	// RemoveBundleFromChannel removes Bundle from Channel, linking the channel around it.
+	RemoveBundleFromChannel ChannelOperation = "rm"
+	// RenameChannel renames Channel to NewName.
+	RenameChannel ChannelOperation = "rename"
+	// UpdateDefaultChannel makes Channel the default channel of Package.
+	UpdateDefaultChannel ChannelOperation = "default"
+)
+
+type EditChannelsRequest struct {
+	InputDatabase string
+	Operation     ChannelOperation
+	Package       string
+	Channel       string
+	// Bundle is the name of the bundle to add or remove
+	Bundle string
+	// NewName is the name to rename the channel to
+	NewName string
+}
+
+func (r RegistryUpdater) EditChannels(request EditChannelsRequest) error {
+	db, err := sqlite.Open(request.InputDatabase)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	dbLoader, err := sqlite.NewSQLLiteLoader(db)
+	if err != nil {
+		return err
+	}
+	if err := dbLoader.Migrate(context.TODO()); err != nil {
+		return fmt.Errorf("unable to migrate database: %s", err)
+	}
+	editor, ok := dbLoader.(registry.Editor)
+	if !ok {
+		return fmt.Errorf("loader %T can't edit channels", dbLoader)
+	}
+
+	switch request.Operation {
+	case AddBundleToChannel:
+		err = editor.AddBundleToChannel(request.Package, request.Channel, request.Bundle)
+	case RemoveBundleFromChannel:
+		err = editor.RemoveBundleFromChannel(request.Package, request.Channel, request.Bundle)
+	case RenameChannel:
+		err = editor.RenameChannel(request.Package, request.Channel, request.NewName)
+	case UpdateDefaultChannel:
+		err = editor.UpdateDefaultChannel(request.Package, request.Channel)
+	default:
+		return fmt.Errorf("unknown channel operation %q", request.Operation)
+	}
+	if err != nil {
+		return fmt.Errorf("error editing channel %s of package %s: %s", request.Channel, request.Package, err)
 	}
 
 	return nil
@@ -5,15 +5,20 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 	"testing/fstest"
 
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
 
 	"github.com/operator-framework/operator-registry/internal/model"
 	"github.com/operator-framework/operator-registry/internal/property"
 	"github.com/operator-framework/operator-registry/pkg/image"
 	"github.com/operator-framework/operator-registry/pkg/registry"
+	"github.com/operator-framework/operator-registry/pkg/sqlite"
 )
 
 func fakeBundlePathFromName(name string) string {
@@ -241,3 +246,129 @@ func TestUnpackImage(t *testing.T) {
 		})
 	}
 }
+
+func TestPlanPackageChanges(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "index.db")
+
+	populate := func(dbPath string, names ...string) {
+		t.Helper()
+		db, err := sqlite.Open(dbPath)
+		require.NoError(t, err)
+		defer db.Close()
+		loader, err := sqlite.NewSQLLiteLoader(db)
+		require.NoError(t, err)
+		require.NoError(t, loader.Migrate(context.TODO()))
+		graphLoader, err := sqlite.NewSQLGraphLoaderFromDB(db)
+		require.NoError(t, err)
+
+		refs := map[image.Reference]string{}
+		for _, name := range names {
+			refs[image.SimpleReference("quay.io/test/"+name)] = "../../../bundles/" + name
+		}
+		require.NoError(t, registry.NewDirectoryPopulator(loader, graphLoader, sqlite.NewSQLLiteQuerierFromDb(db), refs, nil, false).Populate(registry.ReplacesMode))
+	}
+	populate(path, "prometheus.0.14.0")
+
+	// staging copies the database, leaving it unchanged
+	staged, err := stageDatabase(path)
+	require.NoError(t, err)
+	defer os.Remove(staged)
+	require.Equal(t, filepath.Clean(os.TempDir()), filepath.Dir(staged))
+
+	before, err := snapshotPackages(staged, false)
+	require.NoError(t, err)
+	populate(staged, "prometheus.0.15.0", "prometheus.0.22.2")
+	after, err := snapshotPackages(staged, false)
+	require.NoError(t, err)
+
+	unchanged, err := snapshotPackages(path, false)
+	require.NoError(t, err)
+	require.Equal(t, before, unchanged)
+
+	require.Equal(t, []PackageChange{
+		{
+			Package:                "prometheus",
+			DefaultChannel:         "preview",
+			PreviousDefaultChannel: "preview",
+			Channels: []ChannelChange{
+				{Channel: "preview", Head: "prometheusoperator.0.22.2", PreviousHead: "prometheusoperator.0.14.0", Bundles: []string{"prometheusoperator.0.15.0", "prometheusoperator.0.22.2"}},
+				// stable is a new channel, which 0.15.0 brings 0.14.0 into by replacing it
+				{Channel: "stable", Head: "prometheusoperator.0.15.0", Bundles: []string{"prometheusoperator.0.14.0", "prometheusoperator.0.15.0"}},
+			},
+		},
+	}, diffPackages(before, after))
+	require.Empty(t, diffPackages(after, after))
+
+	// staging a database that doesn't exist yet stages an empty one, in which every package is new
+	staged, err = stageDatabase(filepath.Join(dir, "new.db"))
+	require.NoError(t, err)
+	defer os.Remove(staged)
+	empty, err := snapshotPackages(staged, false)
+	require.NoError(t, err)
+	changes := diffPackages(empty, after)
+	require.Len(t, changes, 1)
+	require.True(t, changes[0].New)
+	require.Equal(t, "", changes[0].PreviousDefaultChannel)
+}
+
+func TestAddToRegistryStrict(t *testing.T) {
+	dir := t.TempDir()
+
+	bundleImage := func(name string) *image.MockImage {
+		return &image.MockImage{FS: os.DirFS("../../../bundles/" + name)}
+	}
+	reg := &image.MockRegistry{
+		RemoteImages: map[image.Reference]*image.MockImage{
+			image.SimpleReference("quay.io/test/prometheus.0.14.0"): bundleImage("prometheus.0.14.0"),
+			image.SimpleReference("quay.io/test/prometheus.0.15.0"): bundleImage("prometheus.0.15.0"),
+			image.SimpleReference("quay.io/test/prometheus.0.22.2"): bundleImage("prometheus.0.22.2"),
+		},
+	}
+	updater := RegistryUpdater{Logger: logrus.NewEntry(logrus.New())}
+
+	// newDatabase returns the path of a database with 0.14.0 added to it, and its content
+	newDatabase := func(name string) (string, []byte) {
+		path := filepath.Join(dir, name)
+		require.NoError(t, updater.addToDatabase(context.TODO(), reg, AddToRegistryRequest{
+			InputDatabase: path,
+			Bundles:       []string{"quay.io/test/prometheus.0.14.0"},
+			Mode:          registry.ReplacesMode,
+		}))
+		content, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		return path, content
+	}
+	// 0.22.2 is added, but its channels can't be, since the bundle it replaces is missing
+	failing := AddToRegistryRequest{
+		Bundles: []string{"quay.io/test/prometheus.0.22.2"},
+		Mode:    registry.ReplacesMode,
+	}
+
+	// without strict mode, the bundles that can be added are
+	path, content := newDatabase("lenient.db")
+	request := failing
+	request.InputDatabase = path
+	require.Error(t, updater.addToDatabase(context.TODO(), reg, request))
+	changed, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.NotEqual(t, content, changed)
+
+	// in strict mode, the database file is unchanged
+	path, content = newDatabase("strict.db")
+	request.InputDatabase = path
+	request.Strict = true
+	require.Error(t, updater.addToDatabase(context.TODO(), reg, request))
+	unchanged, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, content, unchanged)
+
+	// and the bundles are all added when none of them fails
+	request.Bundles = []string{"quay.io/test/prometheus.0.15.0"}
+	require.NoError(t, updater.addToDatabase(context.TODO(), reg, request))
+	querier, err := sqlite.NewSQLLiteQuerier(path)
+	require.NoError(t, err)
+	bundlePath, err := querier.GetBundlePathIfExists(context.TODO(), "prometheusoperator.0.15.0")
+	require.NoError(t, err)
+	require.Equal(t, "quay.io/test/prometheus.0.15.0", bundlePath)
+}
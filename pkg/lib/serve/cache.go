@@ -0,0 +1,96 @@
+package serve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/sirupsen/logrus"
+
+	"github.com/operator-framework/operator-registry/internal/model"
+	"github.com/operator-framework/operator-registry/pkg/lib/cache"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+// cachedSource loads the catalog cached in cacheDir for src if there is one, and otherwise falls back
+// to loading src.
+type cachedSource struct {
+	src      string
+	cacheDir string
+	fallback source
+	logger   *logrus.Entry
+}
+
+func (c *cachedSource) load(ctx context.Context) (registry.GRPCQuery, error) {
+	logger := c.logger.WithField("cache-dir", c.cacheDir)
+	sourceDigest, err := cache.SourceDigest(c.src)
+	if err != nil {
+		return nil, err
+	}
+	q, err := c.loadCache(sourceDigest)
+	switch {
+	case err == nil:
+		logger.Info("loaded catalog from cache")
+		return q, nil
+	case os.IsNotExist(err):
+		logger.Warn("no cache found for source, loading source")
+	case errors.Is(err, cache.ErrStale):
+		logger.WithError(err).Warn("cache is stale, loading source")
+	default:
+		logger.WithError(err).Warn("unable to load cache, loading source")
+	}
+	return c.fallback.load(ctx)
+}
+
+func (c *cachedSource) loadCache(sourceDigest digest.Digest) (registry.GRPCQuery, error) {
+	dgst, err := cache.Lookup(c.cacheDir, sourceDigest)
+	if err != nil {
+		return nil, err
+	}
+	return cache.Load(c.cacheDir, dgst)
+}
+
+func (c *cachedSource) close() error {
+	return c.fallback.close()
+}
+
+// BuildCache writes a cache of the catalog in src, a sqlite database file or a directory of declarative
+// configs, to cacheDir. Serve loads it instead of src when given the same cache directory, for as long as
+// src is unchanged, if it serves src from memory: always for declarative configs, and with InMemory for
+// a sqlite database. It returns the digest of the catalog's content, which the cache is keyed by.
+func BuildCache(ctx context.Context, src, cacheDir string, logger *logrus.Entry) (digest.Digest, error) {
+	sourceType, err := DetectSource(src)
+	if err != nil {
+		return "", err
+	}
+	// hash the source before loading it, so that a cache is never considered valid for content it doesn't hold
+	sourceDigest, err := cache.SourceDigest(src)
+	if err != nil {
+		return "", err
+	}
+
+	var m model.Model
+	switch sourceType {
+	case SqliteSource:
+		s := &sqliteSource{path: src, logger: logger}
+		defer s.close()
+		store, err := s.open(ctx)
+		if err != nil {
+			return "", err
+		}
+		m, err = s.model(ctx, store)
+		if err != nil {
+			return "", err
+		}
+	case DeclarativeConfigSource:
+		m, err = dirSource{dir: src}.model(ctx)
+		if err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("caching a catalog is only supported for a sqlite database or a declarative config directory")
+	}
+	return cache.Write(cacheDir, m, sourceDigest)
+}
@@ -0,0 +1,150 @@
+package serve
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/docker/distribution/reference"
+	"github.com/opencontainers/go-digest"
+	"github.com/sirupsen/logrus"
+
+	"github.com/operator-framework/operator-registry/internal/action"
+	"github.com/operator-framework/operator-registry/internal/declcfg"
+	"github.com/operator-framework/operator-registry/pkg/image"
+	"github.com/operator-framework/operator-registry/pkg/image/containerdregistry"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+// imageSource loads the catalog in an index image, pinned to the digest its reference resolved to.
+type imageSource struct {
+	ref      string
+	registry *containerdregistry.Registry
+	logger   *logrus.Entry
+
+	digest digest.Digest
+}
+
+func newImageSource(ref string, logger *logrus.Entry) (*imageSource, error) {
+	cacheDir, err := os.MkdirTemp("", "serve-registry-")
+	if err != nil {
+		return nil, fmt.Errorf("create tempdir: %v", err)
+	}
+
+	// The containerd registry impl is somewhat verbose, even on the happy path,
+	// so only pass along its logs when debugging.
+	regLogger := logrus.New()
+	regLogger.SetOutput(ioutil.Discard)
+	if logger.Logger.IsLevelEnabled(logrus.DebugLevel) {
+		regLogger = logger.Logger
+	}
+	reg, err := containerdregistry.NewRegistry(
+		containerdregistry.WithCacheDir(cacheDir),
+		containerdregistry.WithLog(logrus.NewEntry(regLogger)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &imageSource{ref: ref, registry: reg, logger: logger}, nil
+}
+
+// load resolves the image reference to its current digest and loads the catalog from the image at that digest.
+func (i *imageSource) load(ctx context.Context) (registry.GRPCQuery, error) {
+	dgst, err := i.registry.Digest(ctx, image.SimpleReference(i.ref))
+	if err != nil {
+		return nil, err
+	}
+	store, err := i.loadDigest(ctx, dgst)
+	if err != nil {
+		return nil, err
+	}
+	i.digest = dgst
+	return store, nil
+}
+
+func (i *imageSource) loadDigest(ctx context.Context, dgst digest.Digest) (registry.GRPCQuery, error) {
+	ref, err := digestReference(i.ref, dgst)
+	if err != nil {
+		return nil, err
+	}
+	i.logger.WithField("image", ref).Info("loading catalog from image")
+
+	cfg, err := action.IndexImage{Ref: ref, Registry: i.registry}.Run(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m, err := declcfg.ConvertToModel(ctx, *cfg)
+	if err != nil {
+		return nil, fmt.Errorf("could not build index model from declarative config: %v", err)
+	}
+	return registry.NewQuerier(m), nil
+}
+
+// poll checks the image reference for a new digest every interval until ctx is done.
+// When the digest changes, the catalog at the new digest replaces the one served by store.
+// If the new catalog fails to load, the previous one continues to be served but store is
+// marked as failed, and the load is retried on the next interval.
+func (i *imageSource) poll(ctx context.Context, interval time.Duration, store *registry.SwappableQuerier) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		dgst, err := i.registry.Digest(ctx, image.SimpleReference(i.ref))
+		if err != nil {
+			i.logger.WithError(err).Warn("unable to resolve image digest")
+			continue
+		}
+		if dgst == i.digest {
+			continue
+		}
+
+		logger := i.logger.WithFields(logrus.Fields{"previous": i.digest, "digest": dgst})
+		next, err := i.loadDigest(ctx, dgst)
+		if err != nil {
+			logger.WithError(err).Warn("unable to reload catalog, continuing to serve previous digest")
+			store.Fail(fmt.Errorf("reload %s: %v", dgst, err))
+			continue
+		}
+		store.Swap(next)
+		previous := i.digest
+		i.digest = dgst
+		logger.Info("reloaded catalog")
+
+		// The previous image is no longer served, so it doesn't need to stay in the cache
+		if err := i.removeDigest(ctx, previous); err != nil {
+			logger.WithError(err).Warn("unable to remove previous image from cache")
+		}
+	}
+}
+
+func (i *imageSource) removeDigest(ctx context.Context, dgst digest.Digest) error {
+	ref, err := digestReference(i.ref, dgst)
+	if err != nil {
+		return err
+	}
+	return i.registry.Remove(ctx, image.SimpleReference(ref))
+}
+
+func (i *imageSource) close() error {
+	return i.registry.Destroy()
+}
+
+// digestReference returns the reference to the image with the given digest in the repository of ref.
+func digestReference(ref string, dgst digest.Digest) (string, error) {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return "", fmt.Errorf("parse image reference %q: %v", ref, err)
+	}
+	canonical, err := reference.WithDigest(reference.TrimNamed(named), dgst)
+	if err != nil {
+		return "", err
+	}
+	return canonical.String(), nil
+}
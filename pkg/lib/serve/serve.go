@@ -0,0 +1,246 @@
+package serve
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/pflag"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+	health "github.com/operator-framework/operator-registry/pkg/api/grpc_health_v1"
+	"github.com/operator-framework/operator-registry/pkg/lib/dns"
+	"github.com/operator-framework/operator-registry/pkg/lib/graceful"
+	"github.com/operator-framework/operator-registry/pkg/lib/log"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+	"github.com/operator-framework/operator-registry/pkg/server"
+)
+
+// Options configure how a catalog is served. They are shared by every command that serves a catalog.
+type Options struct {
+	Port           string
+	HTTPPort       string
+	MetricsPort    string
+	TerminationLog string
+
+	TLSCert     string
+	TLSKey      string
+	TLSClientCA string
+
+	// Timeout stops serving after it elapses. Zero serves until interrupted.
+	Timeout time.Duration
+	// SkipMigrate serves sqlite databases at the revision they are at.
+	SkipMigrate bool
+	// InMemory loads sqlite databases into memory once and serves them from there instead of querying the database.
+	InMemory bool
+	// PollInterval is how often an index image reference is checked for a new digest. Zero disables polling.
+	PollInterval time.Duration
+	// CacheDir holds a cache built by BuildCache, loaded instead of the source when it was built from it
+	// and the source is served from memory: a declarative config directory, or a sqlite database with InMemory.
+	CacheDir string
+}
+
+// AddFlags binds the serving flags to o.
+func (o *Options) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVarP(&o.Port, "port", "p", "50051", "port number to serve on")
+	fs.StringVar(&o.HTTPPort, "http-port", "", "port number to serve the HTTP/JSON gateway on, disabled if unset")
+	fs.StringVar(&o.MetricsPort, "metrics-port", "", "port number to serve Prometheus metrics on at /metrics, disabled if unset")
+	fs.StringVarP(&o.TerminationLog, "termination-log", "t", "/dev/termination-log", "path to a container termination log file")
+	fs.StringVar(&o.TLSCert, "tls-cert", "", "path to a PEM-encoded certificate to serve TLS with, reloaded on change")
+	fs.StringVar(&o.TLSKey, "tls-key", "", "path to the PEM-encoded private key for --tls-cert, reloaded on change")
+	fs.StringVar(&o.TLSClientCA, "tls-client-ca", "", "path to a PEM-encoded CA bundle used to require and verify client certificates (mutual TLS)")
+	fs.DurationVar(&o.Timeout, "timeout", 0, "stop serving after this long, serving until interrupted if 0")
+	fs.BoolVar(&o.SkipMigrate, "skip-migrate", false, "do  not attempt to migrate a sqlite database to the latest db revision when starting")
+	fs.BoolVar(&o.InMemory, "in-memory", false, "load a sqlite database into memory when starting and serve it from there, closing the database")
+	fs.DurationVar(&o.PollInterval, "poll-interval", 0, "interval at which to check an index image for a new digest and reload the catalog, disabled if 0")
+	fs.StringVar(&o.CacheDir, "cache-dir", "", "directory of a cache built by 'opm alpha cache build', served instead of the source when it was built from it (requires --in-memory for a sqlite database)")
+}
+
+// Serve serves the catalog at src, which is a sqlite database file, a directory of declarative configs
+// or an index image reference, until ctx is done, the timeout expires or the process is interrupted.
+//
+// The server starts listening before the catalog is loaded; its health service reports NOT_SERVING until then.
+func Serve(ctx context.Context, src string, o Options, logger *logrus.Entry) error {
+	// Immediately set up termination log
+	if err := log.AddDefaultWriterHooks(o.TerminationLog); err != nil {
+		logger.WithError(err).Warn("unable to set termination log path")
+	}
+
+	// Ensure there is a default nsswitch config
+	if err := dns.EnsureNsswitch(); err != nil {
+		logger.WithError(err).Warn("unable to write default nsswitch config")
+	}
+
+	sourceType, err := DetectSource(src)
+	if err != nil {
+		return err
+	}
+	if o.PollInterval > 0 && sourceType != ImageSource {
+		return fmt.Errorf("--poll-interval is only supported when serving an index image")
+	}
+	if o.CacheDir != "" && sourceType == ImageSource {
+		return fmt.Errorf("--cache-dir is only supported when serving a sqlite database or a declarative config directory")
+	}
+	logger = logger.WithFields(logrus.Fields{"source": src, "type": sourceType, "port": o.Port})
+
+	var (
+		catalog source
+		image   *imageSource
+	)
+	switch sourceType {
+	case SqliteSource:
+		catalog = &sqliteSource{path: src, skipMigrate: o.SkipMigrate, inMemory: o.InMemory, logger: logger}
+	case DeclarativeConfigSource:
+		catalog = dirSource{dir: src}
+	case ImageSource:
+		image, err = newImageSource(src, logger)
+		if err != nil {
+			return fmt.Errorf("create registry: %v", err)
+		}
+		catalog = image
+	}
+	switch {
+	case o.CacheDir == "":
+	case sourceType == SqliteSource && !o.InMemory:
+		// a cache is served from memory, which only gives the same answers as the database does with --in-memory
+		logger.WithField("cache-dir", o.CacheDir).Warn("a sqlite database is only served from a cache with --in-memory, loading source")
+	default:
+		catalog = &cachedSource{src: src, cacheDir: o.CacheDir, fallback: catalog, logger: logger}
+	}
+	defer func() {
+		if err := catalog.close(); err != nil {
+			logger.WithError(err).Warn("unable to clean up catalog")
+		}
+	}()
+
+	tlsConfig, err := server.TLSConfig(o.TLSCert, o.TLSKey, o.TLSClientCA)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// The catalog is loaded after the server starts, so that health checks can report
+	// NOT_SERVING while loading instead of refusing connections.
+	store := registry.NewSwappableQuerier(nil)
+
+	lis, err := net.Listen("tcp", ":"+o.Port)
+	if err != nil {
+		return fmt.Errorf("failed to listen: %v", err)
+	}
+
+	// The gateway runs each request through the same interceptors as the gRPC server
+	interceptors := []server.Interceptors{server.TracingInterceptors()}
+	var metricsServer *http.Server
+	var metricsLis net.Listener
+	if len(o.MetricsPort) > 0 {
+		reg := prometheus.NewRegistry()
+		metrics, err := server.NewMetrics(reg)
+		if err != nil {
+			return err
+		}
+		interceptors = append(interceptors, metrics.Interceptors())
+
+		metricsLis, err = net.Listen("tcp", ":"+o.MetricsPort)
+		if err != nil {
+			return fmt.Errorf("failed to listen: %v", err)
+		}
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+		metricsServer = &http.Server{Handler: mux}
+		logger.WithField("metrics-port", o.MetricsPort).Info("serving metrics")
+	}
+
+	serverOpts := server.TLSServerOptions(tlsConfig)
+	for _, i := range interceptors {
+		serverOpts = append(serverOpts, i.ServerOptions()...)
+	}
+	grpcServer := grpc.NewServer(serverOpts...)
+	api.RegisterRegistryServer(grpcServer, server.NewRegistryServer(store))
+	health.RegisterHealthServer(grpcServer, server.NewStoreHealthServer(store))
+	reflection.Register(grpcServer)
+
+	var gateway *server.Gateway
+	if len(o.HTTPPort) > 0 {
+		gateway, err = server.NewGateway(":"+o.HTTPPort, store, tlsConfig, interceptors...)
+		if err != nil {
+			return fmt.Errorf("failed to listen: %v", err)
+		}
+		logger.WithField("http-port", o.HTTPPort).Info("serving http gateway")
+	}
+
+	stop := func() {
+		cancel()
+		if gateway != nil {
+			gateway.GracefulStop()
+		}
+		if metricsServer != nil {
+			metricsServer.Close()
+		}
+		grpcServer.GracefulStop()
+	}
+
+	if o.Timeout > 0 {
+		logger.Infof("serving for %s", o.Timeout)
+		timer := time.AfterFunc(o.Timeout, func() {
+			logger.Info("Timeout expired. Gracefully stopping.")
+			stop()
+		})
+		defer timer.Stop()
+	}
+
+	logger.Info("serving registry")
+	return graceful.Shutdown(logger, func() error {
+		var (
+			g       errgroup.Group
+			loadErr error
+		)
+		g.Go(func() error {
+			return grpcServer.Serve(lis)
+		})
+		if gateway != nil {
+			g.Go(gateway.Serve)
+		}
+		if metricsServer != nil {
+			g.Go(func() error {
+				if err := metricsServer.Serve(metricsLis); err != http.ErrServerClosed {
+					return err
+				}
+				return nil
+			})
+		}
+		g.Go(func() error {
+			q, err := catalog.load(ctx)
+			if err != nil {
+				// Loading is cancelled when shutting down, which is not a failure
+				if ctx.Err() == nil {
+					loadErr = fmt.Errorf("load %s catalog: %v", sourceType, err)
+				}
+				stop()
+				return nil
+			}
+			store.Swap(q)
+			logger.Info("catalog loaded")
+			if image != nil && o.PollInterval > 0 {
+				image.poll(ctx, o.PollInterval, store)
+			}
+			// Stop serving when the caller's context is done
+			<-ctx.Done()
+			stop()
+			return nil
+		})
+		if err := g.Wait(); loadErr == nil {
+			return err
+		}
+		return loadErr
+	}, stop)
+}
@@ -0,0 +1,156 @@
+package serve
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/phayes/freeport"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/pkg/client"
+	"github.com/operator-framework/operator-registry/pkg/lib/dns"
+	"github.com/operator-framework/operator-registry/pkg/sqlite"
+)
+
+const configDir = "../../../internal/action/testdata/foo-index-v0.2.0-declcfg"
+
+func createDB(t *testing.T, dbPath string) {
+	db, err := sqlite.Open(dbPath)
+	require.NoError(t, err)
+	defer db.Close()
+	load, err := sqlite.NewSQLLiteLoader(db)
+	require.NoError(t, err)
+	require.NoError(t, load.Migrate(context.TODO()))
+	require.NoError(t, sqlite.NewSQLLoaderForDirectory(load, "../../../manifests").Populate())
+}
+
+func TestDetectSource(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "serve_test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	dbPath := filepath.Join(tmpDir, "bundles.db")
+	createDB(t, dbPath)
+	emptyPath := filepath.Join(tmpDir, "empty.db")
+	require.NoError(t, ioutil.WriteFile(emptyPath, nil, 0644))
+	textPath := filepath.Join(tmpDir, "index.yaml")
+	require.NoError(t, ioutil.WriteFile(textPath, []byte("schema: olm.package"), 0644))
+
+	for _, tt := range []struct {
+		source   string
+		expected SourceType
+		err      bool
+	}{
+		{source: dbPath, expected: SqliteSource},
+		{source: emptyPath, expected: SqliteSource},
+		{source: configDir, expected: DeclarativeConfigSource},
+		{source: "quay.io/operator-framework/example-index:latest", expected: ImageSource},
+		{source: textPath, err: true},
+		{source: filepath.Join(tmpDir, "missing.db"), err: true},
+		{source: "Not An Image", err: true},
+	} {
+		t.Run(tt.source, func(t *testing.T) {
+			actual, err := DetectSource(tt.source)
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestServe(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "serve_test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	dns.NsswitchFilename = filepath.Join(tmpDir, "nsswitch.conf")
+
+	dbPath := filepath.Join(tmpDir, "bundles.db")
+	createDB(t, dbPath)
+
+	dbCache := filepath.Join(tmpDir, "db-cache")
+	_, err = BuildCache(context.TODO(), dbPath, dbCache, logrus.NewEntry(logrus.New()))
+	require.NoError(t, err)
+	configCache := filepath.Join(tmpDir, "config-cache")
+	_, err = BuildCache(context.TODO(), configDir, configCache, logrus.NewEntry(logrus.New()))
+	require.NoError(t, err)
+
+	for _, tt := range []struct {
+		name     string
+		source   string
+		inMemory bool
+		cacheDir string
+		packages []string
+	}{
+		{name: "Sqlite", source: dbPath, packages: []string{"etcd", "prometheus", "strimzi-kafka-operator"}},
+		{name: "SqliteInMemory", source: dbPath, inMemory: true, packages: []string{"etcd", "prometheus", "strimzi-kafka-operator"}},
+		{name: "SqliteCached", source: dbPath, inMemory: true, cacheDir: dbCache, packages: []string{"etcd", "prometheus", "strimzi-kafka-operator"}},
+		{name: "SqliteCacheIgnored", source: dbPath, cacheDir: dbCache, packages: []string{"etcd", "prometheus", "strimzi-kafka-operator"}},
+		{name: "DeclarativeConfig", source: configDir, packages: []string{"foo"}},
+		{name: "DeclarativeConfigCached", source: configDir, cacheDir: configCache, packages: []string{"foo"}},
+		{name: "StaleCache", source: configDir, cacheDir: dbCache, packages: []string{"foo"}},
+		{name: "MissingCache", source: configDir, cacheDir: filepath.Join(tmpDir, "missing-cache"), packages: []string{"foo"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			port, err := freeport.GetFreePort()
+			require.NoError(t, err)
+			opts := Options{
+				Port:           fmt.Sprint(port),
+				TerminationLog: filepath.Join(tmpDir, "termination-log"),
+				InMemory:       tt.inMemory,
+				CacheDir:       tt.cacheDir,
+			}
+
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			served := make(chan error)
+			go func() {
+				served <- Serve(ctx, tt.source, opts, logrus.NewEntry(logrus.New()))
+			}()
+
+			c, err := client.NewClient(fmt.Sprintf("localhost:%d", port))
+			require.NoError(t, err)
+			defer c.Close()
+			require.Eventually(t, func() bool {
+				healthy, _ := c.HealthCheck(ctx, time.Second)
+				return healthy
+			}, 10*time.Second, 10*time.Millisecond)
+
+			it, err := c.ListPackages(ctx)
+			require.NoError(t, err)
+			var packages []string
+			for p := it.Next(); p != nil; p = it.Next() {
+				packages = append(packages, p.Name)
+			}
+			require.NoError(t, it.Error())
+			require.ElementsMatch(t, tt.packages, packages)
+
+			cancel()
+			select {
+			case err := <-served:
+				require.NoError(t, err)
+			case <-time.After(10 * time.Second):
+				t.Fatal("serve did not stop when its context was done")
+			}
+		})
+	}
+
+	t.Run("LoadError", func(t *testing.T) {
+		badDir := filepath.Join(tmpDir, "bad")
+		require.NoError(t, os.Mkdir(badDir, 0755))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(badDir, "index.json"), []byte(`{"schema": "olm.bundle", "package": "missing"}`), 0644))
+
+		port, err := freeport.GetFreePort()
+		require.NoError(t, err)
+		err = Serve(context.TODO(), badDir, Options{Port: fmt.Sprint(port), TerminationLog: filepath.Join(tmpDir, "termination-log")}, logrus.NewEntry(logrus.New()))
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,205 @@
+package serve
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/docker/distribution/reference"
+	"github.com/sirupsen/logrus"
+
+	"github.com/operator-framework/operator-registry/internal/declcfg"
+	"github.com/operator-framework/operator-registry/internal/model"
+	"github.com/operator-framework/operator-registry/pkg/lib/tmp"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+	"github.com/operator-framework/operator-registry/pkg/sqlite"
+)
+
+type SourceType string
+
+const (
+	SqliteSource            SourceType = "sqlite"
+	DeclarativeConfigSource SourceType = "declcfg"
+	ImageSource             SourceType = "image"
+)
+
+// sqliteHeader starts every sqlite database file.
+var sqliteHeader = []byte("SQLite format 3\x00")
+
+// DetectSource reports what kind of catalog source is: a sqlite database file, a directory of
+// declarative configs, or otherwise a reference to an index image.
+func DetectSource(source string) (SourceType, error) {
+	stat, err := os.Stat(source)
+	if os.IsNotExist(err) {
+		// sqlite databases are conventionally named *.db, which also parses as an image reference
+		if filepath.Ext(source) == ".db" {
+			return "", err
+		}
+		if _, perr := reference.ParseNormalizedNamed(source); perr != nil {
+			return "", fmt.Errorf("%q is neither a file, a directory nor an image reference: %v", source, perr)
+		}
+		return ImageSource, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if stat.IsDir() {
+		return DeclarativeConfigSource, nil
+	}
+
+	f, err := os.Open(source)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	header := make([]byte, len(sqliteHeader))
+	n, err := io.ReadFull(f, header)
+	// an empty file is a valid, empty database
+	if n == 0 || (err == nil && bytes.Equal(header, sqliteHeader)) {
+		return SqliteSource, nil
+	}
+	return "", fmt.Errorf("%s is not a sqlite database", source)
+}
+
+// source loads a catalog to serve.
+type source interface {
+	load(ctx context.Context) (registry.GRPCQuery, error)
+	close() error
+}
+
+type dirSource struct {
+	dir string
+}
+
+func (d dirSource) load(ctx context.Context) (registry.GRPCQuery, error) {
+	m, err := d.model(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return registry.NewQuerier(m), nil
+}
+
+func (d dirSource) model(ctx context.Context) (model.Model, error) {
+	cfg, err := declcfg.LoadFS(ctx, os.DirFS(d.dir))
+	if err != nil {
+		return nil, fmt.Errorf("load declarative config directory: %v", err)
+	}
+
+	m, err := declcfg.ConvertToModel(ctx, *cfg)
+	if err != nil {
+		return nil, fmt.Errorf("could not build index model from declarative config: %v", err)
+	}
+	return m, nil
+}
+
+func (d dirSource) close() error {
+	return nil
+}
+
+// sqliteSource serves a writable copy of a sqlite database, migrated to the latest revision unless skipMigrate is set.
+// With inMemory set, the copy is converted to a model and removed once loaded.
+type sqliteSource struct {
+	path        string
+	skipMigrate bool
+	inMemory    bool
+	logger      *logrus.Entry
+
+	tmpdb string
+	db    *sql.DB
+}
+
+func (s *sqliteSource) load(ctx context.Context) (registry.GRPCQuery, error) {
+	store, err := s.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !s.inMemory {
+		return store, nil
+	}
+	m, err := s.model(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	return registry.NewQuerier(m), nil
+}
+
+// open opens and migrates a copy of the database.
+func (s *sqliteSource) open(ctx context.Context) (*sqlite.SQLQuerier, error) {
+	// make a writable copy of the db for migrations
+	tmpdb, err := tmp.CopyTmpDB(s.path)
+	if err != nil {
+		return nil, err
+	}
+	s.tmpdb = tmpdb
+
+	db, err := sqlite.Open(tmpdb)
+	if err != nil {
+		return nil, err
+	}
+	s.db = db
+
+	// migrate to the latest version
+	if err := s.migrate(ctx); err != nil {
+		s.logger.WithError(err).Warnf("couldn't migrate db")
+	}
+
+	store := sqlite.NewSQLLiteQuerierFromDb(db)
+
+	// sanity check that the db is available
+	tables, err := store.ListTables(ctx)
+	if err != nil {
+		s.logger.WithError(err).Warnf("couldn't list tables in db")
+	}
+	if len(tables) == 0 {
+		s.logger.Warn("no tables found in db")
+	}
+	return store, nil
+}
+
+// model converts the opened database to a model, then closes it.
+func (s *sqliteSource) model(ctx context.Context, store *sqlite.SQLQuerier) (model.Model, error) {
+	m, err := sqlite.ToModel(ctx, store)
+	if err != nil {
+		return nil, fmt.Errorf("could not build index model from sqlite database: %v", err)
+	}
+	// the database isn't needed once the model is built
+	if err := s.close(); err != nil {
+		s.logger.WithError(err).Warn("unable to clean up database")
+	}
+	return m, nil
+}
+
+func (s *sqliteSource) migrate(ctx context.Context) error {
+	if s.skipMigrate {
+		return nil
+	}
+
+	migrator, err := sqlite.NewSQLLiteMigrator(s.db)
+	if err != nil {
+		return err
+	}
+	if migrator == nil {
+		return fmt.Errorf("failed to load migrator")
+	}
+
+	return migrator.Migrate(ctx)
+}
+
+func (s *sqliteSource) close() error {
+	var err error
+	if s.db != nil {
+		err = s.db.Close()
+		s.db = nil
+	}
+	if s.tmpdb != "" {
+		if rerr := os.Remove(s.tmpdb); rerr != nil && err == nil {
+			err = rerr
+		}
+		s.tmpdb = ""
+	}
+	return err
+}
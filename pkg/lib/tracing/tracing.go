@@ -0,0 +1,82 @@
+// Package tracing records OpenTelemetry spans around the slow parts of opm: pulling and unpacking
+// images, loading and converting declarative configs, sqlite loader transactions and gRPC calls.
+//
+// Spans go to the global tracer provider, which does nothing until Setup is called, so
+// instrumented code costs next to nothing when tracing is off.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName names the tracer that records operator-registry spans.
+const InstrumentationName = "github.com/operator-framework/operator-registry"
+
+// Stdout is the output that writes spans to standard output.
+const Stdout = "stdout"
+
+// Start starts a span, as a child of any span in ctx.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(InstrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End ends span, first marking it failed if err is not nil.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Setup makes the global tracer provider write spans, as JSON, to output: Stdout or a file, which is
+// truncated. Nothing is sent over the network. Spans are written as they end, so they aren't lost if
+// the process exits without calling the returned shutdown function, which closes the output.
+//
+// An empty output leaves tracing off.
+func Setup(output, service string) (shutdown func(context.Context) error, err error) {
+	if output == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	var w io.Writer = os.Stdout
+	closeOutput := func() error { return nil }
+	if output != Stdout {
+		f, err := os.Create(output)
+		if err != nil {
+			return nil, fmt.Errorf("create trace output: %v", err)
+		}
+		w, closeOutput = f, f.Close
+	}
+
+	exporter, err := stdouttrace.New(stdouttrace.WithWriter(w))
+	if err != nil {
+		closeOutput()
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(service))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closeErr := closeOutput(); err == nil {
+			err = closeErr
+		}
+		return err
+	}, nil
+}
@@ -0,0 +1,57 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSetup(t *testing.T) {
+	defer otel.SetTracerProvider(trace.NewNoopTracerProvider())
+
+	tmpDir, err := ioutil.TempDir("", "tracing-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	output := filepath.Join(tmpDir, "trace.json")
+
+	shutdown, err := Setup(output, "test")
+	require.NoError(t, err)
+
+	ctx, parent := Start(context.Background(), "parent")
+	_, child := Start(ctx, "child", attribute.String("image", "quay.io/example/bundle:v1"))
+	End(child, errors.New("pull failed"))
+	End(parent, nil)
+
+	// spans are written as they end, before shutdown
+	data, err := ioutil.ReadFile(output)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"Name":"child"`)
+	require.Contains(t, string(data), `"Name":"parent"`)
+	require.Contains(t, string(data), "quay.io/example/bundle:v1")
+	require.Contains(t, string(data), `"Description":"pull failed"`)
+
+	require.NoError(t, shutdown(context.Background()))
+}
+
+func TestSetupDisabled(t *testing.T) {
+	shutdown, err := Setup("", "test")
+	require.NoError(t, err)
+	require.NoError(t, shutdown(context.Background()))
+
+	_, span := Start(context.Background(), "untraced")
+	require.False(t, span.IsRecording())
+	End(span, nil)
+}
+
+func TestSetupInvalidOutput(t *testing.T) {
+	_, err := Setup(filepath.Join("does", "not", "exist", "trace.json"), "test")
+	require.Error(t, err)
+}
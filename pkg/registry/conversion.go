@@ -39,6 +39,30 @@ func ChannelEntryToAPIChannelEntry(entry *ChannelEntry) *api.ChannelEntry {
 	}
 }
 
+func APIPackageToPackageManifest(pkg *api.Package) *PackageManifest {
+	channels := []PackageChannel{}
+	for _, c := range pkg.GetChannels() {
+		channels = append(channels, PackageChannel{
+			Name:           c.GetName(),
+			CurrentCSVName: c.GetCsvName(),
+		})
+	}
+	return &PackageManifest{
+		PackageName:        pkg.GetName(),
+		DefaultChannelName: pkg.GetDefaultChannelName(),
+		Channels:           channels,
+	}
+}
+
+func APIChannelEntryToChannelEntry(entry *api.ChannelEntry) *ChannelEntry {
+	return &ChannelEntry{
+		PackageName: entry.GetPackageName(),
+		ChannelName: entry.GetChannelName(),
+		BundleName:  entry.GetBundleName(),
+		Replaces:    entry.GetReplaces(),
+	}
+}
+
 // Bundle strings are appended json objects, we need to split them apart
 // e.g. {"my":"obj"}{"csv":"data"}{"crd":"too"}
 func BundleStringToObjectStrings(bundleString string) ([]string, error) {
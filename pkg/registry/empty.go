@@ -112,6 +112,13 @@ func (EmptyQuery) ListRegistryBundles(ctx context.Context) ([]*Bundle, error) {
 	return nil, errors.New("empty querier: cannot list registry bundles")
 }
 
+func (EmptyQuery) GetBundleProvenance(ctx context.Context, bundleName string) ([]Provenance, error) {
+	return nil, errors.New("empty querier: cannot get bundle provenance")
+}
+func (EmptyQuery) ListBundlesWithProperty(ctx context.Context, predicate PropertyPredicate) ([]*BundlePropertyMatch, error) {
+	return nil, errors.New("empty querier: cannot list bundles with property")
+}
+
 var _ Query = &EmptyQuery{}
 
 func NewEmptyQuerier() *EmptyQuery {
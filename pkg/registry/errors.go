@@ -0,0 +1,94 @@
+package registry
+
+import (
+	"fmt"
+)
+
+// Resource names what a catalog query was about. Fields that don't apply to the query are empty.
+type Resource struct {
+	Package string
+	Channel string
+	Bundle  string
+	// API is the group/version/kind of an API, for queries about the bundles that provide it.
+	API string
+}
+
+// APIResource returns the Resource for a query about the providers of an API.
+func APIResource(group, version, kind string) Resource {
+	return Resource{API: fmt.Sprintf("%s/%s/%s", group, version, kind)}
+}
+
+// ErrorDomain is the domain of the ErrorInfo detail the registry server attaches to the status of a failed request.
+const ErrorDomain = "operator-registry"
+
+// Reasons of the ErrorInfo detail, one for each of the error types below.
+const (
+	ReasonNotFound        = "NOT_FOUND"
+	ReasonInvalidArgument = "INVALID_ARGUMENT"
+	ReasonInternal        = "INTERNAL"
+)
+
+// Metadata keys of the ErrorInfo detail naming what a failed request was about.
+const (
+	MetadataPackage = "package"
+	MetadataChannel = "channel"
+	MetadataBundle  = "bundle"
+	MetadataAPI     = "api"
+)
+
+// NotFoundError is returned by a GRPCQuery when what was asked for isn't in the catalog.
+type NotFoundError struct {
+	Resource
+	Err error
+}
+
+// NewNotFoundError returns a NotFoundError about r with the given message.
+func NewNotFoundError(r Resource, format string, a ...interface{}) error {
+	return NotFoundError{Resource: r, Err: fmt.Errorf(format, a...)}
+}
+
+func (e NotFoundError) Error() string {
+	return e.Err.Error()
+}
+
+func (e NotFoundError) Unwrap() error {
+	return e.Err
+}
+
+// InvalidArgumentError is returned by a GRPCQuery when a query can't be answered as asked, e.g. without a required name.
+type InvalidArgumentError struct {
+	Resource
+	Err error
+}
+
+// NewInvalidArgumentError returns an InvalidArgumentError about r with the given message.
+func NewInvalidArgumentError(r Resource, format string, a ...interface{}) error {
+	return InvalidArgumentError{Resource: r, Err: fmt.Errorf(format, a...)}
+}
+
+func (e InvalidArgumentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e InvalidArgumentError) Unwrap() error {
+	return e.Err
+}
+
+// InternalError is returned by a GRPCQuery when the catalog can't be read or is inconsistent.
+type InternalError struct {
+	Resource
+	Err error
+}
+
+// NewInternalError returns an InternalError about r with the given message.
+func NewInternalError(r Resource, format string, a ...interface{}) error {
+	return InternalError{Resource: r, Err: fmt.Errorf(format, a...)}
+}
+
+func (e InternalError) Error() string {
+	return e.Err.Error()
+}
+
+func (e InternalError) Unwrap() error {
+	return e.Err
+}
@@ -2,6 +2,7 @@ package registry
 
 import (
 	"context"
+	"errors"
 
 	"github.com/operator-framework/operator-registry/pkg/api"
 )
@@ -17,6 +18,22 @@ type Load interface {
 	ClearNonHeadBundles() error
 }
 
+// Editor is implemented by a Load that can edit the bundles and channels it has already loaded.
+type Editor interface {
+	// RemoveBundle removes the bundle with the given image, linking the rest of its channels around it
+	RemoveBundle(path string) error
+	// UndeprecateBundle removes the deprecation of the bundle with the given image, restoring the channels it headed
+	UndeprecateBundle(path string) error
+	// AddBundleToChannel makes a bundle of a package the head of one of its channels, creating the channel if needed
+	AddBundleToChannel(packageName, channelName, bundleName string) error
+	// RemoveBundleFromChannel removes a bundle from one channel of a package, linking the channel around it
+	RemoveBundleFromChannel(packageName, channelName, bundleName string) error
+	// RenameChannel renames a channel of a package
+	RenameChannel(packageName, channelName, newName string) error
+	// UpdateDefaultChannel makes a channel of a package its default channel
+	UpdateDefaultChannel(packageName, channelName string) error
+}
+
 type GRPCQuery interface {
 	// List all available package names in the index
 	ListPackages(ctx context.Context) ([]string, error)
@@ -49,8 +66,24 @@ type GRPCQuery interface {
 	GetBundleThatProvides(ctx context.Context, group, version, kind string) (*api.Bundle, error)
 }
 
+// CatalogInfoQuery is implemented by GRPCQuery stores that can describe the catalog they serve as a whole.
+type CatalogInfoQuery interface {
+	// Get the digest and size of the catalog, and when it was loaded
+	GetCatalogInfo(ctx context.Context) (*CatalogInfo, error)
+}
+
+// ErrCatalogInfoUnsupported is returned by stores wrapping another that can't describe its catalog.
+var ErrCatalogInfoUnsupported = errors.New("catalog info is not supported by this store")
+
+// PropertyQuery is implemented by stores that can find bundles by their properties.
+type PropertyQuery interface {
+	// List the bundles with properties matching a predicate, sorted by package and bundle name
+	ListBundlesWithProperty(ctx context.Context, predicate PropertyPredicate) ([]*BundlePropertyMatch, error)
+}
+
 type Query interface {
 	GRPCQuery
+	PropertyQuery
 
 	ListTables(ctx context.Context) ([]string, error)
 	GetDefaultPackage(ctx context.Context, name string) (string, error)
@@ -79,6 +112,8 @@ type Query interface {
 	GetBundlePathIfExists(ctx context.Context, csvName string) (string, error)
 	// ListRegistryBundles returns a set of registry bundles.
 	ListRegistryBundles(ctx context.Context) ([]*Bundle, error)
+	// Get the provenance records of a bundle, oldest first
+	GetBundleProvenance(ctx context.Context, bundleName string) ([]Provenance, error)
 }
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . GraphLoader
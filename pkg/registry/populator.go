@@ -119,7 +119,7 @@ func (i *DirectoryPopulator) globalSanityCheck(imagesToAdd []*ImageInput) error
 				// ensure overwrite is not in the middle of a channel (i.e. nothing replaces it)
 				_, err = i.querier.GetBundleThatReplaces(context.TODO(), image.Bundle.Name, image.Bundle.Package, channel)
 				if err != nil {
-					if err.Error() == fmt.Errorf("no entry found for %s %s", image.Bundle.Package, channel).Error() {
+					if errors.As(err, &NotFoundError{}) {
 						// overwrite is not replaced by any other bundle
 						validOverwrite = true
 						continue
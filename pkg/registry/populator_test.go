@@ -653,10 +653,18 @@ func CheckInvariants(t *testing.T, db *sql.DB) {
 	CheckBundlesHaveContentsIfNoPath(t, db)
 }
 
+// contentLength returns a column expression for the length of the content of a bundle field, which is stored inline
+// by older databases and as blobs by newer ones.
+func contentLength(field string) string {
+	return fmt.Sprintf(`coalesce(length(operatorbundle.%[1]s), (select sum(length(blob.content)) from operatorbundle_blob
+		join blob on blob.digest = operatorbundle_blob.blob_digest
+		where operatorbundle_blob.operatorbundle_name = operatorbundle.name and operatorbundle_blob.field = '%[1]s'))`, field)
+}
+
 func CheckChannelHeadsHaveDescriptions(t *testing.T, db *sql.DB) {
 	// check channel heads have csv / bundle
 	rows, err := db.Query(`
-		select operatorbundle.name,length(operatorbundle.csv),length(operatorbundle.bundle) from operatorbundle
+		select operatorbundle.name,` + contentLength("csv") + `,` + contentLength("bundle") + ` from operatorbundle
 		join channel on channel.head_operatorbundle_name = operatorbundle.name`)
 	require.NoError(t, err)
 
@@ -675,7 +683,7 @@ func CheckChannelHeadsHaveDescriptions(t *testing.T, db *sql.DB) {
 func CheckBundlesHaveContentsIfNoPath(t *testing.T, db *sql.DB) {
 	// check that any bundle entry has csv/bundle content unpacked if there is no bundlepath
 	rows, err := db.Query(`
-		select name,length(csv),length(bundle) from operatorbundle
+		select operatorbundle.name,` + contentLength("csv") + `,` + contentLength("bundle") + ` from operatorbundle
 		where bundlepath="" or bundlepath=null`)
 	require.NoError(t, err)
 
@@ -1121,6 +1129,172 @@ func TestAddAfterDeprecate(t *testing.T) {
 	}
 }
 
+func TestUndeprecateBundle(t *testing.T) {
+	type args struct {
+		deprecate    []string
+		restore      []string
+		undeprecate  []string
+		restoreAfter []string
+	}
+	type expected struct {
+		remaining  []string
+		deprecated []string
+	}
+	tests := []struct {
+		description string
+		args        args
+		expected    expected
+	}{
+		{
+			description: "Undeprecate",
+			args: args{
+				deprecate: []string{
+					"quay.io/test/prometheus.0.15.0",
+				},
+				undeprecate: []string{
+					"quay.io/test/prometheus.0.15.0",
+				},
+			},
+			expected: expected{
+				remaining: []string{
+					"quay.io/test/prometheus.0.22.2/preview",
+					"quay.io/test/prometheus.0.15.0/preview",
+					"quay.io/test/prometheus.0.15.0/stable",
+				},
+			},
+		},
+		{
+			description: "RestoreTruncated",
+			args: args{
+				deprecate: []string{
+					"quay.io/test/prometheus.0.15.0",
+				},
+				restore: []string{
+					"prometheus.0.14.0",
+				},
+				undeprecate: []string{
+					"quay.io/test/prometheus.0.15.0",
+				},
+			},
+			expected: expected{
+				remaining: []string{
+					"quay.io/test/prometheus.0.22.2/preview",
+					"quay.io/test/prometheus.0.15.0/preview",
+					"quay.io/test/prometheus.0.14.0/preview",
+					"quay.io/test/prometheus.0.15.0/stable",
+					"quay.io/test/prometheus.0.14.0/stable",
+				},
+			},
+		},
+		{
+			description: "RestoreAfterUndeprecate",
+			args: args{
+				deprecate: []string{
+					"quay.io/test/prometheus.0.15.0",
+				},
+				undeprecate: []string{
+					"quay.io/test/prometheus.0.15.0",
+				},
+				restoreAfter: []string{
+					"prometheus.0.14.0",
+				},
+			},
+			expected: expected{
+				remaining: []string{
+					"quay.io/test/prometheus.0.22.2/preview",
+					"quay.io/test/prometheus.0.15.0/preview",
+					"quay.io/test/prometheus.0.14.0/preview",
+					"quay.io/test/prometheus.0.15.0/stable",
+					"quay.io/test/prometheus.0.14.0/stable",
+				},
+			},
+		},
+		{
+			description: "NotDeprecated",
+			args: args{
+				deprecate: []string{
+					"quay.io/test/prometheus.0.15.0",
+				},
+				undeprecate: []string{
+					"quay.io/test/prometheus.0.22.2",
+				},
+			},
+			expected: expected{
+				remaining: []string{
+					"quay.io/test/prometheus.0.22.2/preview",
+					"quay.io/test/prometheus.0.15.0/preview",
+				},
+				deprecated: []string{
+					"quay.io/test/prometheus.0.15.0/preview",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			db, cleanup := CreateTestDb(t)
+			defer cleanup()
+
+			load, err := sqlite.NewSQLLiteLoader(db)
+			require.NoError(t, err)
+			require.NoError(t, load.Migrate(context.TODO()))
+			query := sqlite.NewSQLLiteQuerierFromDb(db)
+			graphLoader, err := sqlite.NewSQLGraphLoaderFromDB(db)
+			require.NoError(t, err)
+
+			populate := func(add []string) error {
+				addRefs := map[image.Reference]string{}
+				for _, a := range add {
+					addRefs[image.SimpleReference("quay.io/test/"+a)] = "../../bundles/" + a
+				}
+				return registry.NewDirectoryPopulator(
+					load,
+					graphLoader,
+					query,
+					addRefs,
+					nil,
+					false,
+				).Populate(registry.ReplacesMode)
+			}
+			require.NoError(t, populate([]string{"prometheus.0.14.0", "prometheus.0.15.0", "prometheus.0.22.2"}))
+			require.NoError(t, sqlite.NewSQLDeprecatorForBundles(load, tt.args.deprecate).Deprecate())
+
+			// truncated bundles are added back before undeprecating the bundle that replaces them
+			if len(tt.args.restore) > 0 {
+				require.NoError(t, populate(tt.args.restore))
+			}
+			require.NoError(t, sqlite.NewSQLUndeprecatorForBundles(load, tt.args.undeprecate).Undeprecate())
+
+			// an undeprecated bundle still replaces the bundle truncated below it, whether or not that was restored
+			if len(tt.expected.deprecated) == 0 {
+				replacement, err := query.GetBundleThatReplaces(context.Background(), "prometheusoperator.0.14.0", "prometheus", "preview")
+				require.NoError(t, err)
+				require.Equal(t, "prometheusoperator.0.15.0", replacement.CsvName)
+			}
+
+			// so restoring it later puts it back into its channels
+			if len(tt.args.restoreAfter) > 0 {
+				require.NoError(t, populate(tt.args.restoreAfter))
+			}
+
+			bundles, err := query.ListBundles(context.Background())
+			require.NoError(t, err)
+			var remaining, deprecated []string
+			for _, bundle := range bundles {
+				remaining = append(remaining, strings.Join([]string{bundle.BundlePath, bundle.ChannelName}, "/"))
+				for _, prop := range bundle.Properties {
+					if prop.Type == registry.DeprecatedType {
+						deprecated = append(deprecated, strings.Join([]string{bundle.BundlePath, bundle.ChannelName}, "/"))
+					}
+				}
+			}
+			require.ElementsMatch(t, tt.expected.remaining, remaining)
+			require.ElementsMatch(t, tt.expected.deprecated, deprecated)
+		})
+	}
+}
+
 func TestOverwrite(t *testing.T) {
 	type args struct {
 		firstAdd   map[image.Reference]string
@@ -0,0 +1,185 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+)
+
+// PropertyPredicate selects the properties of a type whose value, or the part of it at a path, equals a value.
+type PropertyPredicate struct {
+	// Type is the type of the properties to select, like olm.maxOpenShiftVersion.
+	Type string
+
+	// Path selects part of each property's value in JSONPath notation, limited to member (.name or ['name']) and
+	// index ([0]) selectors, like $.packageName or $.gvks[0].kind. The whole value is selected if Path is empty.
+	Path string
+
+	// Value is compared to the selected value: the content of a string, or the JSON encoding of anything else.
+	// Any selected value matches if Value is nil.
+	Value *string
+}
+
+// BundlePropertyMatch is a bundle with properties matching a PropertyPredicate.
+type BundlePropertyMatch struct {
+	PackageName string
+	CsvName     string
+	Version     string
+	BundlePath  string
+	// Properties are the properties of the bundle that match.
+	Properties []*api.Property
+}
+
+// addProperties adds properties to a match, unless it already has them.
+func (m *BundlePropertyMatch) addProperties(properties ...*api.Property) {
+	for _, p := range properties {
+		found := false
+		for _, existing := range m.Properties {
+			if existing.Type == p.Type && existing.Value == p.Value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			m.Properties = append(m.Properties, p)
+		}
+	}
+}
+
+// pathSegment selects a member of an object, or an element of an array if it's an index.
+type pathSegment struct {
+	member string
+	index  int
+}
+
+func (s pathSegment) isIndex() bool {
+	return s.index >= 0
+}
+
+// Validate returns an error if the predicate has no type or its path is invalid.
+func (p PropertyPredicate) Validate() error {
+	if p.Type == "" {
+		return fmt.Errorf("property type is required")
+	}
+	_, err := parsePropertyPath(p.Path)
+	return err
+}
+
+// Match reports whether a property value of the predicate's type matches.
+func (p PropertyPredicate) Match(value string) (bool, error) {
+	segments, err := parsePropertyPath(p.Path)
+	if err != nil {
+		return false, err
+	}
+
+	dec := json.NewDecoder(strings.NewReader(value))
+	dec.UseNumber()
+	var selected interface{}
+	if err := dec.Decode(&selected); err != nil {
+		return false, fmt.Errorf("invalid %s property value %q: %v", p.Type, value, err)
+	}
+	for _, s := range segments {
+		var ok bool
+		if s.isIndex() {
+			var elems []interface{}
+			if elems, ok = selected.([]interface{}); ok && s.index < len(elems) {
+				selected = elems[s.index]
+			} else {
+				ok = false
+			}
+		} else {
+			var members map[string]interface{}
+			if members, ok = selected.(map[string]interface{}); ok {
+				selected, ok = members[s.member]
+			}
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	if p.Value == nil {
+		return true, nil
+	}
+
+	if str, ok := selected.(string); ok {
+		return str == *p.Value, nil
+	}
+	encoded, err := json.Marshal(selected)
+	if err != nil {
+		return false, err
+	}
+	// objects are compared regardless of whitespace
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, []byte(*p.Value)); err != nil {
+		return string(encoded) == *p.Value, nil
+	}
+	return string(encoded) == compacted.String(), nil
+}
+
+// parsePropertyPath parses the selectors of a JSONPath expression, with an optional leading $.
+func parsePropertyPath(path string) ([]pathSegment, error) {
+	invalid := func(reason string) error {
+		return fmt.Errorf("invalid property path %q: %s", path, reason)
+	}
+
+	rest := strings.TrimPrefix(path, "$")
+	var segments []pathSegment
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, invalid("empty member name")
+			}
+			segments = append(segments, pathSegment{member: rest[:end], index: -1})
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, invalid("unterminated [")
+			}
+			selector := rest[1:end]
+			rest = rest[end+1:]
+			if len(selector) >= 2 && (selector[0] == '\'' || selector[0] == '"') && selector[len(selector)-1] == selector[0] {
+				segments = append(segments, pathSegment{member: selector[1 : len(selector)-1], index: -1})
+				continue
+			}
+			index, err := strconv.Atoi(selector)
+			if err != nil || index < 0 {
+				return nil, invalid(fmt.Sprintf("%q is neither a quoted member name nor an index", selector))
+			}
+			segments = append(segments, pathSegment{index: index})
+		default:
+			return nil, invalid("expected . or [")
+		}
+	}
+	return segments, nil
+}
+
+// SortBundlePropertyMatches sorts matches by package, then bundle name, and the properties of each match by type,
+// then value, the order stores list them in.
+func SortBundlePropertyMatches(matches []*BundlePropertyMatch) {
+	for _, m := range matches {
+		sort.Slice(m.Properties, func(i, j int) bool {
+			if m.Properties[i].Type != m.Properties[j].Type {
+				return m.Properties[i].Type < m.Properties[j].Type
+			}
+			return m.Properties[i].Value < m.Properties[j].Value
+		})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].PackageName != matches[j].PackageName {
+			return matches[i].PackageName < matches[j].PackageName
+		}
+		return matches[i].CsvName < matches[j].CsvName
+	})
+}
@@ -0,0 +1,134 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPropertyPredicateMatch(t *testing.T) {
+	value := func(v string) *string { return &v }
+
+	type expect struct {
+		match bool
+		err   bool
+	}
+	for _, tt := range []struct {
+		description string
+		predicate   PropertyPredicate
+		value       string
+		expect      expect
+	}{
+		{
+			description: "AnyValue",
+			predicate:   PropertyPredicate{Type: "olm.maxOpenShiftVersion"},
+			value:       `"4.8"`,
+			expect:      expect{match: true},
+		},
+		{
+			description: "WholeString",
+			predicate:   PropertyPredicate{Type: "olm.maxOpenShiftVersion", Value: value("4.8")},
+			value:       `"4.8"`,
+			expect:      expect{match: true},
+		},
+		{
+			description: "WholeNumber",
+			predicate:   PropertyPredicate{Type: "olm.maxOpenShiftVersion", Value: value("4.8")},
+			value:       `4.8`,
+			expect:      expect{match: true},
+		},
+		{
+			description: "DifferentValue",
+			predicate:   PropertyPredicate{Type: "olm.maxOpenShiftVersion", Value: value("4.9")},
+			value:       `"4.8"`,
+			expect:      expect{match: false},
+		},
+		{
+			description: "Member",
+			predicate:   PropertyPredicate{Type: "olm.package", Path: "$.packageName", Value: value("etcd")},
+			value:       `{"packageName":"etcd","version":"0.9.4"}`,
+			expect:      expect{match: true},
+		},
+		{
+			description: "MemberWithoutRoot",
+			predicate:   PropertyPredicate{Type: "olm.package", Path: ".version", Value: value("0.9.4")},
+			value:       `{"packageName":"etcd","version":"0.9.4"}`,
+			expect:      expect{match: true},
+		},
+		{
+			description: "QuotedMember",
+			predicate:   PropertyPredicate{Type: "custom", Path: "$['a.b']", Value: value("c")},
+			value:       `{"a.b":"c"}`,
+			expect:      expect{match: true},
+		},
+		{
+			description: "MissingMember",
+			predicate:   PropertyPredicate{Type: "olm.package", Path: "$.versionRange"},
+			value:       `{"packageName":"etcd","version":"0.9.4"}`,
+			expect:      expect{match: false},
+		},
+		{
+			description: "Index",
+			predicate:   PropertyPredicate{Type: "custom", Path: "$.items[1].name", Value: value("b")},
+			value:       `{"items":[{"name":"a"},{"name":"b"}]}`,
+			expect:      expect{match: true},
+		},
+		{
+			description: "IndexOutOfRange",
+			predicate:   PropertyPredicate{Type: "custom", Path: "$.items[2]"},
+			value:       `{"items":[{"name":"a"},{"name":"b"}]}`,
+			expect:      expect{match: false},
+		},
+		{
+			description: "IndexOfObject",
+			predicate:   PropertyPredicate{Type: "custom", Path: "$[0]"},
+			value:       `{"items":[]}`,
+			expect:      expect{match: false},
+		},
+		{
+			description: "Object",
+			predicate:   PropertyPredicate{Type: "olm.gvk", Value: value(`{"group": "etcd.database.coreos.com", "kind": "EtcdBackup", "version": "v1beta2"}`)},
+			value:       `{"version":"v1beta2","kind":"EtcdBackup","group":"etcd.database.coreos.com"}`,
+			expect:      expect{match: true},
+		},
+		{
+			description: "Bool",
+			predicate:   PropertyPredicate{Type: "custom", Path: "$.enabled", Value: value("true")},
+			value:       `{"enabled":true}`,
+			expect:      expect{match: true},
+		},
+		{
+			description: "InvalidValue",
+			predicate:   PropertyPredicate{Type: "custom"},
+			value:       `{`,
+			expect:      expect{err: true},
+		},
+		{
+			description: "InvalidPath",
+			predicate:   PropertyPredicate{Type: "custom", Path: "$.items[first]"},
+			value:       `{}`,
+			expect:      expect{err: true},
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			match, err := tt.predicate.Match(tt.value)
+			if tt.expect.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expect.match, match)
+		})
+	}
+}
+
+func TestPropertyPredicateValidate(t *testing.T) {
+	require.NoError(t, PropertyPredicate{Type: "olm.package"}.Validate())
+	require.NoError(t, PropertyPredicate{Type: "olm.package", Path: "$"}.Validate())
+	require.NoError(t, PropertyPredicate{Type: "olm.package", Path: `$.a["b"][0]`}.Validate())
+	require.Error(t, PropertyPredicate{Path: "$.packageName"}.Validate(), "type is required")
+	require.Error(t, PropertyPredicate{Type: "olm.package", Path: "packageName"}.Validate())
+	require.Error(t, PropertyPredicate{Type: "olm.package", Path: "$..packageName"}.Validate())
+	require.Error(t, PropertyPredicate{Type: "olm.package", Path: "$.a[0"}.Validate())
+	require.Error(t, PropertyPredicate{Type: "olm.package", Path: "$.a[-1]"}.Validate())
+}
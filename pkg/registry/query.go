@@ -4,21 +4,136 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"sync"
+	"time"
 
+	"google.golang.org/protobuf/proto"
+
+	"github.com/operator-framework/operator-registry/internal/declcfg"
 	"github.com/operator-framework/operator-registry/internal/model"
+	"github.com/operator-framework/operator-registry/internal/property"
 	"github.com/operator-framework/operator-registry/pkg/api"
 )
 
 type Querier struct {
-	pkgs model.Model
+	pkgs       model.Model
+	apiBundles map[bundleKey]*api.Bundle
+	loadTime   time.Time
+	digest     *modelDigest
 }
 
 var _ GRPCQuery = &Querier{}
+var _ CatalogInfoQuery = &Querier{}
+var _ PropertyQuery = &Querier{}
+
+// modelDigest is the digest of a model, computed when first asked for.
+type modelDigest struct {
+	once   sync.Once
+	digest string
+	err    error
+}
+
+// bundleKey identifies a bundle in a channel of a package.
+type bundleKey struct {
+	pkg, channel, name string
+}
+
+type QuerierOptions struct {
+	// APIBundles are conversions of the model's bundles, served instead of converting the bundles on each request.
+	APIBundles []*api.Bundle
+	// Digest is the digest of the model, reported instead of computing it.
+	Digest string
+}
+
+type QuerierOption func(*QuerierOptions)
+
+func defaultQuerierOptions() *QuerierOptions {
+	return &QuerierOptions{}
+}
+
+func WithAPIBundles(bundles []*api.Bundle) QuerierOption {
+	return func(o *QuerierOptions) {
+		o.APIBundles = bundles
+	}
+}
+
+func WithDigest(digest string) QuerierOption {
+	return func(o *QuerierOptions) {
+		o.Digest = digest
+	}
+}
+
+func NewQuerier(packages model.Model, opts ...QuerierOption) *Querier {
+	options := defaultQuerierOptions()
+	for _, o := range opts {
+		o(options)
+	}
 
-func NewQuerier(packages model.Model) *Querier {
-	return &Querier{
-		pkgs: packages,
+	q := &Querier{
+		pkgs:     packages,
+		loadTime: time.Now(),
+		digest:   &modelDigest{},
+	}
+	if len(options.APIBundles) > 0 {
+		q.apiBundles = make(map[bundleKey]*api.Bundle, len(options.APIBundles))
+		for _, b := range options.APIBundles {
+			q.apiBundles[bundleKey{pkg: b.PackageName, channel: b.ChannelName, name: b.CsvName}] = b
+		}
+	}
+	if options.Digest != "" {
+		q.digest.once.Do(func() { q.digest.digest = options.Digest })
+	}
+	return q
+}
+
+// apiBundle returns the conversion of b to an api.Bundle, which the caller may modify.
+func (q Querier) apiBundle(b model.Bundle) (*api.Bundle, error) {
+	apiBundle, shared, err := q.sharedAPIBundle(b)
+	if err != nil || !shared {
+		return apiBundle, err
+	}
+	return proto.Clone(apiBundle).(*api.Bundle), nil
+}
+
+// sharedAPIBundle returns the conversion of b to an api.Bundle, which must not be modified if it is shared.
+func (q Querier) sharedAPIBundle(b model.Bundle) (apiBundle *api.Bundle, shared bool, err error) {
+	if apiBundle, ok := q.apiBundles[bundleKey{pkg: b.Package.Name, channel: b.Channel.Name, name: b.Name}]; ok {
+		return apiBundle, true, nil
+	}
+	apiBundle, err = api.ConvertModelBundleToAPIBundle(b)
+	return apiBundle, false, err
+}
+
+// GetCatalogInfo returns the digest of the model and its number of packages and bundles. The load
+// time is when the querier was created.
+func (q Querier) GetCatalogInfo(_ context.Context) (*CatalogInfo, error) {
+	md := q.digest
+	if md == nil {
+		md = &modelDigest{}
+	}
+	md.once.Do(func() {
+		d, err := declcfg.DigestModel(q.pkgs)
+		md.digest, md.err = d.String(), err
+	})
+	if md.err != nil {
+		return nil, NewInternalError(Resource{}, "digest catalog: %v", md.err)
+	}
+
+	info := &CatalogInfo{
+		Digest:   md.digest,
+		Packages: len(q.pkgs),
+		LoadTime: q.loadTime,
+	}
+	for _, pkg := range q.pkgs {
+		bundles := map[string]struct{}{}
+		for _, ch := range pkg.Channels {
+			for name := range ch.Bundles {
+				bundles[name] = struct{}{}
+			}
+		}
+		info.Bundles += len(bundles)
 	}
+	return info, nil
 }
 
 func (q Querier) ListPackages(_ context.Context) ([]string, error) {
@@ -35,9 +150,9 @@ func (q Querier) ListBundles(_ context.Context) ([]*api.Bundle, error) {
 	for _, pkg := range q.pkgs {
 		for _, ch := range pkg.Channels {
 			for _, b := range ch.Bundles {
-				apiBundle, err := api.ConvertModelBundleToAPIBundle(*b)
+				apiBundle, err := q.apiBundle(*b)
 				if err != nil {
-					return nil, fmt.Errorf("convert bundle %q: %v", b.Name, err)
+					return nil, NewInternalError(Resource{Package: pkg.Name, Channel: ch.Name, Bundle: b.Name}, "convert bundle %q: %v", b.Name, err)
 				}
 				bundles = append(bundles, apiBundle)
 			}
@@ -49,14 +164,14 @@ func (q Querier) ListBundles(_ context.Context) ([]*api.Bundle, error) {
 func (q Querier) GetPackage(_ context.Context, name string) (*PackageManifest, error) {
 	pkg, ok := q.pkgs[name]
 	if !ok {
-		return nil, fmt.Errorf("package %q not found", name)
+		return nil, NewNotFoundError(Resource{Package: name}, "package %q not found", name)
 	}
 
 	var channels []PackageChannel
 	for _, ch := range pkg.Channels {
 		head, err := ch.Head()
 		if err != nil {
-			return nil, fmt.Errorf("package %q, channel %q has invalid head: %v", name, ch.Name, err)
+			return nil, NewInternalError(Resource{Package: name, Channel: ch.Name}, "package %q, channel %q has invalid head: %v", name, ch.Name, err)
 		}
 		channels = append(channels, PackageChannel{
 			Name:           ch.Name,
@@ -73,19 +188,19 @@ func (q Querier) GetPackage(_ context.Context, name string) (*PackageManifest, e
 func (q Querier) GetBundle(_ context.Context, pkgName, channelName, csvName string) (*api.Bundle, error) {
 	pkg, ok := q.pkgs[pkgName]
 	if !ok {
-		return nil, fmt.Errorf("package %q not found", pkgName)
+		return nil, NewNotFoundError(Resource{Package: pkgName}, "package %q not found", pkgName)
 	}
 	ch, ok := pkg.Channels[channelName]
 	if !ok {
-		return nil, fmt.Errorf("package %q, channel %q not found", pkgName, channelName)
+		return nil, NewNotFoundError(Resource{Package: pkgName, Channel: channelName}, "package %q, channel %q not found", pkgName, channelName)
 	}
 	b, ok := ch.Bundles[csvName]
 	if !ok {
-		return nil, fmt.Errorf("package %q, channel %q, bundle %q not found", pkgName, channelName, csvName)
+		return nil, NewNotFoundError(Resource{Package: pkgName, Channel: channelName, Bundle: csvName}, "package %q, channel %q, bundle %q not found", pkgName, channelName, csvName)
 	}
-	apiBundle, err := api.ConvertModelBundleToAPIBundle(*b)
+	apiBundle, err := q.apiBundle(*b)
 	if err != nil {
-		return nil, fmt.Errorf("convert bundle %q: %v", b.Name, err)
+		return nil, NewInternalError(Resource{Package: pkgName, Channel: channelName, Bundle: b.Name}, "convert bundle %q: %v", b.Name, err)
 	}
 
 	// unset Replaces and Skips (sqlite query does not populate these fields)
@@ -97,19 +212,19 @@ func (q Querier) GetBundle(_ context.Context, pkgName, channelName, csvName stri
 func (q Querier) GetBundleForChannel(_ context.Context, pkgName string, channelName string) (*api.Bundle, error) {
 	pkg, ok := q.pkgs[pkgName]
 	if !ok {
-		return nil, fmt.Errorf("package %q not found", pkgName)
+		return nil, NewNotFoundError(Resource{Package: pkgName}, "package %q not found", pkgName)
 	}
 	ch, ok := pkg.Channels[channelName]
 	if !ok {
-		return nil, fmt.Errorf("package %q, channel %q not found", pkgName, channelName)
+		return nil, NewNotFoundError(Resource{Package: pkgName, Channel: channelName}, "package %q, channel %q not found", pkgName, channelName)
 	}
 	head, err := ch.Head()
 	if err != nil {
-		return nil, fmt.Errorf("package %q, channel %q has invalid head: %v", pkgName, channelName, err)
+		return nil, NewInternalError(Resource{Package: pkgName, Channel: channelName}, "package %q, channel %q has invalid head: %v", pkgName, channelName, err)
 	}
-	apiBundle, err := api.ConvertModelBundleToAPIBundle(*head)
+	apiBundle, err := q.apiBundle(*head)
 	if err != nil {
-		return nil, fmt.Errorf("convert bundle %q: %v", head.Name, err)
+		return nil, NewInternalError(Resource{Package: pkgName, Channel: channelName, Bundle: head.Name}, "convert bundle %q: %v", head.Name, err)
 	}
 
 	// unset Replaces and Skips (sqlite query does not populate these fields)
@@ -129,7 +244,7 @@ func (q Querier) GetChannelEntriesThatReplace(_ context.Context, name string) ([
 		}
 	}
 	if len(entries) == 0 {
-		return nil, fmt.Errorf("no channel entries found that replace %s", name)
+		return nil, NewNotFoundError(Resource{Bundle: name}, "no channel entries found that replace %s", name)
 	}
 	return entries, nil
 }
@@ -137,11 +252,11 @@ func (q Querier) GetChannelEntriesThatReplace(_ context.Context, name string) ([
 func (q Querier) GetBundleThatReplaces(_ context.Context, name, pkgName, channelName string) (*api.Bundle, error) {
 	pkg, ok := q.pkgs[pkgName]
 	if !ok {
-		return nil, fmt.Errorf("package %s not found", pkgName)
+		return nil, NewNotFoundError(Resource{Package: pkgName}, "package %s not found", pkgName)
 	}
 	ch, ok := pkg.Channels[channelName]
 	if !ok {
-		return nil, fmt.Errorf("package %q, channel %q not found", pkgName, channelName)
+		return nil, NewNotFoundError(Resource{Package: pkgName, Channel: channelName}, "package %q, channel %q not found", pkgName, channelName)
 	}
 
 	// NOTE: iterating over a map is non-deterministic in Go, so if multiple bundles replace this one,
@@ -150,9 +265,9 @@ func (q Querier) GetBundleThatReplaces(_ context.Context, name, pkgName, channel
 	//       implementation to be non-deterministic as well.
 	for _, b := range ch.Bundles {
 		if bundleReplaces(*b, name) {
-			apiBundle, err := api.ConvertModelBundleToAPIBundle(*b)
+			apiBundle, err := q.apiBundle(*b)
 			if err != nil {
-				return nil, fmt.Errorf("convert bundle %q: %v", b.Name, err)
+				return nil, NewInternalError(Resource{Package: pkgName, Channel: channelName, Bundle: b.Name}, "convert bundle %q: %v", b.Name, err)
 			}
 
 			// unset Replaces and Skips (sqlite query does not populate these fields)
@@ -161,7 +276,7 @@ func (q Querier) GetBundleThatReplaces(_ context.Context, name, pkgName, channel
 			return apiBundle, nil
 		}
 	}
-	return nil, fmt.Errorf("no entry found for package %q, channel %q", pkgName, channelName)
+	return nil, NewNotFoundError(Resource{Package: pkgName, Channel: channelName, Bundle: name}, "no entry found for package %q, channel %q", pkgName, channelName)
 }
 
 func (q Querier) GetChannelEntriesThatProvide(_ context.Context, group, version, kind string) ([]*ChannelEntry, error) {
@@ -170,7 +285,7 @@ func (q Querier) GetChannelEntriesThatProvide(_ context.Context, group, version,
 	for _, pkg := range q.pkgs {
 		for _, ch := range pkg.Channels {
 			for _, b := range ch.Bundles {
-				provides, err := doesModelBundleProvide(*b, group, version, kind)
+				provides, err := q.bundleProvides(*b, group, version, kind)
 				if err != nil {
 					return nil, err
 				}
@@ -187,39 +302,29 @@ func (q Querier) GetChannelEntriesThatProvide(_ context.Context, group, version,
 		}
 	}
 	if len(entries) == 0 {
-		return nil, fmt.Errorf("no channel entries found that provide group:%q version:%q kind:%q", group, version, kind)
+		return nil, NewNotFoundError(APIResource(group, version, kind), "no channel entries found that provide group:%q version:%q kind:%q", group, version, kind)
 	}
 	return entries, nil
 }
 
-// TODO(joelanford): Need to review the expected functionality of this function. I ran
-//   some experiments with the sqlite version of this function and it seems to only return
-//   channel heads that provide the GVK (rather than searching down the graph if parent bundles
-//   don't provide the API). Based on that, this function currently looks at channel heads only.
-//   ---
-//   Separate, but possibly related, I noticed there are several channels in the channel entry
-//   table who's minimum depth is 1. What causes 1 to be minimum depth in some cases and 0 in others?
+// GetLatestChannelEntriesThatProvide returns, for each channel, the entries of the bundle nearest the
+// channel head that provides the API, which need not be the head itself.
 func (q Querier) GetLatestChannelEntriesThatProvide(_ context.Context, group, version, kind string) ([]*ChannelEntry, error) {
 	var entries []*ChannelEntry
 
 	for _, pkg := range q.pkgs {
 		for _, ch := range pkg.Channels {
-			b, err := ch.Head()
+			b, err := q.latestProvider(*ch, group, version, kind)
 			if err != nil {
-				return nil, fmt.Errorf("package %q, channel %q has invalid head: %v", pkg.Name, ch.Name, err)
+				return nil, NewInternalError(Resource{Package: pkg.Name, Channel: ch.Name}, "package %q, channel %q: %v", pkg.Name, ch.Name, err)
 			}
-
-			provides, err := doesModelBundleProvide(*b, group, version, kind)
-			if err != nil {
-				return nil, err
-			}
-			if provides {
+			if b != nil {
 				entries = append(entries, channelEntriesForBundle(*b, false)...)
 			}
 		}
 	}
 	if len(entries) == 0 {
-		return nil, fmt.Errorf("no channel entries found that provide group:%q version:%q kind:%q", group, version, kind)
+		return nil, NewNotFoundError(APIResource(group, version, kind), "no channel entries found that provide group:%q version:%q kind:%q", group, version, kind)
 	}
 	return entries, nil
 }
@@ -248,11 +353,61 @@ func (q Querier) GetBundleThatProvides(ctx context.Context, group, version, kind
 			return q.GetBundle(ctx, entry.PackageName, entry.ChannelName, entry.BundleName)
 		}
 	}
-	return nil, fmt.Errorf("no entry found that provides group:%q version:%q kind:%q", group, version, kind)
+	return nil, NewNotFoundError(APIResource(group, version, kind), "no entry found that provides group:%q version:%q kind:%q", group, version, kind)
+}
+
+func (q Querier) ListBundlesWithProperty(_ context.Context, predicate PropertyPredicate) ([]*BundlePropertyMatch, error) {
+	if err := predicate.Validate(); err != nil {
+		return nil, err
+	}
+
+	var matches []*BundlePropertyMatch
+	byBundle := map[bundleKey]*BundlePropertyMatch{}
+	for _, pkg := range q.pkgs {
+		for _, ch := range pkg.Channels {
+			for _, b := range ch.Bundles {
+				var matching []*api.Property
+				for _, p := range b.Properties {
+					if p.Type != predicate.Type {
+						continue
+					}
+					ok, err := predicate.Match(string(p.Value))
+					if err != nil {
+						return nil, NewInternalError(Resource{Package: pkg.Name, Channel: ch.Name, Bundle: b.Name}, "match property: %v", err)
+					}
+					if ok {
+						matching = append(matching, &api.Property{Type: p.Type, Value: string(p.Value)})
+					}
+				}
+				if len(matching) == 0 {
+					continue
+				}
+
+				// bundles are listed once, with the matching properties of every channel they're in
+				key := bundleKey{pkg: pkg.Name, name: b.Name}
+				match, ok := byBundle[key]
+				if !ok {
+					match = &BundlePropertyMatch{
+						PackageName: pkg.Name,
+						CsvName:     b.Name,
+						BundlePath:  b.Image,
+					}
+					if props, err := property.Parse(b.Properties); err == nil && len(props.Packages) > 0 {
+						match.Version = props.Packages[0].Version
+					}
+					byBundle[key] = match
+					matches = append(matches, match)
+				}
+				match.addProperties(matching...)
+			}
+		}
+	}
+	SortBundlePropertyMatches(matches)
+	return matches, nil
 }
 
-func doesModelBundleProvide(b model.Bundle, group, version, kind string) (bool, error) {
-	apiBundle, err := api.ConvertModelBundleToAPIBundle(b)
+func (q Querier) bundleProvides(b model.Bundle, group, version, kind string) (bool, error) {
+	apiBundle, _, err := q.sharedAPIBundle(b)
 	if err != nil {
 		return false, fmt.Errorf("convert bundle %q: %v", b.Name, err)
 	}
@@ -264,6 +419,34 @@ func doesModelBundleProvide(b model.Bundle, group, version, kind string) (bool,
 	return false, nil
 }
 
+// latestProvider returns the bundle nearest the head of ch that provides the API, searching breadth-first
+// through the bundles each one replaces or skips, or nil if no bundle in ch provides it.
+func (q Querier) latestProvider(ch model.Channel, group, version, kind string) (*model.Bundle, error) {
+	head, err := ch.Head()
+	if err != nil {
+		return nil, fmt.Errorf("invalid head: %v", err)
+	}
+	visited := map[string]struct{}{head.Name: {}}
+	for queue := []*model.Bundle{head}; len(queue) > 0; queue = queue[1:] {
+		b := queue[0]
+		provides, err := q.bundleProvides(*b, group, version, kind)
+		if err != nil {
+			return nil, err
+		}
+		if provides {
+			return b, nil
+		}
+		for _, name := range append([]string{b.Replaces}, b.Skips...) {
+			next, ok := ch.Bundles[name]
+			if _, seen := visited[name]; ok && !seen {
+				visited[name] = struct{}{}
+				queue = append(queue, next)
+			}
+		}
+	}
+	return nil, nil
+}
+
 func bundleReplaces(b model.Bundle, name string) bool {
 	if b.Replaces == name {
 		return true
@@ -292,7 +475,7 @@ func channelEntriesThatReplace(b model.Bundle, name string) []*ChannelEntry {
 				PackageName: b.Package.Name,
 				ChannelName: b.Channel.Name,
 				BundleName:  b.Name,
-				Replaces:    b.Replaces,
+				Replaces:    s,
 			})
 		}
 	}
@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/operator-framework/operator-registry/internal/declcfg"
+	"github.com/operator-framework/operator-registry/pkg/api"
 )
 
 var testModelQuerier = genTestModelQuerier()
@@ -174,12 +175,57 @@ func TestQuerier_ListPackages(t *testing.T) {
 	require.Equal(t, 2, len(packages))
 }
 
+func TestQuerier_ListBundlesWithProperty(t *testing.T) {
+	value := func(v string) *string { return &v }
+
+	matches, err := testModelQuerier.ListBundlesWithProperty(context.TODO(), PropertyPredicate{Type: "olm.gvk", Path: "$.kind", Value: value("EtcdBackup")})
+	require.NoError(t, err)
+	var names []string
+	for _, m := range matches {
+		require.Equal(t, "etcd", m.PackageName)
+		require.Len(t, m.Properties, 1)
+		require.Equal(t, "olm.gvk", m.Properties[0].Type)
+		names = append(names, m.CsvName)
+	}
+	// etcdoperator.v0.9.0 is in two channels, but is listed once
+	require.Equal(t, []string{"etcdoperator.v0.9.0", "etcdoperator.v0.9.2-clusterwide", "etcdoperator.v0.9.4", "etcdoperator.v0.9.4-clusterwide"}, names)
+	require.Equal(t, "0.9.0", matches[0].Version)
+	require.Equal(t, "quay.io/operatorhubio/etcd:v0.9.0", matches[0].BundlePath)
+
+	matches, err = testModelQuerier.ListBundlesWithProperty(context.TODO(), PropertyPredicate{Type: "olm.skips", Value: value("etcdoperator.v0.9.0")})
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	require.Equal(t, "etcdoperator.v0.9.2-clusterwide", matches[0].CsvName)
+	require.Equal(t, `"etcdoperator.v0.9.0"`, matches[0].Properties[0].Value)
+
+	// bundles in several channels are listed with the channel property of each
+	matches, err = testModelQuerier.ListBundlesWithProperty(context.TODO(), PropertyPredicate{Type: "olm.channel"})
+	require.NoError(t, err)
+	byName := map[string]*BundlePropertyMatch{}
+	for _, m := range matches {
+		byName[m.CsvName] = m
+	}
+	require.Contains(t, byName, "etcdoperator.v0.9.0")
+	require.Equal(t, []*api.Property{
+		{Type: "olm.channel", Value: `{"name":"clusterwide-alpha"}`},
+		{Type: "olm.channel", Value: `{"name":"singlenamespace-alpha"}`},
+	}, byName["etcdoperator.v0.9.0"].Properties)
+
+	matches, err = testModelQuerier.ListBundlesWithProperty(context.TODO(), PropertyPredicate{Type: "olm.package.required"})
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	require.Equal(t, "etcdoperator.v0.9.4", matches[0].CsvName)
+
+	_, err = testModelQuerier.ListBundlesWithProperty(context.TODO(), PropertyPredicate{Type: "olm.gvk", Path: "kind"})
+	require.Error(t, err)
+}
+
 func genTestModelQuerier() *Querier {
-	cfg, err := declcfg.LoadFS(validFS)
+	cfg, err := declcfg.LoadFS(context.TODO(), validFS)
 	if err != nil {
 		panic(err)
 	}
-	m, err := declcfg.ConvertToModel(*cfg)
+	m, err := declcfg.ConvertToModel(context.TODO(), *cfg)
 	if err != nil {
 		panic(err)
 	}
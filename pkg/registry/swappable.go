@@ -0,0 +1,160 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+)
+
+// ErrCatalogNotLoaded is returned by queries against a SwappableQuerier that has no store yet.
+var ErrCatalogNotLoaded = errors.New("catalog not loaded")
+
+// SwappableQuerier is a GRPCQuery backed by a store that can be replaced while it is being served,
+// for example when a catalog is reloaded from a newer image.
+type SwappableQuerier struct {
+	mu    sync.RWMutex
+	store GRPCQuery
+	err   error
+}
+
+var _ GRPCQuery = &SwappableQuerier{}
+
+// NewSwappableQuerier returns a querier backed by store.
+// The store may be nil while the catalog is still loading, in which case queries fail with ErrCatalogNotLoaded.
+func NewSwappableQuerier(store GRPCQuery) *SwappableQuerier {
+	return &SwappableQuerier{store: store}
+}
+
+// Swap replaces the backing store and clears any failure recorded by Fail.
+// Queries already in flight complete against the previous store.
+func (q *SwappableQuerier) Swap(store GRPCQuery) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.store = store
+	q.err = nil
+}
+
+// Fail records that the catalog could not be reloaded. Queries continue to be answered by the
+// previous store, if any, but Ready reports err until the next successful Swap.
+func (q *SwappableQuerier) Fail(err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.err = err
+}
+
+// Ready returns nil when the querier is backed by an up-to-date catalog, or the reason it is not.
+func (q *SwappableQuerier) Ready() error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.err != nil {
+		return q.err
+	}
+	if q.store == nil {
+		return ErrCatalogNotLoaded
+	}
+	return nil
+}
+
+func (q *SwappableQuerier) current() (GRPCQuery, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.store == nil {
+		return nil, ErrCatalogNotLoaded
+	}
+	return q.store, nil
+}
+
+func (q *SwappableQuerier) ListPackages(ctx context.Context) ([]string, error) {
+	store, err := q.current()
+	if err != nil {
+		return nil, err
+	}
+	return store.ListPackages(ctx)
+}
+
+func (q *SwappableQuerier) ListBundles(ctx context.Context) ([]*api.Bundle, error) {
+	store, err := q.current()
+	if err != nil {
+		return nil, err
+	}
+	return store.ListBundles(ctx)
+}
+
+func (q *SwappableQuerier) GetPackage(ctx context.Context, name string) (*PackageManifest, error) {
+	store, err := q.current()
+	if err != nil {
+		return nil, err
+	}
+	return store.GetPackage(ctx, name)
+}
+
+func (q *SwappableQuerier) GetBundle(ctx context.Context, pkgName, channelName, csvName string) (*api.Bundle, error) {
+	store, err := q.current()
+	if err != nil {
+		return nil, err
+	}
+	return store.GetBundle(ctx, pkgName, channelName, csvName)
+}
+
+func (q *SwappableQuerier) GetBundleForChannel(ctx context.Context, pkgName string, channelName string) (*api.Bundle, error) {
+	store, err := q.current()
+	if err != nil {
+		return nil, err
+	}
+	return store.GetBundleForChannel(ctx, pkgName, channelName)
+}
+
+func (q *SwappableQuerier) GetChannelEntriesThatReplace(ctx context.Context, name string) ([]*ChannelEntry, error) {
+	store, err := q.current()
+	if err != nil {
+		return nil, err
+	}
+	return store.GetChannelEntriesThatReplace(ctx, name)
+}
+
+func (q *SwappableQuerier) GetBundleThatReplaces(ctx context.Context, name, pkgName, channelName string) (*api.Bundle, error) {
+	store, err := q.current()
+	if err != nil {
+		return nil, err
+	}
+	return store.GetBundleThatReplaces(ctx, name, pkgName, channelName)
+}
+
+func (q *SwappableQuerier) GetChannelEntriesThatProvide(ctx context.Context, group, version, kind string) ([]*ChannelEntry, error) {
+	store, err := q.current()
+	if err != nil {
+		return nil, err
+	}
+	return store.GetChannelEntriesThatProvide(ctx, group, version, kind)
+}
+
+func (q *SwappableQuerier) GetLatestChannelEntriesThatProvide(ctx context.Context, group, version, kind string) ([]*ChannelEntry, error) {
+	store, err := q.current()
+	if err != nil {
+		return nil, err
+	}
+	return store.GetLatestChannelEntriesThatProvide(ctx, group, version, kind)
+}
+
+func (q *SwappableQuerier) GetBundleThatProvides(ctx context.Context, group, version, kind string) (*api.Bundle, error) {
+	store, err := q.current()
+	if err != nil {
+		return nil, err
+	}
+	return store.GetBundleThatProvides(ctx, group, version, kind)
+}
+
+// GetCatalogInfo describes the catalog of the current store, if it can describe its catalog.
+func (q *SwappableQuerier) GetCatalogInfo(ctx context.Context) (*CatalogInfo, error) {
+	store, err := q.current()
+	if err != nil {
+		return nil, err
+	}
+	infoStore, ok := store.(CatalogInfoQuery)
+	if !ok {
+		return nil, ErrCatalogInfoUnsupported
+	}
+	return infoStore.GetCatalogInfo(ctx)
+}
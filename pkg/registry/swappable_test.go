@@ -0,0 +1,42 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/internal/model"
+)
+
+func TestSwappableQuerier(t *testing.T) {
+	q := NewSwappableQuerier(nil)
+	require.Equal(t, ErrCatalogNotLoaded, q.Ready())
+	_, err := q.ListPackages(context.TODO())
+	require.Equal(t, ErrCatalogNotLoaded, err)
+
+	q.Swap(NewQuerier(model.Model{}))
+	require.NoError(t, q.Ready())
+	packages, err := q.ListPackages(context.TODO())
+	require.NoError(t, err)
+	require.Empty(t, packages)
+	_, err = q.GetPackage(context.TODO(), "etcd")
+	require.Error(t, err)
+
+	q.Swap(testModelQuerier)
+	packages, err = q.ListPackages(context.TODO())
+	require.NoError(t, err)
+	require.Contains(t, packages, "etcd")
+
+	// a failed reload keeps serving the previous store
+	reloadErr := errors.New("reload failed")
+	q.Fail(reloadErr)
+	require.Equal(t, reloadErr, q.Ready())
+	pkg, err := q.GetPackage(context.TODO(), "etcd")
+	require.NoError(t, err)
+	require.Equal(t, "etcd", pkg.PackageName)
+
+	q.Swap(testModelQuerier)
+	require.NoError(t, q.Ready())
+}
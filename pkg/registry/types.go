@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/blang/semver"
 )
@@ -20,6 +21,13 @@ var (
 	// ErrRemovingDefaultChannelDuringDeprecation is an error that describes a bundle deprecation causing the deletion
 	// of the default channel
 	ErrRemovingDefaultChannelDuringDeprecation = errors.New("Bundle deprecation causing default channel removal")
+
+	// ErrRemovingDefaultChannelDuringBundleRemoval is an error that describes a bundle removal causing the deletion
+	// of the default channel
+	ErrRemovingDefaultChannelDuringBundleRemoval = errors.New("Bundle removal causing default channel removal")
+
+	// ErrBundleNotDeprecated is an error that describes undeprecating a bundle that is not deprecated
+	ErrBundleNotDeprecated = errors.New("Bundle not deprecated")
 )
 
 // BundleImageAlreadyAddedErr is an error that describes a bundle is already added
@@ -120,6 +128,38 @@ func (pc PackageChannel) IsDefaultChannel(pm PackageManifest) bool {
 	return pc.Name == pm.DefaultChannelName || len(pm.Channels) == 1
 }
 
+// CatalogInfo describes the catalog served by a store.
+type CatalogInfo struct {
+	// Digest identifies the content of the catalog: two stores have the same digest if and only if they
+	// serve the same content.
+	Digest   string
+	Packages int
+	Bundles  int
+	// LoadTime is when the store was loaded.
+	LoadTime time.Time
+}
+
+// ProvenanceOperation is an operation on a bundle recorded in its provenance.
+type ProvenanceOperation string
+
+const (
+	ProvenanceAdd       ProvenanceOperation = "add"
+	ProvenanceOverwrite ProvenanceOperation = "overwrite"
+	ProvenanceDeprecate ProvenanceOperation = "deprecate"
+)
+
+// Provenance records an operation on a bundle in an index database.
+type Provenance struct {
+	BundleName string
+	// AddedAt is when the operation happened.
+	AddedAt time.Time
+	// SourceDigest is the digest the bundle image resolved to when it was pulled, if known.
+	SourceDigest string
+	// OpmVersion is the version of opm that performed the operation.
+	OpmVersion string
+	Operation  ProvenanceOperation
+}
+
 // ChannelEntry is a denormalized node in a channel graph
 type ChannelEntry struct {
 	PackageName string
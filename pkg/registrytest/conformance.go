@@ -0,0 +1,431 @@
+package registrytest
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+//go:embed testdata/conformance
+var conformanceFS embed.FS
+
+// QuerierFactory returns a store serving the package manifests in dir.
+type QuerierFactory func(t *testing.T, dir string) registry.GRPCQuery
+
+// Catalog is a fixture catalog in the package manifest format, with the answers a store serving it must give.
+type Catalog struct {
+	// FS holds the package manifests, one directory per package.
+	FS fs.FS
+	// Packages are the packages in the catalog.
+	Packages []registry.PackageManifest
+	// Bundles are the bundles in the catalog, once for each channel they are in. Only their names, versions,
+	// APIs and, from ListBundles, the bundles they replace and skip are compared.
+	Bundles []*api.Bundle
+	// Replaces are the channel entries of the bundles replacing or skipping each bundle name.
+	Replaces map[string][]registry.ChannelEntry
+	// APIs are the APIs provided by bundles in the catalog.
+	APIs []API
+}
+
+// API is an API provided by bundles in a Catalog.
+type API struct {
+	Group   string
+	Version string
+	Kind    string
+	// Entries are the channel entries of every bundle providing the API.
+	Entries []registry.ChannelEntry
+	// Latest are the entries of the bundles nearest the head of each channel that provide the API,
+	// whether or not the head itself still does.
+	Latest []registry.ChannelEntry
+	// Bundle is the name of the latest bundle providing the API in a default channel. When several
+	// packages provide it, the first package by name wins.
+	Bundle string
+}
+
+// ConformanceCatalog returns a catalog of two packages: foo, whose newest bundle in its default channel
+// stops providing an API an older one provided, and bar, which provides that API too.
+func ConformanceCatalog() Catalog {
+	fsys, err := fs.Sub(conformanceFS, "testdata/conformance")
+	if err != nil {
+		panic(err)
+	}
+
+	foo := &api.GroupVersionKind{Group: "test.example.com", Version: "v1", Kind: "Foo"}
+	bar := &api.GroupVersionKind{Group: "test.example.com", Version: "v1", Kind: "Bar"}
+	entry := func(pkg, channel, bundle, replaces string) registry.ChannelEntry {
+		return registry.ChannelEntry{PackageName: pkg, ChannelName: channel, BundleName: bundle, Replaces: replaces}
+	}
+	return Catalog{
+		FS: fsys,
+		Packages: []registry.PackageManifest{
+			{
+				PackageName:        "foo",
+				DefaultChannelName: "stable",
+				Channels: []registry.PackageChannel{
+					{Name: "stable", CurrentCSVName: "foo.v0.2.0"},
+					{Name: "beta", CurrentCSVName: "foo.v0.1.0"},
+				},
+			},
+			{
+				PackageName:        "bar",
+				DefaultChannelName: "alpha",
+				Channels:           []registry.PackageChannel{{Name: "alpha", CurrentCSVName: "bar.v1.0.0"}},
+			},
+		},
+		Bundles: []*api.Bundle{
+			{
+				CsvName: "foo.v0.2.0", PackageName: "foo", ChannelName: "stable", Version: "0.2.0",
+				Replaces: "foo.v0.1.0", Skips: []string{"foo.v0.1.1"},
+				ProvidedApis: []*api.GroupVersionKind{foo},
+			},
+			{
+				CsvName: "foo.v0.1.0", PackageName: "foo", ChannelName: "stable", Version: "0.1.0",
+				ProvidedApis: []*api.GroupVersionKind{foo, bar},
+			},
+			{
+				CsvName: "foo.v0.1.0", PackageName: "foo", ChannelName: "beta", Version: "0.1.0",
+				ProvidedApis: []*api.GroupVersionKind{foo, bar},
+			},
+			{
+				CsvName: "bar.v1.0.0", PackageName: "bar", ChannelName: "alpha", Version: "1.0.0",
+				ProvidedApis: []*api.GroupVersionKind{bar},
+				RequiredApis: []*api.GroupVersionKind{foo},
+			},
+		},
+		Replaces: map[string][]registry.ChannelEntry{
+			"foo.v0.1.0": {entry("foo", "stable", "foo.v0.2.0", "foo.v0.1.0")},
+			"foo.v0.1.1": {entry("foo", "stable", "foo.v0.2.0", "foo.v0.1.1")},
+		},
+		APIs: []API{
+			{
+				Group: foo.Group, Version: foo.Version, Kind: foo.Kind,
+				Entries: []registry.ChannelEntry{
+					entry("foo", "stable", "foo.v0.2.0", "foo.v0.1.0"),
+					entry("foo", "stable", "foo.v0.2.0", "foo.v0.1.1"),
+					entry("foo", "stable", "foo.v0.1.0", ""),
+					entry("foo", "beta", "foo.v0.1.0", ""),
+				},
+				Latest: []registry.ChannelEntry{
+					entry("foo", "stable", "foo.v0.2.0", "foo.v0.1.0"),
+					entry("foo", "beta", "foo.v0.1.0", ""),
+				},
+				Bundle: "foo.v0.2.0",
+			},
+			{
+				Group: bar.Group, Version: bar.Version, Kind: bar.Kind,
+				Entries: []registry.ChannelEntry{
+					entry("foo", "stable", "foo.v0.1.0", ""),
+					entry("foo", "beta", "foo.v0.1.0", ""),
+					entry("bar", "alpha", "bar.v1.0.0", ""),
+				},
+				Latest: []registry.ChannelEntry{
+					entry("foo", "stable", "foo.v0.1.0", ""),
+					entry("foo", "beta", "foo.v0.1.0", ""),
+					entry("bar", "alpha", "bar.v1.0.0", ""),
+				},
+				Bundle: "bar.v1.0.0",
+			},
+		},
+	}
+}
+
+// TestGRPCQuery checks that the store newQuerier returns for the catalog answers every GRPCQuery method
+// as the catalog expects, and fails lookups of anything the catalog doesn't have. The order of listed
+// results is unspecified, so it is not compared.
+func TestGRPCQuery(t *testing.T, catalog Catalog, newQuerier QuerierFactory) {
+	dir, err := ioutil.TempDir("", "conformance-")
+	noError(t, err)
+	defer os.RemoveAll(dir)
+	noError(t, writeFS(catalog.FS, dir))
+
+	q := newQuerier(t, dir)
+	ctx := context.TODO()
+
+	t.Run("ListPackages", func(t *testing.T) {
+		var expected []string
+		for _, p := range catalog.Packages {
+			expected = append(expected, p.PackageName)
+		}
+		actual, err := q.ListPackages(ctx)
+		noError(t, err)
+		elementsMatch(t, expected, actual)
+	})
+
+	t.Run("GetPackage", func(t *testing.T) {
+		for _, expected := range catalog.Packages {
+			actual, err := q.GetPackage(ctx, expected.PackageName)
+			noError(t, err)
+			equal(t, expected.PackageName, actual.PackageName)
+			equal(t, expected.DefaultChannelName, actual.DefaultChannelName)
+			elementsMatch(t, expected.Channels, actual.Channels)
+		}
+		_, err := q.GetPackage(ctx, "missing")
+		requireNotFound(t, err)
+	})
+
+	t.Run("ListBundles", func(t *testing.T) {
+		actual, err := q.ListBundles(ctx)
+		noError(t, err)
+		elementsMatch(t, identities(catalog.Bundles, true), identities(actual, true))
+	})
+
+	t.Run("GetBundle", func(t *testing.T) {
+		for _, expected := range catalog.Bundles {
+			actual, err := q.GetBundle(ctx, expected.PackageName, expected.ChannelName, expected.CsvName)
+			noError(t, err)
+			equal(t, identify(expected, false), identify(actual, false))
+		}
+		b := catalog.Bundles[0]
+		_, err := q.GetBundle(ctx, b.PackageName, b.ChannelName, "missing")
+		requireNotFound(t, err)
+		_, err = q.GetBundle(ctx, b.PackageName, "missing", b.CsvName)
+		requireNotFound(t, err)
+		_, err = q.GetBundle(ctx, "missing", b.ChannelName, b.CsvName)
+		requireNotFound(t, err)
+	})
+
+	t.Run("GetBundleForChannel", func(t *testing.T) {
+		for _, p := range catalog.Packages {
+			for _, ch := range p.Channels {
+				actual, err := q.GetBundleForChannel(ctx, p.PackageName, ch.Name)
+				noError(t, err)
+				equal(t, identify(catalog.bundle(p.PackageName, ch.Name, ch.CurrentCSVName), false), identify(actual, false))
+			}
+			_, err := q.GetBundleForChannel(ctx, p.PackageName, "missing")
+			requireNotFound(t, err)
+		}
+		_, err := q.GetBundleForChannel(ctx, "missing", "missing")
+		requireNotFound(t, err)
+	})
+
+	t.Run("GetChannelEntriesThatReplace", func(t *testing.T) {
+		for name, expected := range catalog.Replaces {
+			actual, err := q.GetChannelEntriesThatReplace(ctx, name)
+			noError(t, err)
+			elementsMatch(t, expected, entries(actual), "entries replacing %s", name)
+		}
+		_, err := q.GetChannelEntriesThatReplace(ctx, "missing")
+		requireNotFound(t, err)
+	})
+
+	t.Run("GetBundleThatReplaces", func(t *testing.T) {
+		for name, replacements := range catalog.Replaces {
+			for _, e := range replacements {
+				actual, err := q.GetBundleThatReplaces(ctx, name, e.PackageName, e.ChannelName)
+				noError(t, err)
+				equal(t, identify(catalog.bundle(e.PackageName, e.ChannelName, e.BundleName), false), identify(actual, false), "bundle replacing %s", name)
+			}
+		}
+		// nothing replaces a channel head
+		p := catalog.Packages[0]
+		ch := p.Channels[0]
+		_, err := q.GetBundleThatReplaces(ctx, ch.CurrentCSVName, p.PackageName, ch.Name)
+		requireNotFound(t, err)
+		_, err = q.GetBundleThatReplaces(ctx, ch.CurrentCSVName, p.PackageName, "missing")
+		requireNotFound(t, err)
+	})
+
+	missing := API{Group: "missing.example.com", Version: "v1", Kind: "Missing"}
+
+	t.Run("GetChannelEntriesThatProvide", func(t *testing.T) {
+		for _, a := range catalog.APIs {
+			actual, err := q.GetChannelEntriesThatProvide(ctx, a.Group, a.Version, a.Kind)
+			noError(t, err)
+			elementsMatch(t, a.Entries, entries(actual), "entries providing %s", a)
+		}
+		_, err := q.GetChannelEntriesThatProvide(ctx, missing.Group, missing.Version, missing.Kind)
+		requireNotFound(t, err)
+	})
+
+	t.Run("GetLatestChannelEntriesThatProvide", func(t *testing.T) {
+		for _, a := range catalog.APIs {
+			actual, err := q.GetLatestChannelEntriesThatProvide(ctx, a.Group, a.Version, a.Kind)
+			noError(t, err)
+			elementsMatch(t, a.Latest, entries(actual), "latest entries providing %s", a)
+		}
+		_, err := q.GetLatestChannelEntriesThatProvide(ctx, missing.Group, missing.Version, missing.Kind)
+		requireNotFound(t, err)
+	})
+
+	t.Run("GetBundleThatProvides", func(t *testing.T) {
+		for _, a := range catalog.APIs {
+			actual, err := q.GetBundleThatProvides(ctx, a.Group, a.Version, a.Kind)
+			noError(t, err)
+			equal(t, a.Bundle, actual.CsvName, "bundle providing %s", a)
+			var pkg registry.PackageManifest
+			for _, p := range catalog.Packages {
+				if p.PackageName == actual.PackageName {
+					pkg = p
+				}
+			}
+			equal(t, pkg.DefaultChannelName, actual.ChannelName, "bundle providing %s", a)
+		}
+		_, err := q.GetBundleThatProvides(ctx, missing.Group, missing.Version, missing.Kind)
+		requireNotFound(t, err)
+	})
+}
+
+func (a API) String() string {
+	return fmt.Sprintf("%s/%s/%s", a.Group, a.Version, a.Kind)
+}
+
+// bundle returns the expected bundle with the given name in a package's channel.
+func (c Catalog) bundle(pkg, channel, name string) *api.Bundle {
+	for _, b := range c.Bundles {
+		if b.PackageName == pkg && b.ChannelName == channel && b.CsvName == name {
+			return b
+		}
+	}
+	return nil
+}
+
+// identity is the part of a bundle the conformance suite compares.
+type identity struct {
+	PackageName  string
+	ChannelName  string
+	CsvName      string
+	Version      string
+	Replaces     string
+	Skips        []string
+	ProvidedApis []string
+	RequiredApis []string
+}
+
+// identify returns the identity of b, including the bundles it replaces and skips if edges is set.
+// Stores only return those when listing bundles.
+func identify(b *api.Bundle, edges bool) identity {
+	if b == nil {
+		return identity{}
+	}
+	id := identity{
+		PackageName:  b.PackageName,
+		ChannelName:  b.ChannelName,
+		CsvName:      b.CsvName,
+		Version:      b.Version,
+		ProvidedApis: gvks(b.ProvidedApis),
+		RequiredApis: gvks(b.RequiredApis),
+	}
+	if edges {
+		id.Replaces = b.Replaces
+		id.Skips = sorted(b.Skips)
+	}
+	return id
+}
+
+func identities(bundles []*api.Bundle, edges bool) []identity {
+	var ids []identity
+	for _, b := range bundles {
+		ids = append(ids, identify(b, edges))
+	}
+	return ids
+}
+
+// gvks returns the sorted group/version/kind of each API; not every store knows their plural names.
+func gvks(apis []*api.GroupVersionKind) []string {
+	var out []string
+	for _, a := range apis {
+		out = append(out, fmt.Sprintf("%s/%s/%s", a.Group, a.Version, a.Kind))
+	}
+	return sorted(out)
+}
+
+func sorted(s []string) []string {
+	if len(s) == 0 {
+		return nil
+	}
+	out := append([]string(nil), s...)
+	sort.Strings(out)
+	return out
+}
+
+func entries(in []*registry.ChannelEntry) []registry.ChannelEntry {
+	var out []registry.ChannelEntry
+	for _, e := range in {
+		out = append(out, *e)
+	}
+	return out
+}
+
+// writeFS copies the files in fsys to dir.
+func writeFS(fsys fs.FS, dir string) error {
+	return fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, filepath.FromSlash(path))
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		data, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(target, data, 0644)
+	})
+}
+
+// The suite reports failures with the testing package alone, so that using it doesn't require an assertion library.
+
+// requireNotFound fails the test unless err is a registry.NotFoundError.
+func requireNotFound(t *testing.T, err error) {
+	t.Helper()
+	if !errors.As(err, &registry.NotFoundError{}) {
+		t.Fatalf("expected a registry.NotFoundError, got %T: %v", err, err)
+	}
+}
+
+// noError fails the test if err is set.
+func noError(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// equal fails the test unless expected and actual are deeply equal.
+func equal(t *testing.T, expected, actual interface{}, msgAndArgs ...interface{}) {
+	t.Helper()
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("%sexpected %+v, got %+v", describe(msgAndArgs), expected, actual)
+	}
+}
+
+// elementsMatch fails the test unless the slices expected and actual hold deeply equal elements, in any order.
+func elementsMatch(t *testing.T, expected, actual interface{}, msgAndArgs ...interface{}) {
+	t.Helper()
+	e, a := reflect.ValueOf(expected), reflect.ValueOf(actual)
+	matched := make([]bool, a.Len())
+	ok := e.Len() == a.Len()
+	for i := 0; ok && i < e.Len(); i++ {
+		found := false
+		for j := 0; j < a.Len(); j++ {
+			if !matched[j] && reflect.DeepEqual(e.Index(i).Interface(), a.Index(j).Interface()) {
+				matched[j], found = true, true
+				break
+			}
+		}
+		ok = found
+	}
+	if !ok {
+		t.Fatalf("%sexpected elements %+v, got %+v", describe(msgAndArgs), expected, actual)
+	}
+}
+
+// describe formats an optional message and its arguments as a prefix for a failure.
+func describe(msgAndArgs []interface{}) string {
+	if len(msgAndArgs) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(msgAndArgs[0].(string), msgAndArgs[1:]...) + ": "
+}
@@ -0,0 +1,215 @@
+// Package registrytest provides an in-process registry server for testing code that uses pkg/client, and a
+// conformance suite for registry.GRPCQuery implementations.
+package registrytest
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/operator-framework/operator-registry/internal/declcfg"
+	"github.com/operator-framework/operator-registry/pkg/api"
+	health "github.com/operator-framework/operator-registry/pkg/api/grpc_health_v1"
+	"github.com/operator-framework/operator-registry/pkg/client"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+	"github.com/operator-framework/operator-registry/pkg/server"
+)
+
+const bufSize = 1024 * 1024
+
+// Fault describes how calls to a method misbehave.
+type Fault struct {
+	// Latency delays each call before it is handled.
+	Latency time.Duration
+	// Code, unless OK, fails each call with this code instead of handling it.
+	Code codes.Code
+	// DisconnectAfter, when positive, breaks server streams with Unavailable after sending this many messages,
+	// as a client sees when the connection drops mid-stream.
+	DisconnectAfter int
+	// Times limits the fault to this many calls, after which calls are handled normally. Zero means every call.
+	Times int
+}
+
+// Server is a registry server listening on an in-memory connection.
+type Server struct {
+	// Client is connected to the server, retrying calls that fail with Unavailable as clients from client.NewClient do.
+	Client *client.Client
+
+	lis        *bufconn.Listener
+	grpcServer *grpc.Server
+
+	mu     sync.Mutex
+	faults map[string]*Fault
+}
+
+// NewServerFromFS serves the declarative config files in fsys.
+func NewServerFromFS(fsys fs.FS) (*Server, error) {
+	ctx := context.TODO()
+	cfg, err := declcfg.LoadFS(ctx, fsys)
+	if err != nil {
+		return nil, fmt.Errorf("load declarative config: %v", err)
+	}
+	m, err := declcfg.ConvertToModel(ctx, *cfg)
+	if err != nil {
+		return nil, fmt.Errorf("convert declarative config to model: %v", err)
+	}
+	return NewServer(registry.NewQuerier(m))
+}
+
+// NewServerFromDir serves the declarative config files in dir.
+func NewServerFromDir(dir string) (*Server, error) {
+	return NewServerFromFS(os.DirFS(dir))
+}
+
+// NewServer serves store.
+func NewServer(store registry.GRPCQuery) (*Server, error) {
+	s := &Server{
+		lis:    bufconn.Listen(bufSize),
+		faults: map[string]*Fault{},
+	}
+	s.grpcServer = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(s.unaryInterceptor),
+		grpc.ChainStreamInterceptor(s.streamInterceptor),
+	)
+	api.RegisterRegistryServer(s.grpcServer, server.NewRegistryServer(store))
+	health.RegisterHealthServer(s.grpcServer, server.NewHealthServer())
+	go s.grpcServer.Serve(s.lis)
+
+	c, err := s.Dial()
+	if err != nil {
+		s.grpcServer.Stop()
+		return nil, err
+	}
+	s.Client = c
+	return s, nil
+}
+
+// Dial returns a new client connected to the server, configured as client.NewClient configures it
+// with any additional opts.
+func (s *Server) Dial(opts ...grpc.DialOption) (*client.Client, error) {
+	dialer := grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		return s.lis.Dial()
+	})
+	return client.NewClient("bufconn", append([]grpc.DialOption{dialer}, opts...)...)
+}
+
+// ListenTCP serves the server on a loopback TCP port as well, for code that dials an address, and returns the address.
+// Faults apply to calls on either connection.
+func (s *Server) ListenTCP() (string, error) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	go s.grpcServer.Serve(lis)
+	return lis.Addr().String(), nil
+}
+
+// Close closes the server's client and stops the server, breaking any open connections.
+func (s *Server) Close() {
+	if s.Client != nil {
+		s.Client.Close()
+	}
+	s.grpcServer.Stop()
+}
+
+// Inject makes calls to the named Registry RPC, e.g. "GetBundle", misbehave as described by fault.
+// An empty method applies the fault to every RPC without a fault of its own, including health checks.
+func (s *Server) Inject(method string, fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults[method] = &fault
+}
+
+// ClearFaults removes every injected fault.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = map[string]*Fault{}
+}
+
+// fault returns the fault to apply to a call of the given full method name, counting the call against its Times.
+func (s *Server) fault(fullMethod string) *Fault {
+	method := fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := method
+	f, ok := s.faults[key]
+	if !ok {
+		key = ""
+		if f, ok = s.faults[key]; !ok {
+			return nil
+		}
+	}
+	if f.Times > 0 {
+		f.Times--
+		if f.Times == 0 {
+			delete(s.faults, key)
+		}
+	}
+	applied := *f
+	return &applied
+}
+
+func (f *Fault) apply(ctx context.Context, method string) error {
+	if f.Latency > 0 {
+		timer := time.NewTimer(f.Latency)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-timer.C:
+		}
+	}
+	if f.Code != codes.OK {
+		return status.Errorf(f.Code, "injected fault in %s", method)
+	}
+	return nil
+}
+
+func (s *Server) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if f := s.fault(info.FullMethod); f != nil {
+		if err := f.apply(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+	}
+	return handler(ctx, req)
+}
+
+func (s *Server) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	f := s.fault(info.FullMethod)
+	if f == nil {
+		return handler(srv, ss)
+	}
+	if err := f.apply(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	if f.DisconnectAfter > 0 {
+		ss = &disconnectingStream{ServerStream: ss, remaining: f.DisconnectAfter}
+	}
+	return handler(srv, ss)
+}
+
+// disconnectingStream fails once it has sent a fixed number of messages.
+type disconnectingStream struct {
+	grpc.ServerStream
+	remaining int
+}
+
+func (s *disconnectingStream) SendMsg(m interface{}) error {
+	if s.remaining == 0 {
+		return status.Error(codes.Unavailable, "injected disconnect")
+	}
+	s.remaining--
+	return s.ServerStream.SendMsg(m)
+}
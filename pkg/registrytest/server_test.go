@@ -0,0 +1,129 @@
+package registrytest
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/operator-framework/operator-registry/pkg/client"
+)
+
+var testFS = fstest.MapFS{
+	"etcd.json": &fstest.MapFile{Data: []byte(`{
+    "schema": "olm.package",
+    "name": "etcd",
+    "defaultChannel": "stable"
+}
+{
+    "schema": "olm.bundle",
+    "name": "etcd.v0.9.0",
+    "package": "etcd",
+    "image": "quay.io/example/etcd:v0.9.0",
+    "properties": [
+        {"type": "olm.channel", "value": {"name": "stable"}},
+        {"type": "olm.package", "value": {"packageName": "etcd", "version": "0.9.0"}}
+    ]
+}
+{
+    "schema": "olm.bundle",
+    "name": "etcd.v0.9.2",
+    "package": "etcd",
+    "image": "quay.io/example/etcd:v0.9.2",
+    "properties": [
+        {"type": "olm.channel", "value": {"name": "stable", "replaces": "etcd.v0.9.0"}},
+        {"type": "olm.package", "value": {"packageName": "etcd", "version": "0.9.2"}}
+    ]
+}`)},
+}
+
+func TestServer(t *testing.T) {
+	s, err := NewServerFromFS(testFS)
+	require.NoError(t, err)
+	defer s.Close()
+	ctx := context.TODO()
+
+	healthy, err := s.Client.HealthCheck(ctx, time.Second)
+	require.NoError(t, err)
+	require.True(t, healthy)
+
+	b, err := s.Client.GetBundleInPackageChannel(ctx, "etcd", "stable")
+	require.NoError(t, err)
+	require.Equal(t, "etcd.v0.9.2", b.CsvName)
+
+	t.Run("Code", func(t *testing.T) {
+		s.Inject("GetPackage", Fault{Code: codes.PermissionDenied})
+		defer s.ClearFaults()
+
+		_, err := s.Client.GetPackage(ctx, "etcd")
+		require.Equal(t, codes.PermissionDenied, status.Code(err))
+		_, err = s.Client.GetBundle(ctx, "etcd", "stable", "etcd.v0.9.2")
+		require.NoError(t, err, "other methods are unaffected")
+	})
+
+	t.Run("Times", func(t *testing.T) {
+		// the client's retries outlast a limited run of Unavailable errors
+		s.Inject("GetPackage", Fault{Code: codes.Unavailable, Times: 2})
+		pkg, err := s.Client.GetPackage(ctx, "etcd")
+		require.NoError(t, err)
+		require.Equal(t, "etcd", pkg.Name)
+	})
+
+	t.Run("Latency", func(t *testing.T) {
+		s.Inject("", Fault{Latency: time.Second})
+		defer s.ClearFaults()
+
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		_, err := s.Client.GetPackage(ctx, "etcd")
+		require.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	})
+
+	t.Run("Disconnect", func(t *testing.T) {
+		s.Inject("ListBundles", Fault{DisconnectAfter: 1})
+		defer s.ClearFaults()
+
+		it, err := s.Client.ListBundles(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, it.Next())
+		require.Nil(t, it.Next())
+		require.Equal(t, codes.Unavailable, status.Code(it.Error()))
+	})
+}
+
+func TestNewServerFromDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "registrytest-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, writeFS(testFS, dir))
+
+	s, err := NewServerFromDir(dir)
+	require.NoError(t, err)
+	defer s.Close()
+
+	pkg, err := s.Client.GetPackage(context.TODO(), "etcd")
+	require.NoError(t, err)
+	require.Equal(t, "etcd.v0.9.2", pkg.Channels[0].CsvName)
+}
+
+func TestServerListenTCP(t *testing.T) {
+	s, err := NewServerFromFS(testFS)
+	require.NoError(t, err)
+	defer s.Close()
+
+	address, err := s.ListenTCP()
+	require.NoError(t, err)
+	c, err := client.NewClient(address)
+	require.NoError(t, err)
+	defer c.Close()
+
+	pkg, err := c.GetPackage(context.TODO(), "etcd")
+	require.NoError(t, err)
+	require.Equal(t, "etcd.v0.9.2", pkg.Channels[0].CsvName)
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+// toStatus converts an error returned by a registry.GRPCQuery to a gRPC status error. Typed registry
+// errors get their matching code and an ErrorInfo detail naming the package, channel, bundle or API
+// the request was about. A catalog that is still loading is unavailable, so clients retry the call.
+// Status errors, e.g. from a federated upstream, are returned as they are, and anything else is an
+// internal error.
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	var (
+		notFound   registry.NotFoundError
+		invalid    registry.InvalidArgumentError
+		internal   registry.InternalError
+		code       = codes.Internal
+		reason     = registry.ReasonInternal
+		resource   registry.Resource
+		hasDetails = true
+	)
+	switch {
+	case errors.As(err, &notFound):
+		code, reason, resource = codes.NotFound, registry.ReasonNotFound, notFound.Resource
+	case errors.As(err, &invalid):
+		code, reason, resource = codes.InvalidArgument, registry.ReasonInvalidArgument, invalid.Resource
+	case errors.As(err, &internal):
+		resource = internal.Resource
+	case errors.Is(err, registry.ErrCatalogNotLoaded):
+		code, hasDetails = codes.Unavailable, false
+	default:
+		hasDetails = false
+	}
+
+	st := status.New(code, err.Error())
+	if !hasDetails {
+		return st.Err()
+	}
+	withDetails, detailsErr := st.WithDetails(&errdetails.ErrorInfo{
+		Reason:   reason,
+		Domain:   registry.ErrorDomain,
+		Metadata: resourceMetadata(resource),
+	})
+	if detailsErr != nil {
+		return st.Err()
+	}
+	return withDetails.Err()
+}
+
+func resourceMetadata(r registry.Resource) map[string]string {
+	md := map[string]string{}
+	for key, value := range map[string]string{
+		registry.MetadataPackage: r.Package,
+		registry.MetadataChannel: r.Channel,
+		registry.MetadataBundle:  r.Bundle,
+		registry.MetadataAPI:     r.API,
+	} {
+		if value != "" {
+			md[key] = value
+		}
+	}
+	return md
+}
@@ -0,0 +1,168 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/operator-framework/operator-registry/internal/model"
+	"github.com/operator-framework/operator-registry/pkg/api"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+func TestToStatus(t *testing.T) {
+	upstream := status.Error(codes.Unavailable, "upstream down")
+
+	for _, tt := range []struct {
+		name     string
+		err      error
+		code     codes.Code
+		info     *errdetails.ErrorInfo
+		sameErr  bool
+		expected string
+	}{
+		{
+			name: "NotFound",
+			err:  registry.NewNotFoundError(registry.Resource{Package: "etcd", Channel: "alpha"}, "package %q, channel %q not found", "etcd", "alpha"),
+			code: codes.NotFound,
+			info: &errdetails.ErrorInfo{
+				Reason:   registry.ReasonNotFound,
+				Domain:   registry.ErrorDomain,
+				Metadata: map[string]string{registry.MetadataPackage: "etcd", registry.MetadataChannel: "alpha"},
+			},
+			expected: `package "etcd", channel "alpha" not found`,
+		},
+		{
+			name: "WrappedNotFound",
+			err:  fmt.Errorf("lookup: %w", registry.NewNotFoundError(registry.APIResource("etcd.database.coreos.com", "v1beta2", "EtcdCluster"), "no provider")),
+			code: codes.NotFound,
+			info: &errdetails.ErrorInfo{
+				Reason:   registry.ReasonNotFound,
+				Domain:   registry.ErrorDomain,
+				Metadata: map[string]string{registry.MetadataAPI: "etcd.database.coreos.com/v1beta2/EtcdCluster"},
+			},
+			expected: "lookup: no provider",
+		},
+		{
+			name: "InvalidArgument",
+			err:  registry.NewInvalidArgumentError(registry.Resource{Bundle: "etcdoperator.v0.9.2"}, "package name is required"),
+			code: codes.InvalidArgument,
+			info: &errdetails.ErrorInfo{
+				Reason:   registry.ReasonInvalidArgument,
+				Domain:   registry.ErrorDomain,
+				Metadata: map[string]string{registry.MetadataBundle: "etcdoperator.v0.9.2"},
+			},
+			expected: "package name is required",
+		},
+		{
+			name: "Internal",
+			err:  registry.NewInternalError(registry.Resource{Package: "etcd"}, "invalid head"),
+			code: codes.Internal,
+			info: &errdetails.ErrorInfo{
+				Reason:   registry.ReasonInternal,
+				Domain:   registry.ErrorDomain,
+				Metadata: map[string]string{registry.MetadataPackage: "etcd"},
+			},
+			expected: "invalid head",
+		},
+		{
+			name:     "Untyped",
+			err:      errors.New("database is locked"),
+			code:     codes.Internal,
+			expected: "database is locked",
+		},
+		{
+			name:     "CatalogNotLoaded",
+			err:      registry.ErrCatalogNotLoaded,
+			code:     codes.Unavailable,
+			expected: "catalog not loaded",
+		},
+		{
+			name:     "Status",
+			err:      upstream,
+			code:     codes.Unavailable,
+			sameErr:  true,
+			expected: "upstream down",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := toStatus(tt.err)
+			if tt.sameErr {
+				require.Equal(t, tt.err, err)
+			}
+			st, ok := status.FromError(err)
+			require.True(t, ok)
+			require.Equal(t, tt.code, st.Code())
+			require.Equal(t, tt.expected, st.Message())
+
+			details := st.Details()
+			if tt.info == nil {
+				require.Empty(t, details)
+				return
+			}
+			require.Len(t, details, 1)
+			info, ok := details[0].(*errdetails.ErrorInfo)
+			require.True(t, ok)
+			require.Equal(t, tt.info.GetReason(), info.GetReason())
+			require.Equal(t, tt.info.GetDomain(), info.GetDomain())
+			require.Equal(t, tt.info.GetMetadata(), info.GetMetadata())
+		})
+	}
+
+	require.NoError(t, toStatus(nil))
+}
+
+func TestRegistryServerErrorCodes(t *testing.T) {
+	s := NewRegistryServer(registry.NewQuerier(model.Model{}))
+	ctx := context.TODO()
+
+	_, err := s.GetPackage(ctx, &api.GetPackageRequest{Name: "etcd"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = s.GetPackage(ctx, &api.GetPackageRequest{})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = s.GetBundle(ctx, &api.GetBundleRequest{PkgName: "etcd", ChannelName: "alpha", CsvName: "etcdoperator.v0.9.2"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = s.GetBundle(ctx, &api.GetBundleRequest{PkgName: "etcd", ChannelName: "alpha"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = s.GetBundleForChannel(ctx, &api.GetBundleInChannelRequest{ChannelName: "alpha"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = s.GetBundleThatReplaces(ctx, &api.GetReplacementRequest{PkgName: "etcd", ChannelName: "alpha", CsvName: "etcdoperator.v0.9.0"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = s.GetDefaultBundleThatProvides(ctx, &api.GetDefaultProviderRequest{Group: "etcd.database.coreos.com", Version: "v1beta2", Kind: "EtcdCluster"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	// core APIs have no group
+	_, err = s.GetDefaultBundleThatProvides(ctx, &api.GetDefaultProviderRequest{Version: "v1", Kind: "ConfigMap"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = s.GetDefaultBundleThatProvides(ctx, &api.GetDefaultProviderRequest{Group: "etcd.database.coreos.com", Kind: "EtcdCluster"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestRegistryServerCatalogNotLoaded(t *testing.T) {
+	store := registry.NewSwappableQuerier(nil)
+	s := NewRegistryServer(store)
+	ctx := context.TODO()
+
+	_, err := s.GetPackage(ctx, &api.GetPackageRequest{Name: "etcd"})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+
+	_, err = s.GetBundleForChannel(ctx, &api.GetBundleInChannelRequest{PkgName: "etcd", ChannelName: "alpha"})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+
+	store.Swap(registry.NewQuerier(model.Model{}))
+	_, err = s.GetPackage(ctx, &api.GetPackageRequest{Name: "etcd"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
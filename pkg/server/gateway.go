@@ -0,0 +1,370 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+// GatewayPathPrefix is the path every gateway endpoint is rooted at.
+// Each Registry RPC is served at GatewayPathPrefix + the RPC name, e.g. /api/v1/GetBundle.
+const GatewayPathPrefix = "/api/v1/"
+
+// NDJSONContentType is the content type of responses from streaming RPCs, which hold one JSON message per line.
+const NDJSONContentType = "application/x-ndjson"
+
+// NewGatewayHandler returns an http.Handler that mirrors every Registry RPC as an HTTP/JSON endpoint backed by store.
+//
+// Request fields are read from the query string of a GET, or from the JSON body of a POST, using the
+// JSON names of the gRPC request message. Unary RPCs respond with a single JSON message, and streaming
+// RPCs respond with newline-delimited JSON. Each request runs through interceptors as the matching
+// gRPC call would on a server they are installed on.
+func NewGatewayHandler(store registry.GRPCQuery, interceptors ...Interceptors) http.Handler {
+	s := NewRegistryServer(store)
+	chain := mergeInterceptors(interceptors...)
+	mux := http.NewServeMux()
+	handleUnary := func(rpc string, req proto.Message, call func(context.Context, proto.Message) (proto.Message, error)) {
+		info := &grpc.UnaryServerInfo{Server: s, FullMethod: gatewayMethod(rpc)}
+		handler := chain.unaryHandler(info, func(ctx context.Context, in interface{}) (interface{}, error) {
+			return call(ctx, in.(proto.Message))
+		})
+		mux.Handle(GatewayPathPrefix+rpc, unary(req, handler))
+	}
+	handleStream := func(rpc string, req proto.Message, call func(proto.Message, grpc.ServerStream) error) {
+		info := &grpc.StreamServerInfo{FullMethod: gatewayMethod(rpc), IsServerStream: true}
+		mux.Handle(GatewayPathPrefix+rpc, stream(req, func(in proto.Message, out grpc.ServerStream) error {
+			return chain.streamHandler(info, func(_ interface{}, ss grpc.ServerStream) error {
+				return call(in, ss)
+			})(s, out)
+		}))
+	}
+
+	handleStream("ListPackages", &api.ListPackageRequest{}, func(req proto.Message, out grpc.ServerStream) error {
+		return s.ListPackages(req.(*api.ListPackageRequest), packageNameStream{out})
+	})
+	handleUnary("GetPackage", &api.GetPackageRequest{}, func(ctx context.Context, req proto.Message) (proto.Message, error) {
+		return s.GetPackage(ctx, req.(*api.GetPackageRequest))
+	})
+	handleUnary("GetBundle", &api.GetBundleRequest{}, func(ctx context.Context, req proto.Message) (proto.Message, error) {
+		return s.GetBundle(ctx, req.(*api.GetBundleRequest))
+	})
+	handleUnary("GetBundleForChannel", &api.GetBundleInChannelRequest{}, func(ctx context.Context, req proto.Message) (proto.Message, error) {
+		return s.GetBundleForChannel(ctx, req.(*api.GetBundleInChannelRequest))
+	})
+	handleStream("GetChannelEntriesThatReplace", &api.GetAllReplacementsRequest{}, func(req proto.Message, out grpc.ServerStream) error {
+		return s.GetChannelEntriesThatReplace(req.(*api.GetAllReplacementsRequest), channelEntryStream{out})
+	})
+	handleUnary("GetBundleThatReplaces", &api.GetReplacementRequest{}, func(ctx context.Context, req proto.Message) (proto.Message, error) {
+		return s.GetBundleThatReplaces(ctx, req.(*api.GetReplacementRequest))
+	})
+	handleStream("GetChannelEntriesThatProvide", &api.GetAllProvidersRequest{}, func(req proto.Message, out grpc.ServerStream) error {
+		return s.GetChannelEntriesThatProvide(req.(*api.GetAllProvidersRequest), channelEntryStream{out})
+	})
+	handleStream("GetLatestChannelEntriesThatProvide", &api.GetLatestProvidersRequest{}, func(req proto.Message, out grpc.ServerStream) error {
+		return s.GetLatestChannelEntriesThatProvide(req.(*api.GetLatestProvidersRequest), channelEntryStream{out})
+	})
+	handleUnary("GetDefaultBundleThatProvides", &api.GetDefaultProviderRequest{}, func(ctx context.Context, req proto.Message) (proto.Message, error) {
+		return s.GetDefaultBundleThatProvides(ctx, req.(*api.GetDefaultProviderRequest))
+	})
+	handleStream("ListBundles", &api.ListBundlesRequest{}, func(req proto.Message, out grpc.ServerStream) error {
+		return s.ListBundles(req.(*api.ListBundlesRequest), bundleStream{out})
+	})
+	handleUnary("GetCatalogInfo", &api.GetCatalogInfoRequest{}, func(ctx context.Context, req proto.Message) (proto.Message, error) {
+		return s.GetCatalogInfo(ctx, req.(*api.GetCatalogInfoRequest))
+	})
+
+	return mux
+}
+
+// gatewayMethod returns the full gRPC method name of a Registry RPC, as interceptors see it.
+func gatewayMethod(rpc string) string {
+	return "/api.Registry/" + rpc
+}
+
+// Gateway serves the HTTP/JSON gateway on its own listener, alongside a gRPC server.
+type Gateway struct {
+	lis    net.Listener
+	server *http.Server
+}
+
+// NewGateway listens on addr for gateway requests for store, serving TLS with tlsConfig when it is set.
+// Requests run through interceptors, which should match those installed on the gRPC server for store.
+func NewGateway(addr string, store registry.GRPCQuery, tlsConfig *tls.Config, interceptors ...Interceptors) (*Gateway, error) {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		lis = tls.NewListener(lis, tlsConfig)
+	}
+	return &Gateway{
+		lis:    lis,
+		server: &http.Server{Handler: NewGatewayHandler(store, interceptors...)},
+	}, nil
+}
+
+// Serve blocks serving gateway requests until the gateway is stopped.
+func (g *Gateway) Serve() error {
+	if err := g.server.Serve(g.lis); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// GracefulStop stops accepting requests and waits for in-flight requests to complete.
+func (g *Gateway) GracefulStop() {
+	_ = g.server.Shutdown(context.Background())
+}
+
+func unary(req proto.Message, call grpc.UnaryHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		in := proto.Clone(req)
+		if err := decodeRequest(r, in); err != nil {
+			writeError(w, err)
+			return
+		}
+		out, err := call(r.Context(), in)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		b, err := protojson.Marshal(out.(proto.Message))
+		if err != nil {
+			writeError(w, status.Error(codes.Internal, err.Error()))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(b)
+	}
+}
+
+func stream(req proto.Message, call func(proto.Message, grpc.ServerStream) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		in := proto.Clone(req)
+		if err := decodeRequest(r, in); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", NDJSONContentType)
+		out := &ndjsonStream{ctx: r.Context(), w: w}
+		err := call(in, out)
+		out.writeTrailer()
+		if err != nil {
+			if !out.headerSent {
+				writeError(w, err)
+				return
+			}
+			// The status line has already been sent, so report the failure as the final line of the stream
+			b, _ := json.Marshal(struct {
+				Error errorBody `json:"error"`
+			}{newErrorBody(err)})
+			_, _ = w.Write(append(b, '\n'))
+		}
+	}
+}
+
+// decodeRequest populates req from the body of a POST or the query parameters of a GET.
+func decodeRequest(r *http.Request, req proto.Message) error {
+	switch r.Method {
+	case http.MethodPost:
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		if len(body) == 0 {
+			return nil
+		}
+		if err := protojson.Unmarshal(body, req); err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid request body: %v", err)
+		}
+		return nil
+	case http.MethodGet:
+	default:
+		return errMethodNotAllowed
+	}
+
+	m := req.ProtoReflect()
+	fields := m.Descriptor().Fields()
+	for key, values := range r.URL.Query() {
+		fd := fields.ByJSONName(key)
+		if fd == nil {
+			fd = fields.ByName(protoreflect.Name(key))
+		}
+		if fd == nil || fd.Kind() != protoreflect.StringKind || fd.Cardinality() == protoreflect.Repeated {
+			return status.Errorf(codes.InvalidArgument, "unknown query parameter %q", key)
+		}
+		if len(values) != 1 {
+			return status.Errorf(codes.InvalidArgument, "query parameter %q must be set once", key)
+		}
+		m.Set(fd, protoreflect.ValueOfString(values[0]))
+	}
+	return nil
+}
+
+var errMethodNotAllowed = status.Error(codes.Unimplemented, "only GET and POST are supported")
+
+type errorBody struct {
+	Code    codes.Code `json:"code"`
+	Message string     `json:"message"`
+}
+
+func newErrorBody(err error) errorBody {
+	st := status.Convert(err)
+	return errorBody{Code: st.Code(), Message: st.Message()}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	body := newErrorBody(err)
+	b, _ := json.Marshal(body)
+	w.Header().Set("Content-Type", "application/json")
+	if err == errMethodNotAllowed {
+		w.Header().Set("Allow", "GET, POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	} else {
+		w.WriteHeader(httpStatusFromCode(body.Code))
+	}
+	_, _ = w.Write(b)
+}
+
+// httpStatusFromCode maps gRPC status codes to their closest HTTP equivalents.
+func httpStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// ndjsonStream writes the messages sent on a server stream as newline-delimited JSON.
+// Header metadata is sent as Grpc-Metadata-* response headers, and trailer metadata as HTTP trailers
+// of the same names.
+type ndjsonStream struct {
+	ctx        context.Context
+	w          http.ResponseWriter
+	header     metadata.MD
+	trailer    metadata.MD
+	headerSent bool
+}
+
+var _ grpc.ServerStream = &ndjsonStream{}
+
+func (s *ndjsonStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *ndjsonStream) SetHeader(md metadata.MD) error {
+	if s.headerSent {
+		return status.Error(codes.Internal, "header already sent")
+	}
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *ndjsonStream) SendHeader(md metadata.MD) error {
+	if err := s.SetHeader(md); err != nil {
+		return err
+	}
+	for k, vs := range s.header {
+		for _, v := range vs {
+			s.w.Header().Add(metadataHeaderPrefix+k, v)
+		}
+	}
+	s.w.WriteHeader(http.StatusOK)
+	s.headerSent = true
+	return nil
+}
+
+func (s *ndjsonStream) SetTrailer(md metadata.MD) {
+	s.trailer = metadata.Join(s.trailer, md)
+}
+
+// writeTrailer sends the trailer metadata once the handler has returned, if the stream has started.
+func (s *ndjsonStream) writeTrailer() {
+	if !s.headerSent {
+		return
+	}
+	for k, vs := range s.trailer {
+		for _, v := range vs {
+			s.w.Header().Add(http.TrailerPrefix+metadataHeaderPrefix+k, v)
+		}
+	}
+}
+
+func (s *ndjsonStream) SendMsg(m interface{}) error {
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return fmt.Errorf("unexpected message type %T", m)
+	}
+	b, err := protojson.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if !s.headerSent {
+		if err := s.SendHeader(nil); err != nil {
+			return err
+		}
+	}
+	if _, err := s.w.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	if f, ok := s.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// RecvMsg reports the end of the stream, since the request has been read before the handler is called.
+func (s *ndjsonStream) RecvMsg(interface{}) error {
+	return io.EOF
+}
+
+// metadataHeaderPrefix is prepended to the names of metadata keys sent as HTTP headers.
+const metadataHeaderPrefix = "Grpc-Metadata-"
+
+type packageNameStream struct{ grpc.ServerStream }
+
+func (s packageNameStream) Send(m *api.PackageName) error { return s.SendMsg(m) }
+
+type bundleStream struct{ grpc.ServerStream }
+
+func (s bundleStream) Send(m *api.Bundle) error { return s.SendMsg(m) }
+
+type channelEntryStream struct{ grpc.ServerStream }
+
+func (s channelEntryStream) Send(m *api.ChannelEntry) error { return s.SendMsg(m) }
@@ -0,0 +1,167 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+func TestGateway(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gateway_test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	stores := map[string]registry.GRPCQuery{
+		"Sqlite":            dbStore(filepath.Join(tmpDir, "test.db")),
+		"DeclarativeConfig": cfgStore(),
+	}
+	for name, store := range stores {
+		t.Run(name, testGateway(store))
+	}
+}
+
+func testGateway(store registry.GRPCQuery) func(*testing.T) {
+	return func(t *testing.T) {
+		srv := httptest.NewServer(NewGatewayHandler(store))
+		defer srv.Close()
+
+		t.Run("ListPackages", func(t *testing.T) {
+			resp, err := http.Get(srv.URL + GatewayPathPrefix + "ListPackages")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			require.Equal(t, NDJSONContentType, resp.Header.Get("Content-Type"))
+
+			var packages []string
+			scanner := bufio.NewScanner(resp.Body)
+			for scanner.Scan() {
+				var p api.PackageName
+				require.NoError(t, protojson.Unmarshal(scanner.Bytes(), &p))
+				packages = append(packages, p.Name)
+			}
+			require.NoError(t, scanner.Err())
+			require.ElementsMatch(t, []string{"etcd", "prometheus", "strimzi-kafka-operator"}, packages)
+		})
+
+		t.Run("GetPackageQuery", func(t *testing.T) {
+			resp, err := http.Get(srv.URL + GatewayPathPrefix + "GetPackage?name=etcd")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			body, err := ioutil.ReadAll(resp.Body)
+			require.NoError(t, err)
+			var pkg api.Package
+			require.NoError(t, protojson.Unmarshal(body, &pkg))
+			require.Equal(t, "etcd", pkg.Name)
+			require.Equal(t, "alpha", pkg.DefaultChannelName)
+			require.Len(t, pkg.Channels, 3)
+		})
+
+		t.Run("GetBundlePost", func(t *testing.T) {
+			resp, err := http.Post(srv.URL+GatewayPathPrefix+"GetBundle", "application/json",
+				strings.NewReader(`{"pkgName": "etcd", "channelName": "alpha", "csvName": "etcdoperator.v0.9.2"}`))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			body, err := ioutil.ReadAll(resp.Body)
+			require.NoError(t, err)
+			var bundle api.Bundle
+			require.NoError(t, protojson.Unmarshal(body, &bundle))
+			require.Equal(t, "etcdoperator.v0.9.2", bundle.CsvName)
+			require.Equal(t, "etcd", bundle.PackageName)
+		})
+
+		t.Run("GetChannelEntriesThatReplace", func(t *testing.T) {
+			resp, err := http.Get(srv.URL + GatewayPathPrefix + "GetChannelEntriesThatReplace?csvName=etcdoperator.v0.9.0")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			var entries []string
+			scanner := bufio.NewScanner(resp.Body)
+			for scanner.Scan() {
+				var e api.ChannelEntry
+				require.NoError(t, protojson.Unmarshal(scanner.Bytes(), &e))
+				require.Equal(t, "etcdoperator.v0.9.0", e.Replaces)
+				entries = append(entries, e.ChannelName+"/"+e.BundleName)
+			}
+			require.ElementsMatch(t, []string{"alpha/etcdoperator.v0.9.2", "stable/etcdoperator.v0.9.2"}, entries)
+		})
+
+		t.Run("UnknownParameter", func(t *testing.T) {
+			resp, err := http.Get(srv.URL + GatewayPathPrefix + "GetPackage?package=etcd")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		})
+
+		t.Run("MethodNotAllowed", func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodDelete, srv.URL+GatewayPathPrefix+"GetPackage?name=etcd", nil)
+			require.NoError(t, err)
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+		})
+
+		t.Run("Error", func(t *testing.T) {
+			resp, err := http.Get(srv.URL + GatewayPathPrefix + "GetPackage?name=missing")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.GreaterOrEqual(t, resp.StatusCode, http.StatusBadRequest)
+			require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+		})
+	}
+}
+
+func TestGatewayInterceptors(t *testing.T) {
+	var methods []string
+	interceptors := Interceptors{
+		Unary: []grpc.UnaryServerInterceptor{func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			methods = append(methods, info.FullMethod)
+			return handler(ctx, req)
+		}},
+		Stream: []grpc.StreamServerInterceptor{func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			methods = append(methods, info.FullMethod)
+			if err := ss.SetHeader(metadata.Pairs("served-by", "gateway")); err != nil {
+				return err
+			}
+			ss.SetTrailer(metadata.Pairs("sent", "done"))
+			return handler(srv, ss)
+		}},
+	}
+	srv := httptest.NewServer(NewGatewayHandler(cfgStore(), interceptors))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + GatewayPathPrefix + "GetPackage?name=etcd")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = http.Get(srv.URL + GatewayPathPrefix + "ListPackages")
+	require.NoError(t, err)
+	_, err = ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "gateway", resp.Header.Get("Grpc-Metadata-served-by"))
+	require.Equal(t, "done", resp.Trailer.Get("Grpc-Metadata-sent"))
+
+	require.Equal(t, []string{"/api.Registry/GetPackage", "/api.Registry/ListPackages"}, methods)
+}
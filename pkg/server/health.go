@@ -3,19 +3,75 @@ package server
 import (
 	"context"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
 	health "github.com/operator-framework/operator-registry/pkg/api/grpc_health_v1"
 )
 
+const (
+	// RegistryServiceName is the service name the Registry service reports its health under.
+	RegistryServiceName = "api.Registry"
+
+	// legacyRegistryServiceName is the service name historically sent by pkg/client health checks.
+	legacyRegistryServiceName = "Registry"
+)
+
+// StoreStatus reports whether a store is able to answer queries.
+type StoreStatus interface {
+	// Ready returns nil when the store can answer queries, or the reason it cannot.
+	Ready() error
+}
+
+// UpstreamStatus is implemented by stores that federate upstream registry servers,
+// whose health is reported under the upstream's name.
+type UpstreamStatus interface {
+	// UpstreamReady reports whether name is a known upstream, and nil if it is serving or the reason it is not.
+	UpstreamReady(name string) (bool, error)
+}
+
 type HealthServer struct {
 	health.UnimplementedHealthServer
+	store StoreStatus
 }
 
 var _ health.HealthServer = &HealthServer{}
 
+// NewHealthServer returns a health server that always reports SERVING.
+// Use it for stores that are fully loaded before the server starts.
 func NewHealthServer() *HealthServer {
-	return &HealthServer{health.UnimplementedHealthServer{}}
+	return &HealthServer{UnimplementedHealthServer: health.UnimplementedHealthServer{}}
 }
 
+// NewStoreHealthServer returns a health server that reports SERVING only while store is ready,
+// and NOT_SERVING while it is loading or after it has failed to reload.
+func NewStoreHealthServer(store StoreStatus) *HealthServer {
+	return &HealthServer{UnimplementedHealthServer: health.UnimplementedHealthServer{}, store: store}
+}
+
+// Check reports the status of the server as a whole (the empty service name) or of the Registry service.
+// Both reflect the state of the store. When the store federates upstreams, each upstream's status is
+// reported under its name. Any other service name is reported as NotFound.
 func (s *HealthServer) Check(ctx context.Context, req *health.HealthCheckRequest) (*health.HealthCheckResponse, error) {
+	var err error
+	switch req.GetService() {
+	case "", RegistryServiceName, legacyRegistryServiceName:
+		if s.store != nil {
+			err = s.store.Ready()
+		}
+	default:
+		upstreams, ok := s.store.(UpstreamStatus)
+		if !ok {
+			return nil, status.Errorf(codes.NotFound, "unknown service %q", req.GetService())
+		}
+		var known bool
+		if known, err = upstreams.UpstreamReady(req.GetService()); !known {
+			return nil, status.Errorf(codes.NotFound, "unknown service %q", req.GetService())
+		}
+	}
+
+	if err != nil {
+		return &health.HealthCheckResponse{Status: health.HealthCheckResponse_NOT_SERVING}, nil
+	}
 	return &health.HealthCheckResponse{Status: health.HealthCheckResponse_SERVING}, nil
 }
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/operator-framework/operator-registry/internal/model"
+	health "github.com/operator-framework/operator-registry/pkg/api/grpc_health_v1"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+func TestHealthServer(t *testing.T) {
+	check := func(t *testing.T, s *HealthServer, service string) health.HealthCheckResponse_ServingStatus {
+		res, err := s.Check(context.TODO(), &health.HealthCheckRequest{Service: service})
+		require.NoError(t, err)
+		return res.Status
+	}
+
+	t.Run("Static", func(t *testing.T) {
+		s := NewHealthServer()
+		for _, service := range []string{"", RegistryServiceName, "Registry"} {
+			require.Equal(t, health.HealthCheckResponse_SERVING, check(t, s, service))
+		}
+	})
+
+	t.Run("UnknownService", func(t *testing.T) {
+		_, err := NewHealthServer().Check(context.TODO(), &health.HealthCheckRequest{Service: "unknown"})
+		require.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("Store", func(t *testing.T) {
+		store := registry.NewSwappableQuerier(nil)
+		s := NewStoreHealthServer(store)
+		for _, service := range []string{"", RegistryServiceName} {
+			require.Equal(t, health.HealthCheckResponse_NOT_SERVING, check(t, s, service), "loading")
+		}
+
+		store.Swap(registry.NewQuerier(model.Model{}))
+		for _, service := range []string{"", RegistryServiceName} {
+			require.Equal(t, health.HealthCheckResponse_SERVING, check(t, s, service), "loaded")
+		}
+
+		store.Fail(context.DeadlineExceeded)
+		for _, service := range []string{"", RegistryServiceName} {
+			require.Equal(t, health.HealthCheckResponse_NOT_SERVING, check(t, s, service), "reload failed")
+		}
+
+		store.Swap(registry.NewQuerier(model.Model{}))
+		require.Equal(t, health.HealthCheckResponse_SERVING, check(t, s, RegistryServiceName), "reloaded")
+	})
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// Interceptors are the interceptors a server runs each call through, outermost first.
+// They can be installed on a gRPC server, and on a Gateway so that gateway requests are handled the same way.
+type Interceptors struct {
+	Unary  []grpc.UnaryServerInterceptor
+	Stream []grpc.StreamServerInterceptor
+}
+
+// ServerOptions returns the options that chain i onto a gRPC server.
+func (i Interceptors) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(i.Unary...),
+		grpc.ChainStreamInterceptor(i.Stream...),
+	}
+}
+
+// mergeInterceptors concatenates interceptors, preserving their order.
+func mergeInterceptors(interceptors ...Interceptors) Interceptors {
+	var merged Interceptors
+	for _, i := range interceptors {
+		merged.Unary = append(merged.Unary, i.Unary...)
+		merged.Stream = append(merged.Stream, i.Stream...)
+	}
+	return merged
+}
+
+// unaryHandler returns handler wrapped in i's unary interceptors, in the order a gRPC server runs them.
+func (i Interceptors) unaryHandler(info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) grpc.UnaryHandler {
+	for n := len(i.Unary) - 1; n >= 0; n-- {
+		interceptor, next := i.Unary[n], handler
+		handler = func(ctx context.Context, req interface{}) (interface{}, error) {
+			return interceptor(ctx, req, info, next)
+		}
+	}
+	return handler
+}
+
+// streamHandler returns handler wrapped in i's stream interceptors, in the order a gRPC server runs them.
+func (i Interceptors) streamHandler(info *grpc.StreamServerInfo, handler grpc.StreamHandler) grpc.StreamHandler {
+	for n := len(i.Stream) - 1; n >= 0; n-- {
+		interceptor, next := i.Stream[n], handler
+		handler = func(srv interface{}, ss grpc.ServerStream) error {
+			return interceptor(srv, ss, info, next)
+		}
+	}
+	return handler
+}
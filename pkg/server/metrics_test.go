@@ -0,0 +1,55 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+func TestMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m, err := NewMetrics(reg)
+	require.NoError(t, err)
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/api.Registry/GetPackage"}
+	ok := func(context.Context, interface{}) (interface{}, error) { return "ok", nil }
+	fail := func(context.Context, interface{}) (interface{}, error) { return nil, errors.New("fail") }
+	for _, handler := range []grpc.UnaryHandler{ok, ok, fail} {
+		_, _ = m.unaryInterceptor(context.TODO(), nil, info, handler)
+	}
+	require.NoError(t, m.streamInterceptor(nil, nil, &grpc.StreamServerInfo{FullMethod: "/api.Registry/ListPackages"}, func(interface{}, grpc.ServerStream) error {
+		return nil
+	}))
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	requests := map[string]float64{}
+	var observed uint64
+	for _, f := range families {
+		for _, metric := range f.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range metric.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			switch f.GetName() {
+			case "opm_grpc_requests_total":
+				requests[labels["method"]+" "+labels["code"]] = metric.GetCounter().GetValue()
+			case "opm_grpc_request_duration_seconds":
+				observed += metric.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+	require.Equal(t, map[string]float64{
+		"/api.Registry/GetPackage OK":      2,
+		"/api.Registry/GetPackage Unknown": 1,
+		"/api.Registry/ListPackages OK":    1,
+	}, requests)
+	require.EqualValues(t, 4, observed)
+
+	_, err = NewMetrics(reg)
+	require.Error(t, err, "metrics are already registered")
+}
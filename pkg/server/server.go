@@ -1,7 +1,12 @@
 package server
 
 import (
+	"errors"
+
 	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/operator-framework/operator-registry/pkg/api"
 	"github.com/operator-framework/operator-registry/pkg/registry"
@@ -21,7 +26,7 @@ func NewRegistryServer(store registry.GRPCQuery) *RegistryServer {
 func (s *RegistryServer) ListPackages(req *api.ListPackageRequest, stream api.Registry_ListPackagesServer) error {
 	packageNames, err := s.store.ListPackages(stream.Context())
 	if err != nil {
-		return err
+		return toStatus(err)
 	}
 	for _, p := range packageNames {
 		if err := stream.Send(&api.PackageName{Name: p}); err != nil {
@@ -35,7 +40,7 @@ func (s *RegistryServer) ListPackages(req *api.ListPackageRequest, stream api.Re
 func (s *RegistryServer) ListBundles(req *api.ListBundlesRequest, stream api.Registry_ListBundlesServer) error {
 	bundles, err := s.store.ListBundles(stream.Context())
 	if err != nil {
-		return err
+		return toStatus(err)
 	}
 	for _, b := range bundles {
 		if err := stream.Send(b); err != nil {
@@ -47,25 +52,41 @@ func (s *RegistryServer) ListBundles(req *api.ListBundlesRequest, stream api.Reg
 }
 
 func (s *RegistryServer) GetPackage(ctx context.Context, req *api.GetPackageRequest) (*api.Package, error) {
+	if req.GetName() == "" {
+		return nil, toStatus(registry.NewInvalidArgumentError(registry.Resource{}, "package name is required"))
+	}
 	packageManifest, err := s.store.GetPackage(ctx, req.GetName())
 	if err != nil {
-		return nil, err
+		return nil, toStatus(err)
 	}
 	return registry.PackageManifestToAPIPackage(packageManifest), nil
 }
 
 func (s *RegistryServer) GetBundle(ctx context.Context, req *api.GetBundleRequest) (*api.Bundle, error) {
-	return s.store.GetBundle(ctx, req.GetPkgName(), req.GetChannelName(), req.GetCsvName())
+	if req.GetPkgName() == "" || req.GetCsvName() == "" {
+		r := registry.Resource{Package: req.GetPkgName(), Channel: req.GetChannelName(), Bundle: req.GetCsvName()}
+		return nil, toStatus(registry.NewInvalidArgumentError(r, "package and bundle names are required"))
+	}
+	bundle, err := s.store.GetBundle(ctx, req.GetPkgName(), req.GetChannelName(), req.GetCsvName())
+	return bundle, toStatus(err)
 }
 
 func (s *RegistryServer) GetBundleForChannel(ctx context.Context, req *api.GetBundleInChannelRequest) (*api.Bundle, error) {
-	return s.store.GetBundleForChannel(ctx, req.GetPkgName(), req.GetChannelName())
+	if req.GetPkgName() == "" {
+		r := registry.Resource{Channel: req.GetChannelName()}
+		return nil, toStatus(registry.NewInvalidArgumentError(r, "package name is required"))
+	}
+	bundle, err := s.store.GetBundleForChannel(ctx, req.GetPkgName(), req.GetChannelName())
+	return bundle, toStatus(err)
 }
 
 func (s *RegistryServer) GetChannelEntriesThatReplace(req *api.GetAllReplacementsRequest, stream api.Registry_GetChannelEntriesThatReplaceServer) error {
+	if req.GetCsvName() == "" {
+		return toStatus(registry.NewInvalidArgumentError(registry.Resource{}, "bundle name is required"))
+	}
 	channelEntries, err := s.store.GetChannelEntriesThatReplace(stream.Context(), req.GetCsvName())
 	if err != nil {
-		return err
+		return toStatus(err)
 	}
 	for _, e := range channelEntries {
 		if err := stream.Send(registry.ChannelEntryToAPIChannelEntry(e)); err != nil {
@@ -76,13 +97,21 @@ func (s *RegistryServer) GetChannelEntriesThatReplace(req *api.GetAllReplacement
 }
 
 func (s *RegistryServer) GetBundleThatReplaces(ctx context.Context, req *api.GetReplacementRequest) (*api.Bundle, error) {
-	return s.store.GetBundleThatReplaces(ctx, req.GetCsvName(), req.GetPkgName(), req.GetChannelName())
+	if req.GetPkgName() == "" || req.GetCsvName() == "" {
+		r := registry.Resource{Package: req.GetPkgName(), Channel: req.GetChannelName(), Bundle: req.GetCsvName()}
+		return nil, toStatus(registry.NewInvalidArgumentError(r, "package and bundle names are required"))
+	}
+	bundle, err := s.store.GetBundleThatReplaces(ctx, req.GetCsvName(), req.GetPkgName(), req.GetChannelName())
+	return bundle, toStatus(err)
 }
 
 func (s *RegistryServer) GetChannelEntriesThatProvide(req *api.GetAllProvidersRequest, stream api.Registry_GetChannelEntriesThatProvideServer) error {
+	if err := requireAPI(req.GetGroup(), req.GetVersion(), req.GetKind()); err != nil {
+		return toStatus(err)
+	}
 	channelEntries, err := s.store.GetChannelEntriesThatProvide(stream.Context(), req.GetGroup(), req.GetVersion(), req.GetKind())
 	if err != nil {
-		return err
+		return toStatus(err)
 	}
 	for _, e := range channelEntries {
 		if err := stream.Send(registry.ChannelEntryToAPIChannelEntry(e)); err != nil {
@@ -93,9 +122,12 @@ func (s *RegistryServer) GetChannelEntriesThatProvide(req *api.GetAllProvidersRe
 }
 
 func (s *RegistryServer) GetLatestChannelEntriesThatProvide(req *api.GetLatestProvidersRequest, stream api.Registry_GetLatestChannelEntriesThatProvideServer) error {
+	if err := requireAPI(req.GetGroup(), req.GetVersion(), req.GetKind()); err != nil {
+		return toStatus(err)
+	}
 	channelEntries, err := s.store.GetLatestChannelEntriesThatProvide(stream.Context(), req.GetGroup(), req.GetVersion(), req.GetKind())
 	if err != nil {
-		return err
+		return toStatus(err)
 	}
 	for _, e := range channelEntries {
 		if err := stream.Send(registry.ChannelEntryToAPIChannelEntry(e)); err != nil {
@@ -106,5 +138,38 @@ func (s *RegistryServer) GetLatestChannelEntriesThatProvide(req *api.GetLatestPr
 }
 
 func (s *RegistryServer) GetDefaultBundleThatProvides(ctx context.Context, req *api.GetDefaultProviderRequest) (*api.Bundle, error) {
-	return s.store.GetBundleThatProvides(ctx, req.GetGroup(), req.GetVersion(), req.GetKind())
+	if err := requireAPI(req.GetGroup(), req.GetVersion(), req.GetKind()); err != nil {
+		return nil, toStatus(err)
+	}
+	bundle, err := s.store.GetBundleThatProvides(ctx, req.GetGroup(), req.GetVersion(), req.GetKind())
+	return bundle, toStatus(err)
+}
+
+// GetCatalogInfo describes the catalog served, if the store can describe it.
+func (s *RegistryServer) GetCatalogInfo(ctx context.Context, req *api.GetCatalogInfoRequest) (*api.CatalogInfo, error) {
+	store, ok := s.store.(registry.CatalogInfoQuery)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, registry.ErrCatalogInfoUnsupported.Error())
+	}
+	info, err := store.GetCatalogInfo(ctx)
+	if errors.Is(err, registry.ErrCatalogInfoUnsupported) {
+		return nil, status.Error(codes.Unimplemented, err.Error())
+	}
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &api.CatalogInfo{
+		Digest:       info.Digest,
+		PackageCount: int32(info.Packages),
+		BundleCount:  int32(info.Bundles),
+		LoadTime:     timestamppb.New(info.LoadTime),
+	}, nil
+}
+
+// requireAPI checks that a provider query names an API. The group may be empty, for core APIs.
+func requireAPI(group, version, kind string) error {
+	if version == "" || kind == "" {
+		return registry.NewInvalidArgumentError(registry.APIResource(group, version, kind), "API version and kind are required")
+	}
+	return nil
 }
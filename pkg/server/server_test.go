@@ -549,6 +549,29 @@ func testGetDefaultBundleThatProvides(addr string, expected *api.Bundle) func(*t
 	}
 }
 
+func TestGetCatalogInfo(t *testing.T) {
+	sqlInfo := getCatalogInfo(t, dbAddress)
+	cfgInfo := getCatalogInfo(t, cfgAddress)
+
+	for _, info := range []*api.CatalogInfo{sqlInfo, cfgInfo} {
+		require.NotEmpty(t, info.Digest)
+		require.EqualValues(t, 3, info.PackageCount)
+		require.NotZero(t, info.BundleCount)
+		require.NotNil(t, info.LoadTime)
+	}
+	require.Equal(t, sqlInfo.Digest, cfgInfo.Digest, "stores serving the same content should have the same digest")
+	require.Equal(t, sqlInfo.BundleCount, cfgInfo.BundleCount)
+}
+
+func getCatalogInfo(t *testing.T, addr string) *api.CatalogInfo {
+	c, conn := client(t, addr)
+	defer conn.Close()
+
+	info, err := c.GetCatalogInfo(context.TODO(), &api.GetCatalogInfoRequest{})
+	require.NoError(t, err)
+	return info
+}
+
 func TestListBundles(t *testing.T) {
 	t.Run("Sqlite", testListBundles(dbAddress,
 		etcdoperator_v0_9_2("alpha", true, false),
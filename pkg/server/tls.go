@@ -0,0 +1,36 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/operator-framework/operator-registry/pkg/lib/certs"
+)
+
+// TLSConfig returns the TLS config for serving the given key pair, or nil when certFile and keyFile are empty.
+// If clientCAFile is set, clients must authenticate with a certificate signed by one of its CAs.
+func TLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	switch {
+	case len(certFile) == 0 && len(keyFile) == 0:
+		if len(clientCAFile) > 0 {
+			return nil, fmt.Errorf("a client CA requires a serving certificate and key")
+		}
+		return nil, nil
+	case len(certFile) == 0 || len(keyFile) == 0:
+		return nil, fmt.Errorf("both a serving certificate and key are required to serve TLS")
+	}
+
+	return certs.ServerTLSConfig(certFile, keyFile, clientCAFile)
+}
+
+// TLSServerOptions returns the grpc.ServerOptions needed to serve TLS with config.
+// No options are returned for a nil config, and the server is left serving plaintext.
+func TLSServerOptions(config *tls.Config) []grpc.ServerOption {
+	if config == nil {
+		return nil
+	}
+	return []grpc.ServerOption{grpc.Creds(credentials.NewTLS(config))}
+}
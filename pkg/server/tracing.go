@@ -0,0 +1,51 @@
+package server
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"github.com/operator-framework/operator-registry/pkg/lib/tracing"
+)
+
+// TracingServerOptions returns the options that record a span for each call a gRPC server handles.
+// Spans go nowhere unless tracing has been set up.
+func TracingServerOptions() []grpc.ServerOption {
+	return TracingInterceptors().ServerOptions()
+}
+
+// TracingInterceptors returns the interceptors that record a span for each call a server handles.
+func TracingInterceptors() Interceptors {
+	return Interceptors{
+		Unary:  []grpc.UnaryServerInterceptor{tracingUnaryInterceptor},
+		Stream: []grpc.StreamServerInterceptor{tracingStreamInterceptor},
+	}
+}
+
+func tracingUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, span := tracing.Start(ctx, info.FullMethod, attribute.String("rpc.system", "grpc"))
+	resp, err := handler(ctx, req)
+	span.SetAttributes(attribute.String("rpc.grpc.status_code", status.Code(err).String()))
+	tracing.End(span, err)
+	return resp, err
+}
+
+func tracingStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, span := tracing.Start(ss.Context(), info.FullMethod, attribute.String("rpc.system", "grpc"))
+	err := handler(srv, &tracedStream{ServerStream: ss, ctx: ctx})
+	span.SetAttributes(attribute.String("rpc.grpc.status_code", status.Code(err).String()))
+	tracing.End(span, err)
+	return err
+}
+
+// tracedStream passes the context holding a call's span to its handler.
+type tracedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *tracedStream) Context() context.Context {
+	return s.ctx
+}
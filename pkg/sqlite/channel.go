@@ -0,0 +1,348 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/operator-framework/operator-registry/pkg/lib/tracing"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+// AddBundleToChannel adds a bundle of a package to one of its channels, creating the channel if it doesn't exist.
+// The bundle becomes the head of the channel, so it must upgrade from the channel's current head by replacing or
+// skipping it, directly or through the bundles it replaces.
+func (s *sqlLoader) AddBundleToChannel(packageName, channelName, bundleName string) (err error) {
+	span := s.traceTx("AddBundleToChannel", attribute.String("package", packageName), attribute.String("channel", channelName), attribute.String("bundle", bundleName))
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := s.begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		s.rollback(tx)
+	}()
+
+	manifest, err := s.getPackageManifest(tx, packageName)
+	if err != nil {
+		return err
+	}
+	if manifest == nil {
+		return registry.ErrPackageNotInDatabase
+	}
+	if err := s.checkChannelBundle(tx, packageName, bundleName); err != nil {
+		return err
+	}
+
+	channel, ok := findChannel(*manifest, channelName)
+	if !ok {
+		manifest.Channels = append(manifest.Channels, registry.PackageChannel{Name: channelName, CurrentCSVName: bundleName})
+		return s.commitPackage(tx, *manifest)
+	}
+
+	inChannel, err := s.inChannel(tx, packageName, channelName, bundleName)
+	if err != nil {
+		return err
+	}
+	if inChannel {
+		return fmt.Errorf("bundle %s is already in channel %s", bundleName, channelName)
+	}
+	upgrades, err := s.upgradesFrom(tx, bundleName)
+	if err != nil {
+		return err
+	}
+	if _, ok := upgrades[manifest.Channels[channel].CurrentCSVName]; !ok {
+		return fmt.Errorf("bundle %s does not replace or skip %s, the head of channel %s", bundleName, manifest.Channels[channel].CurrentCSVName, channelName)
+	}
+	manifest.Channels[channel].CurrentCSVName = bundleName
+
+	return s.commitPackage(tx, *manifest)
+}
+
+// RemoveBundleFromChannel removes a bundle from one channel of a package, leaving it in the others. The channel is
+// linked around the bundle: if it heads the channel, the bundle it replaces becomes the head or, if it replaces none,
+// the newest bundle of the channel it skips; the channel is removed if there is none. Otherwise the bundles of the
+// channel that replace or skip it replace or skip what it replaced and skipped instead. Since those edges are shared
+// by every channel of those bundles, they must be in no other channel. The default channel of the package is never
+// removed, and neither is the last channel of a bundle, which would remove the bundle from the package.
+func (s *sqlLoader) RemoveBundleFromChannel(packageName, channelName, bundleName string) (err error) {
+	span := s.traceTx("RemoveBundleFromChannel", attribute.String("package", packageName), attribute.String("channel", channelName), attribute.String("bundle", bundleName))
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := s.begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		s.rollback(tx)
+	}()
+
+	manifest, err := s.getPackageManifest(tx, packageName)
+	if err != nil {
+		return err
+	}
+	if manifest == nil {
+		return registry.ErrPackageNotInDatabase
+	}
+	channel, ok := findChannel(*manifest, channelName)
+	if !ok {
+		return fmt.Errorf("channel %s not found in package %s", channelName, packageName)
+	}
+	inChannel, err := s.inChannel(tx, packageName, channelName, bundleName)
+	if err != nil {
+		return err
+	}
+	if !inChannel {
+		return fmt.Errorf("bundle %s is not in channel %s", bundleName, channelName)
+	}
+
+	var others int
+	if err := tx.QueryRow(`SELECT count(*) FROM channel_entry WHERE package_name = ? AND operatorbundle_name = ? AND channel_name != ?`, packageName, bundleName, channelName).Scan(&others); err != nil {
+		return err
+	}
+	if others == 0 {
+		return fmt.Errorf("bundle %s is in no other channel, remove the bundle instead", bundleName)
+	}
+
+	replaces, skips, _, err := s.getBundleSkipsReplacesVersion(tx, bundleName)
+	if err != nil {
+		return err
+	}
+
+	if manifest.Channels[channel].CurrentCSVName != bundleName {
+		if err := s.relinkChannel(tx, packageName, channelName, bundleName, replaces, skips); err != nil {
+			return err
+		}
+		return s.commitPackage(tx, *manifest)
+	}
+
+	headless := replaces == ""
+	if !headless {
+		if headless, err = s.deprecated(tx, replaces); err != nil {
+			return err
+		}
+	}
+	if !headless {
+		manifest.Channels[channel].CurrentCSVName = replaces
+		return s.commitPackage(tx, *manifest)
+	}
+	head, err := s.newestSkipped(tx, packageName, channelName, skips)
+	if err != nil {
+		return err
+	}
+	if head != "" {
+		manifest.Channels[channel].CurrentCSVName = head
+		return s.commitPackage(tx, *manifest)
+	}
+	if manifest.Channels[channel].IsDefaultChannel(*manifest) {
+		return fmt.Errorf("%w: %s is the only bundle in default channel %s of package %s", registry.ErrRemovingDefaultChannelDuringBundleRemoval, bundleName, channelName, packageName)
+	}
+	manifest.Channels = append(manifest.Channels[:channel], manifest.Channels[channel+1:]...)
+
+	return s.commitPackage(tx, *manifest)
+}
+
+// relinkChannel makes the bundles of a channel that replace or skip the named bundle replace its replacement instead,
+// and skip the bundles it skips, so that the channel no longer reaches it. The bundles must be in no other channel,
+// which would lose the named bundle too.
+func (s *sqlLoader) relinkChannel(tx *sql.Tx, packageName, channelName, name, replaces string, skips []string) error {
+	rows, err := tx.Query(`SELECT DISTINCT operatorbundle.name FROM operatorbundle
+		INNER JOIN channel_entry ON channel_entry.operatorbundle_name = operatorbundle.name
+		WHERE channel_entry.package_name = ? AND channel_entry.channel_name = ?
+		AND (operatorbundle.replaces = ? OR instr(',' || operatorbundle.skips || ',', ',' || ? || ',') > 0)`, packageName, channelName, name, name)
+	if err != nil {
+		return err
+	}
+	var upgrades []string
+	for rows.Next() {
+		var bundle sql.NullString
+		if err := rows.Scan(&bundle); err != nil {
+			rows.Close()
+			return err
+		}
+		upgrades = append(upgrades, bundle.String)
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+
+	for _, bundle := range upgrades {
+		var other sql.NullString
+		err := tx.QueryRow(`SELECT channel_name FROM channel_entry WHERE package_name = ? AND operatorbundle_name = ? AND channel_name != ? LIMIT 1`, packageName, bundle, channelName).Scan(&other)
+		if err == nil {
+			return fmt.Errorf("bundle %s, which upgrades from %s, is also in channel %s", bundle, name, other.String)
+		}
+		if err != sql.ErrNoRows {
+			return err
+		}
+
+		bundleReplaces, bundleSkips, _, err := s.getBundleSkipsReplacesVersion(tx, bundle)
+		if err != nil {
+			return err
+		}
+		if bundleReplaces == name {
+			if _, err := tx.Exec(`UPDATE operatorbundle SET replaces = ? WHERE name = ?`, replaces, bundle); err != nil {
+				return err
+			}
+		}
+		var kept []string
+		for _, skip := range bundleSkips {
+			if skip != name {
+				kept = append(kept, skip)
+			}
+		}
+		if _, err := tx.Exec(`UPDATE operatorbundle SET skips = ? WHERE name = ?`, strings.Join(kept, ","), bundle); err != nil {
+			return err
+		}
+		if err := s.appendSkips(tx, skips, bundle); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RenameChannel renames a channel of a package, keeping it the default channel if it is.
+func (s *sqlLoader) RenameChannel(packageName, channelName, newName string) (err error) {
+	span := s.traceTx("RenameChannel", attribute.String("package", packageName), attribute.String("channel", channelName), attribute.String("name", newName))
+	defer func() { tracing.End(span, err) }()
+
+	if newName == "" {
+		return fmt.Errorf("channel name must not be empty")
+	}
+
+	tx, err := s.begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		s.rollback(tx)
+	}()
+
+	manifest, err := s.getPackageManifest(tx, packageName)
+	if err != nil {
+		return err
+	}
+	if manifest == nil {
+		return registry.ErrPackageNotInDatabase
+	}
+	channel, ok := findChannel(*manifest, channelName)
+	if !ok {
+		return fmt.Errorf("channel %s not found in package %s", channelName, packageName)
+	}
+	if _, ok := findChannel(*manifest, newName); ok {
+		return fmt.Errorf("channel %s already exists in package %s", newName, packageName)
+	}
+
+	manifest.Channels[channel].Name = newName
+	if manifest.DefaultChannelName == channelName {
+		manifest.DefaultChannelName = newName
+	}
+
+	return s.commitPackage(tx, *manifest)
+}
+
+// UpdateDefaultChannel makes an existing channel of a package its default channel.
+func (s *sqlLoader) UpdateDefaultChannel(packageName, channelName string) (err error) {
+	span := s.traceTx("UpdateDefaultChannel", attribute.String("package", packageName), attribute.String("channel", channelName))
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := s.begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		s.rollback(tx)
+	}()
+
+	manifest, err := s.getPackageManifest(tx, packageName)
+	if err != nil {
+		return err
+	}
+	if manifest == nil {
+		return registry.ErrPackageNotInDatabase
+	}
+	if _, ok := findChannel(*manifest, channelName); !ok {
+		return fmt.Errorf("channel %s not found in package %s", channelName, packageName)
+	}
+
+	if err := updateDefaultChannel(tx, channelName, packageName); err != nil {
+		return err
+	}
+
+	return s.commit(tx)
+}
+
+func (s *sqlLoader) commitPackage(tx *sql.Tx, manifest registry.PackageManifest) error {
+	if err := s.rebuildPackage(tx, manifest); err != nil {
+		return err
+	}
+	return s.commit(tx)
+}
+
+// checkChannelBundle returns an error unless the named bundle is in the database, in a channel of the package,
+// and not deprecated, so that it can head a channel.
+func (s *sqlLoader) checkChannelBundle(tx *sql.Tx, packageName, bundleName string) error {
+	var count int
+	if err := tx.QueryRow(`SELECT count(*) FROM operatorbundle
+		INNER JOIN channel_entry ON channel_entry.operatorbundle_name = operatorbundle.name
+		WHERE operatorbundle.name = ? AND channel_entry.package_name = ?`, bundleName, packageName).Scan(&count); err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("bundle %s not found in package %s", bundleName, packageName)
+	}
+	deprecated, err := s.deprecated(tx, bundleName)
+	if err != nil {
+		return err
+	}
+	if deprecated {
+		return fmt.Errorf("bundle %s is deprecated", bundleName)
+	}
+	return nil
+}
+
+func (s *sqlLoader) inChannel(tx *sql.Tx, packageName, channelName, bundleName string) (bool, error) {
+	var count int
+	err := tx.QueryRow(`SELECT count(*) FROM channel_entry WHERE package_name = ? AND channel_name = ? AND operatorbundle_name = ?`, packageName, channelName, bundleName).Scan(&count)
+	return count > 0, err
+}
+
+// upgradesFrom returns the names of the bundles the named bundle upgrades from, directly or through the
+// bundles it replaces, by replacing or skipping them.
+func (s *sqlLoader) upgradesFrom(tx *sql.Tx, bundleName string) (map[string]struct{}, error) {
+	visited := map[string]struct{}{}
+	next := []string{bundleName}
+	for len(next) > 0 {
+		bundle := next[0]
+		next = next[1:]
+
+		replaces, skips, _, err := s.getBundleSkipsReplacesVersion(tx, bundle)
+		if err != nil {
+			// skipped bundles need not be in the database
+			continue
+		}
+		if replaces != "" {
+			skips = append(skips, replaces)
+		}
+		for _, upgrade := range skips {
+			if _, ok := visited[upgrade]; ok {
+				continue
+			}
+			visited[upgrade] = struct{}{}
+			next = append(next, upgrade)
+		}
+	}
+	return visited, nil
+}
+
+func findChannel(manifest registry.PackageManifest, name string) (int, bool) {
+	for i, c := range manifest.Channels {
+		if c.Name == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
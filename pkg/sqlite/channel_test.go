@@ -0,0 +1,111 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/pkg/image"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+func TestChannelEdits(t *testing.T) {
+	db, cleanup := CreateTestDb(t)
+	defer cleanup()
+	store, err := NewSQLLiteLoader(db)
+	require.NoError(t, err)
+	require.NoError(t, store.Migrate(context.TODO()))
+	editor := store.(registry.Editor)
+
+	query := NewSQLLiteQuerierFromDb(db)
+
+	graphLoader, err := NewSQLGraphLoaderFromDB(db)
+	require.NoError(t, err)
+
+	// preview: 0.22.2 -> 0.15.0 -> 0.14.0, stable: 0.15.0 -> 0.14.0
+	refs := map[image.Reference]string{}
+	for _, name := range []string{"prometheus.0.14.0", "prometheus.0.15.0", "prometheus.0.22.2"} {
+		refs[image.SimpleReference("quay.io/test/"+name)] = "../../bundles/" + name
+	}
+	require.NoError(t, registry.NewDirectoryPopulator(store, graphLoader, query, refs, nil, false).Populate(registry.ReplacesMode))
+
+	requireChannels := func(defaultChannel string, heads map[string]string) {
+		t.Helper()
+		pkg, err := query.GetPackage(context.TODO(), "prometheus")
+		require.NoError(t, err)
+		require.Equal(t, defaultChannel, pkg.DefaultChannelName)
+		actual := map[string]string{}
+		for _, c := range pkg.Channels {
+			actual[c.Name] = c.CurrentCSVName
+		}
+		require.Equal(t, heads, actual)
+
+		results, err := CheckDB(context.TODO(), db, false)
+		require.NoError(t, err)
+		for _, result := range results {
+			require.False(t, result.Failed(), "check %s: %v", result.Name, result.Violations)
+		}
+	}
+	requireChannels("preview", map[string]string{"preview": "prometheusoperator.0.22.2", "stable": "prometheusoperator.0.15.0"})
+
+	t.Run("AddBundleToChannel", func(t *testing.T) {
+		// promote the head of preview to stable, which it upgrades from
+		require.NoError(t, editor.AddBundleToChannel("prometheus", "stable", "prometheusoperator.0.22.2"))
+		requireChannels("preview", map[string]string{"preview": "prometheusoperator.0.22.2", "stable": "prometheusoperator.0.22.2"})
+		_, err := query.GetBundle(context.TODO(), "prometheus", "stable", "prometheusoperator.0.22.2")
+		require.NoError(t, err)
+
+		// adding a bundle to a channel that doesn't exist creates it
+		require.NoError(t, editor.AddBundleToChannel("prometheus", "alpha", "prometheusoperator.0.14.0"))
+		require.NoError(t, editor.AddBundleToChannel("prometheus", "alpha", "prometheusoperator.0.15.0"))
+
+		require.EqualError(t, editor.AddBundleToChannel("prometheus", "stable", "prometheusoperator.0.14.0"), "bundle prometheusoperator.0.14.0 is already in channel stable")
+		require.EqualError(t, editor.AddBundleToChannel("prometheus", "stable", "missing"), "bundle missing not found in package prometheus")
+		require.Equal(t, registry.ErrPackageNotInDatabase, editor.AddBundleToChannel("missing", "stable", "prometheusoperator.0.15.0"))
+	})
+	requireChannels("preview", map[string]string{"preview": "prometheusoperator.0.22.2", "stable": "prometheusoperator.0.22.2", "alpha": "prometheusoperator.0.15.0"})
+
+	t.Run("RemoveBundleFromChannel", func(t *testing.T) {
+		require.NoError(t, editor.RemoveBundleFromChannel("prometheus", "stable", "prometheusoperator.0.22.2"))
+		requireChannels("preview", map[string]string{"preview": "prometheusoperator.0.22.2", "stable": "prometheusoperator.0.15.0", "alpha": "prometheusoperator.0.15.0"})
+
+		// removing a bundle below the head links the channel around it
+		require.NoError(t, editor.RemoveBundleFromChannel("prometheus", "preview", "prometheusoperator.0.15.0"))
+		requireChannels("preview", map[string]string{"preview": "prometheusoperator.0.22.2", "stable": "prometheusoperator.0.15.0", "alpha": "prometheusoperator.0.15.0"})
+		_, err := query.GetBundle(context.TODO(), "prometheus", "preview", "prometheusoperator.0.15.0")
+		require.Error(t, err)
+		replacement, err := query.GetBundleThatReplaces(context.TODO(), "prometheusoperator.0.14.0", "prometheus", "preview")
+		require.NoError(t, err)
+		require.Equal(t, "prometheusoperator.0.22.2", replacement.CsvName)
+		_, err = query.GetBundle(context.TODO(), "prometheus", "stable", "prometheusoperator.0.15.0")
+		require.NoError(t, err)
+
+		require.EqualError(t, editor.RemoveBundleFromChannel("prometheus", "alpha", "prometheusoperator.0.14.0"), "bundle prometheusoperator.0.15.0, which upgrades from prometheusoperator.0.14.0, is also in channel stable")
+		require.EqualError(t, editor.RemoveBundleFromChannel("prometheus", "preview", "prometheusoperator.0.15.0"), "bundle prometheusoperator.0.15.0 is not in channel preview")
+		require.EqualError(t, editor.RemoveBundleFromChannel("prometheus", "preview", "prometheusoperator.0.22.2"), "bundle prometheusoperator.0.22.2 is in no other channel, remove the bundle instead")
+		require.EqualError(t, editor.RemoveBundleFromChannel("prometheus", "beta", "prometheusoperator.0.22.2"), "channel beta not found in package prometheus")
+
+		require.NoError(t, editor.RemoveBundleFromChannel("prometheus", "alpha", "prometheusoperator.0.15.0"))
+		requireChannels("preview", map[string]string{"preview": "prometheusoperator.0.22.2", "stable": "prometheusoperator.0.15.0", "alpha": "prometheusoperator.0.14.0"})
+		// removing the last bundle of a channel removes the channel
+		require.NoError(t, editor.RemoveBundleFromChannel("prometheus", "alpha", "prometheusoperator.0.14.0"))
+	})
+	requireChannels("preview", map[string]string{"preview": "prometheusoperator.0.22.2", "stable": "prometheusoperator.0.15.0"})
+
+	t.Run("RenameChannel", func(t *testing.T) {
+		require.NoError(t, editor.RenameChannel("prometheus", "stable", "fast"))
+		requireChannels("preview", map[string]string{"preview": "prometheusoperator.0.22.2", "fast": "prometheusoperator.0.15.0"})
+		require.NoError(t, editor.RenameChannel("prometheus", "preview", "candidate"))
+		requireChannels("candidate", map[string]string{"candidate": "prometheusoperator.0.22.2", "fast": "prometheusoperator.0.15.0"})
+
+		require.EqualError(t, editor.RenameChannel("prometheus", "fast", "candidate"), "channel candidate already exists in package prometheus")
+		require.EqualError(t, editor.RenameChannel("prometheus", "stable", "beta"), "channel stable not found in package prometheus")
+	})
+
+	t.Run("UpdateDefaultChannel", func(t *testing.T) {
+		require.NoError(t, editor.UpdateDefaultChannel("prometheus", "fast"))
+		requireChannels("fast", map[string]string{"candidate": "prometheusoperator.0.22.2", "fast": "prometheusoperator.0.15.0"})
+		require.EqualError(t, editor.UpdateDefaultChannel("prometheus", "stable"), "channel stable not found in package prometheus")
+	})
+}
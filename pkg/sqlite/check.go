@@ -0,0 +1,212 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Check is a consistency check of an index database.
+type Check struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+
+	// tables are the tables the check needs. Databases without them, e.g. at an older revision, are not checked.
+	tables []string
+	// query selects a description of each violation.
+	query string
+	// repair fixes every violation, or is empty if violations can't be fixed without losing data the
+	// catalog needs, such as bundles in an upgrade graph.
+	repair string
+}
+
+// CheckResult is the outcome of a check.
+type CheckResult struct {
+	Check
+	// Repairable is set when CheckDB can repair violations of the check.
+	Repairable bool `json:"repairable"`
+	// Skipped is set when the database lacks a table the check needs.
+	Skipped    bool     `json:"skipped,omitempty"`
+	Violations []string `json:"violations,omitempty"`
+	// Repaired is set when the violations were repaired.
+	Repaired bool `json:"repaired,omitempty"`
+}
+
+// Failed reports whether the check found violations that remain in the database.
+func (r CheckResult) Failed() bool {
+	return len(r.Violations) > 0 && !r.Repaired
+}
+
+// orphanedRows returns a repairable check for rows of table referencing a bundle that doesn't exist.
+func orphanedRows(name, table, description string) Check {
+	return Check{
+		Name:        name,
+		Description: description,
+		tables:      []string{table, "operatorbundle"},
+		query: fmt.Sprintf(`SELECT 'bundle ' || quote(operatorbundle_name) FROM %[1]s
+			WHERE NOT EXISTS (SELECT 1 FROM operatorbundle WHERE operatorbundle.name = %[1]s.operatorbundle_name)`, table),
+		repair: fmt.Sprintf(`DELETE FROM %[1]s
+			WHERE NOT EXISTS (SELECT 1 FROM operatorbundle WHERE operatorbundle.name = %[1]s.operatorbundle_name)`, table),
+	}
+}
+
+// Checks returns the consistency checks of an index database at the latest revision, in the order CheckDB runs them.
+func Checks() []Check {
+	return []Check{
+		{
+			Name:        "integrity",
+			Description: "the database file is not corrupted",
+			query:       `SELECT integrity_check FROM pragma_integrity_check WHERE integrity_check != 'ok'`,
+		},
+		{
+			Name:        "package-default-channel",
+			Description: "every package's default channel exists",
+			tables:      []string{"package", "channel"},
+			query: `SELECT 'package ' || quote(name) || ', default channel ' || quote(default_channel) FROM package
+				WHERE NOT EXISTS (SELECT 1 FROM channel WHERE channel.package_name = package.name AND channel.name = package.default_channel)`,
+		},
+		{
+			Name:        "channel-package",
+			Description: "every channel's package exists",
+			tables:      []string{"package", "channel"},
+			query: `SELECT 'package ' || quote(package_name) || ', channel ' || quote(name) FROM channel
+				WHERE NOT EXISTS (SELECT 1 FROM package WHERE package.name = channel.package_name)`,
+		},
+		{
+			Name:        "channel-head",
+			Description: "every channel's head bundle exists",
+			tables:      []string{"channel", "operatorbundle"},
+			query: `SELECT 'package ' || quote(package_name) || ', channel ' || quote(name) || ', head ' || quote(head_operatorbundle_name) FROM channel
+				WHERE NOT EXISTS (SELECT 1 FROM operatorbundle WHERE operatorbundle.name = channel.head_operatorbundle_name)`,
+		},
+		{
+			Name:        "channel-entry-channel",
+			Description: "every channel entry's channel exists",
+			tables:      []string{"channel_entry", "channel"},
+			query: `SELECT 'entry ' || entry_id || ', package ' || quote(package_name) || ', channel ' || quote(channel_name) FROM channel_entry
+				WHERE NOT EXISTS (SELECT 1 FROM channel WHERE channel.name = channel_entry.channel_name AND channel.package_name = channel_entry.package_name)`,
+			// entries of a channel that doesn't exist are never served
+			repair: `DELETE FROM channel_entry
+				WHERE NOT EXISTS (SELECT 1 FROM channel WHERE channel.name = channel_entry.channel_name AND channel.package_name = channel_entry.package_name)`,
+		},
+		{
+			Name:        "channel-entry-bundle",
+			Description: "every channel entry's bundle exists, or is skipped or replaced by one that does",
+			tables:      []string{"channel_entry", "operatorbundle"},
+			// bundles that are skipped or replaced get channel entries whether or not they are in the index
+			query: `SELECT 'entry ' || entry_id || ', package ' || quote(package_name) || ', channel ' || quote(channel_name) || ', bundle ' || quote(operatorbundle_name) FROM channel_entry
+				WHERE NOT EXISTS (SELECT 1 FROM operatorbundle WHERE operatorbundle.name = channel_entry.operatorbundle_name)
+				AND NOT EXISTS (SELECT 1 FROM operatorbundle WHERE operatorbundle.replaces = channel_entry.operatorbundle_name
+					OR instr(',' || operatorbundle.skips || ',', ',' || channel_entry.operatorbundle_name || ',') > 0)`,
+		},
+		{
+			Name:        "channel-entry-replaces",
+			Description: "every channel entry replaces an entry that exists",
+			tables:      []string{"channel_entry"},
+			query: `SELECT 'entry ' || entry_id || ', bundle ' || quote(operatorbundle_name) || ', replaces entry ' || replaces FROM channel_entry
+				WHERE replaces IS NOT NULL AND NOT EXISTS (SELECT 1 FROM channel_entry AS replaced WHERE replaced.entry_id = channel_entry.replaces)`,
+		},
+		orphanedRows("api-provider-bundle", "api_provider", "every API provider's bundle exists"),
+		orphanedRows("api-requirer-bundle", "api_requirer", "every API requirer's bundle exists"),
+		{
+			Name:        "api-provider-api",
+			Description: "every provided API exists",
+			tables:      []string{"api_provider", "api"},
+			query: `SELECT 'bundle ' || quote(operatorbundle_name) || ', api ' || quote(group_name || '/' || version || '/' || kind) FROM api_provider
+				WHERE NOT EXISTS (SELECT 1 FROM api WHERE api.group_name = api_provider.group_name AND api.version = api_provider.version AND api.kind = api_provider.kind)`,
+		},
+		{
+			Name:        "api-referenced",
+			Description: "every API is provided or required by a bundle",
+			tables:      []string{"api", "api_provider", "api_requirer"},
+			query: `SELECT 'api ' || quote(group_name || '/' || version || '/' || kind) FROM api
+				WHERE NOT EXISTS (SELECT 1 FROM api_provider WHERE api.group_name = api_provider.group_name AND api.version = api_provider.version AND api.kind = api_provider.kind)
+				AND NOT EXISTS (SELECT 1 FROM api_requirer WHERE api.group_name = api_requirer.group_name AND api.version = api_requirer.version AND api.kind = api_requirer.kind)`,
+			repair: `DELETE FROM api
+				WHERE NOT EXISTS (SELECT 1 FROM api_provider WHERE api.group_name = api_provider.group_name AND api.version = api_provider.version AND api.kind = api_provider.kind)
+				AND NOT EXISTS (SELECT 1 FROM api_requirer WHERE api.group_name = api_requirer.group_name AND api.version = api_requirer.version AND api.kind = api_requirer.kind)`,
+		},
+		orphanedRows("related-image-bundle", "related_image", "every related image's bundle exists"),
+		orphanedRows("dependency-bundle", "dependencies", "every dependency's bundle exists"),
+		orphanedRows("property-bundle", "properties", "every property's bundle exists"),
+		orphanedRows("deprecated-bundle", "deprecated", "every deprecated bundle exists"),
+		orphanedRows("truncated-bundle", "truncated", "every truncated bundle exists"),
+		orphanedRows("blob-reference-bundle", "operatorbundle_blob", "every blob reference's bundle exists"),
+		{
+			Name:        "blob-reference-blob",
+			Description: "every blob a bundle references exists",
+			tables:      []string{"operatorbundle_blob", "blob"},
+			query: `SELECT 'bundle ' || quote(operatorbundle_name) || ', ' || field || ' ' || position || ', blob ' || quote(blob_digest) FROM operatorbundle_blob
+				WHERE NOT EXISTS (SELECT 1 FROM blob WHERE blob.digest = operatorbundle_blob.blob_digest)`,
+		},
+	}
+}
+
+// CheckDB runs every check in Checks against db. With repair set, the violations of each repairable check
+// are repaired before the next check runs, and the repairs are committed only if every one of them succeeds.
+// A result describes the violations its check found before repairing them.
+func CheckDB(ctx context.Context, db *sql.DB, repair bool) ([]CheckResult, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var results []CheckResult
+	for _, check := range Checks() {
+		result := CheckResult{Check: check, Repairable: check.repair != ""}
+		for _, table := range check.tables {
+			exists, err := checkTableExists(ctx, tx, table)
+			if err != nil {
+				return nil, err
+			}
+			if !exists {
+				result.Skipped = true
+			}
+		}
+		if !result.Skipped {
+			if result.Violations, err = checkViolations(ctx, tx, check.query); err != nil {
+				return nil, fmt.Errorf("check %s: %v", check.Name, err)
+			}
+		}
+		if repair && result.Repairable && len(result.Violations) > 0 {
+			if _, err := tx.ExecContext(ctx, check.repair); err != nil {
+				return nil, fmt.Errorf("repair %s: %v", check.Name, err)
+			}
+			result.Repaired = true
+		}
+		results = append(results, result)
+	}
+
+	if repair {
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+func checkTableExists(ctx context.Context, tx *sql.Tx, table string) (bool, error) {
+	var count int
+	err := tx.QueryRowContext(ctx, `SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&count)
+	return count > 0, err
+}
+
+func checkViolations(ctx context.Context, tx *sql.Tx, query string) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var violations []string
+	for rows.Next() {
+		var violation sql.NullString
+		if err := rows.Scan(&violation); err != nil {
+			return nil, err
+		}
+		violations = append(violations, strings.TrimSpace(violation.String))
+	}
+	return violations, rows.Err()
+}
@@ -0,0 +1,92 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckDB(t *testing.T) {
+	db, cleanup := CreateTestDb(t)
+	defer cleanup()
+	load, err := NewSQLLiteLoader(db)
+	require.NoError(t, err)
+	require.NoError(t, load.Migrate(context.TODO()))
+	require.NoError(t, NewSQLLoaderForDirectory(load, "../../manifests").Populate())
+
+	violations := func(results []CheckResult) map[string]int {
+		counts := map[string]int{}
+		for _, r := range results {
+			require.False(t, r.Skipped, "check %s skipped", r.Name)
+			if len(r.Violations) > 0 {
+				counts[r.Name] = len(r.Violations)
+			}
+		}
+		return counts
+	}
+
+	results, err := CheckDB(context.TODO(), db, false)
+	require.NoError(t, err)
+	require.Len(t, results, len(Checks()))
+	require.Empty(t, violations(results))
+
+	// delete a channel head without cascading to the rows referencing it
+	conn, err := db.Conn(context.TODO())
+	require.NoError(t, err)
+	_, err = conn.ExecContext(context.TODO(), `PRAGMA foreign_keys = 0`)
+	require.NoError(t, err)
+	_, err = conn.ExecContext(context.TODO(), `DELETE FROM operatorbundle WHERE name = 'etcdoperator.v0.9.2'`)
+	require.NoError(t, err)
+	_, err = conn.ExecContext(context.TODO(), `PRAGMA foreign_keys = 1`)
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+
+	results, err = CheckDB(context.TODO(), db, false)
+	require.NoError(t, err)
+	found := violations(results)
+	for _, name := range []string{"channel-head", "channel-entry-bundle", "api-provider-bundle", "api-requirer-bundle", "related-image-bundle", "property-bundle"} {
+		require.Contains(t, found, name)
+	}
+	for _, r := range results {
+		require.False(t, r.Repaired)
+	}
+
+	// checking without repairing changes nothing
+	results, err = CheckDB(context.TODO(), db, false)
+	require.NoError(t, err)
+	require.Equal(t, found, violations(results))
+
+	results, err = CheckDB(context.TODO(), db, true)
+	require.NoError(t, err)
+	require.Equal(t, found, violations(results))
+	for _, r := range results {
+		require.Equal(t, r.Repairable && len(r.Violations) > 0, r.Repaired, "check %s", r.Name)
+	}
+
+	// only the violations that can't be repaired remain
+	results, err = CheckDB(context.TODO(), db, false)
+	require.NoError(t, err)
+	for name := range violations(results) {
+		for _, r := range results {
+			if r.Name == name {
+				require.False(t, r.Repairable, "check %s", name)
+			}
+		}
+	}
+	require.Contains(t, violations(results), "channel-head")
+	require.Contains(t, violations(results), "channel-entry-bundle")
+}
+
+func TestCheckDBSkipsMissingTables(t *testing.T) {
+	db, cleanup := CreateTestDb(t)
+	defer cleanup()
+
+	results, err := CheckDB(context.TODO(), db, false)
+	require.NoError(t, err)
+	for _, r := range results {
+		require.Empty(t, r.Violations)
+		// only the integrity check needs no tables
+		require.Equal(t, r.Name != "integrity", r.Skipped, "check %s", r.Name)
+	}
+}
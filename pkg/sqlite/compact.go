@@ -0,0 +1,32 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Compact removes the blobs that no bundle references any longer, which removing or overwriting bundles leaves
+// behind, and rebuilds the database file to reclaim the space they, and any other removed rows, took up. It returns
+// the number of blobs removed.
+func Compact(ctx context.Context, db *sql.DB) (int64, error) {
+	var removed int64
+	var count int
+	if err := db.QueryRowContext(ctx, `SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name IN ('blob', 'operatorbundle_blob')`).Scan(&count); err != nil {
+		return 0, err
+	}
+	if count == 2 {
+		res, err := db.ExecContext(ctx, `DELETE FROM blob WHERE NOT EXISTS (SELECT 1 FROM operatorbundle_blob WHERE operatorbundle_blob.blob_digest = blob.digest)`)
+		if err != nil {
+			return 0, err
+		}
+		if removed, err = res.RowsAffected(); err != nil {
+			return 0, err
+		}
+	}
+
+	// VACUUM can't run in a transaction
+	if _, err := db.ExecContext(ctx, `VACUUM`); err != nil {
+		return removed, err
+	}
+	return removed, nil
+}
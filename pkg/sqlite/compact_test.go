@@ -0,0 +1,174 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/pkg/registry"
+	"github.com/operator-framework/operator-registry/pkg/sqlite/migrations"
+)
+
+func TestBlobStorageSize(t *testing.T) {
+	for _, tt := range []struct {
+		source string
+		// maxGrowth is how much larger the database may get. The blob tables and their index take a few pages
+		// even when the bundles share no objects.
+		maxGrowth int64
+	}{
+		{
+			// two bundles of different packages
+			source:    "../../internal/action/testdata/foo-index-v0.2.0-sqlite/database/index.db",
+			maxGrowth: 6 * 4096,
+		},
+		{
+			// ten versions of a few operators, which share most of their CRDs
+			source:    "../lib/indexer/testdata/bundles.db",
+			maxGrowth: -500 * 1024,
+		},
+	} {
+		source := tt.source
+		t.Run(source, func(t *testing.T) {
+			data, err := ioutil.ReadFile(source)
+			require.NoError(t, err)
+			path := filepath.Join(t.TempDir(), "index.db")
+			require.NoError(t, ioutil.WriteFile(path, data, 0644))
+
+			db, err := Open(path)
+			require.NoError(t, err)
+			defer db.Close()
+
+			// the content of each bundle, as stored inline before the migration
+			contents := map[string]string{}
+			rows, err := db.Query(`SELECT name, bundle FROM operatorbundle`)
+			require.NoError(t, err)
+			for rows.Next() {
+				var name, bundle sql.NullString
+				require.NoError(t, rows.Scan(&name, &bundle))
+				contents[name.String] = bundle.String
+			}
+			require.NoError(t, rows.Close())
+			require.NotEmpty(t, contents)
+
+			// compare the sizes of the compacted database right before and after moving the content to blobs
+			m, err := NewSQLLiteMigrator(db)
+			require.NoError(t, err)
+			migrator := m.(VersionedMigrator)
+			require.NoError(t, migrator.MigrateTo(context.TODO(), migrations.BlobsMigrationKey-1))
+			_, err = Compact(context.TODO(), db)
+			require.NoError(t, err)
+			before := fileSize(t, path)
+
+			require.NoError(t, migrator.MigrateTo(context.TODO(), migrations.BlobsMigrationKey))
+			_, err = Compact(context.TODO(), db)
+			require.NoError(t, err)
+			after := fileSize(t, path)
+
+			t.Logf("%s: %d bytes with inline content, %d bytes with blobs", source, before, after)
+			require.LessOrEqual(t, after-before, tt.maxGrowth)
+
+			// reads reassemble the content of each bundle exactly
+			querier := NewSQLLiteQuerierFromDb(db)
+			for name, content := range contents {
+				actual, err := querier.bundleContent(context.TODO(), name, sql.NullString{})
+				require.NoError(t, err)
+				require.Equal(t, content, actual.String, name)
+			}
+
+			require.NoError(t, migrator.Migrate(context.TODO()))
+			results, err := CheckDB(context.TODO(), db, false)
+			require.NoError(t, err)
+			for _, result := range results {
+				require.False(t, result.Failed(), "check %s: %v", result.Name, result.Violations)
+			}
+		})
+	}
+}
+
+func TestCompact(t *testing.T) {
+	db, cleanup := CreateTestDb(t)
+	defer cleanup()
+	store, err := NewSQLLiteLoader(db)
+	require.NoError(t, err)
+	require.NoError(t, store.Migrate(context.TODO()))
+
+	loader := NewSQLLoaderForDirectory(store, "../../manifests")
+	require.NoError(t, loader.Populate())
+
+	countBlobs := func() int {
+		var count int
+		require.NoError(t, db.QueryRow(`SELECT count(*) FROM blob`).Scan(&count))
+		return count
+	}
+
+	// nothing to remove
+	removed, err := Compact(context.TODO(), db)
+	require.NoError(t, err)
+	require.Zero(t, removed)
+	blobs := countBlobs()
+
+	// removing a package leaves its blobs behind until the database is compacted
+	require.NoError(t, store.RemovePackage("etcd"))
+	require.Equal(t, blobs, countBlobs())
+
+	removed, err = Compact(context.TODO(), db)
+	require.NoError(t, err)
+	require.NotZero(t, removed)
+	require.Equal(t, blobs-int(removed), countBlobs())
+
+	var unreferenced int
+	require.NoError(t, db.QueryRow(`SELECT count(*) FROM blob WHERE NOT EXISTS (SELECT 1 FROM operatorbundle_blob WHERE blob_digest = blob.digest)`).Scan(&unreferenced))
+	require.Zero(t, unreferenced)
+}
+
+func fileSize(t *testing.T, path string) int64 {
+	t.Helper()
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	return info.Size()
+}
+
+func TestListBundlesFromBlobs(t *testing.T) {
+	db, cleanup := CreateTestDb(t)
+	defer cleanup()
+	store, err := NewSQLLiteLoader(db)
+	require.NoError(t, err)
+	require.NoError(t, store.Migrate(context.TODO()))
+
+	loader := NewSQLLoaderForDirectory(store, "../../manifests")
+	require.NoError(t, loader.Populate())
+
+	// listing must not need a second connection while its rows are open
+	db.SetMaxOpenConns(1)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	querier := NewSQLLiteQuerierFromDb(db)
+
+	bundles, err := querier.ListBundles(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, bundles)
+	for _, b := range bundles {
+		require.NotEmpty(t, b.GetCsvJson(), b.GetCsvName())
+		require.NotEmpty(t, b.GetObject(), b.GetCsvName())
+		expected, err := querier.GetBundle(ctx, b.GetPackageName(), b.GetChannelName(), b.GetCsvName())
+		require.NoError(t, err)
+		require.Equal(t, expected.GetObject(), b.GetObject(), b.GetCsvName())
+		require.ElementsMatch(t, expected.GetProvidedApis(), b.GetProvidedApis(), b.GetCsvName())
+	}
+
+	registryBundles, err := querier.ListRegistryBundles(registry.ContextWithPackage(ctx, "etcd"))
+	require.NoError(t, err)
+	require.NotEmpty(t, registryBundles)
+	for _, b := range registryBundles {
+		require.Equal(t, "etcd", b.Package)
+		csv, err := b.ClusterServiceVersion()
+		require.NoError(t, err)
+		require.Equal(t, b.Name, csv.GetName())
+	}
+}
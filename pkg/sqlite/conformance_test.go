@@ -0,0 +1,111 @@
+package sqlite
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/pkg/registry"
+	"github.com/operator-framework/operator-registry/pkg/registrytest"
+)
+
+func TestGRPCQueryConformance(t *testing.T) {
+	catalog := registrytest.ConformanceCatalog()
+
+	t.Run("Sqlite", func(t *testing.T) {
+		registrytest.TestGRPCQuery(t, catalog, func(t *testing.T, dir string) registry.GRPCQuery {
+			return conformanceDB(t, func(load registry.Load) error {
+				return NewSQLLoaderForDirectory(load, dir).Populate()
+			})
+		})
+	})
+
+	t.Run("ConfigMap", func(t *testing.T) {
+		registrytest.TestGRPCQuery(t, catalog, func(t *testing.T, dir string) registry.GRPCQuery {
+			data := configMapData(t, dir)
+			return conformanceDB(t, func(load registry.Load) error {
+				return NewSQLLoaderForConfigMapData(logrus.NewEntry(logrus.New()), load, data).Populate()
+			})
+		})
+	})
+
+	t.Run("Model", func(t *testing.T) {
+		registrytest.TestGRPCQuery(t, catalog, func(t *testing.T, dir string) registry.GRPCQuery {
+			store := conformanceDB(t, func(load registry.Load) error {
+				return NewSQLLoaderForDirectory(load, dir).Populate()
+			})
+			m, err := ToModel(context.TODO(), store)
+			require.NoError(t, err)
+			return registry.NewQuerier(m)
+		})
+	})
+}
+
+// conformanceDB returns a querier for a database populated by populate.
+func conformanceDB(t *testing.T, populate func(registry.Load) error) *SQLQuerier {
+	tmpDir, err := ioutil.TempDir("", "conformance_test-")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(tmpDir) })
+
+	db, err := Open(filepath.Join(tmpDir, "index.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	load, err := NewSQLLiteLoader(db)
+	require.NoError(t, err)
+	require.NoError(t, load.Migrate(context.TODO()))
+	require.NoError(t, populate(load))
+	return NewSQLLiteQuerierFromDb(db)
+}
+
+// configMapData returns the configmap data for the package manifests in dir. Each CRD is included once,
+// however many bundles own it.
+func configMapData(t *testing.T, dir string) map[string]string {
+	var crds, csvs, packages []interface{}
+	seen := map[string]struct{}{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var obj map[string]interface{}
+		if err := yaml.Unmarshal(data, &obj); err != nil {
+			return err
+		}
+		switch {
+		case strings.HasSuffix(path, ".package.yaml"):
+			packages = append(packages, obj)
+		case obj["kind"] == "ClusterServiceVersion":
+			csvs = append(csvs, obj)
+		case obj["kind"] == "CustomResourceDefinition":
+			name := obj["metadata"].(map[string]interface{})["name"].(string)
+			if _, ok := seen[name]; !ok {
+				seen[name] = struct{}{}
+				crds = append(crds, obj)
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	data := map[string]string{}
+	for key, list := range map[string][]interface{}{
+		ConfigMapCRDName:     crds,
+		ConfigMapCSVName:     csvs,
+		ConfigMapPackageName: packages,
+	} {
+		out, err := yaml.Marshal(list)
+		require.NoError(t, err)
+		data[key] = string(out)
+	}
+	return data
+}
@@ -93,6 +93,7 @@ func populateModelChannels(ctx context.Context, pkgs model.Model, q *SQLQuerier)
 		}
 		mbundle.Package = pkg
 		mbundle.Channel = pkgChannel
+
 		pkgChannel.Bundles[bundle.CsvName] = mbundle
 	}
 	return nil
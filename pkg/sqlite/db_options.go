@@ -1,13 +1,22 @@
 package sqlite
 
 import (
+	"context"
 	"database/sql"
+
+	"github.com/operator-framework/operator-registry/pkg/registry"
 )
 
 type DbOptions struct {
 	// MigratorBuilder is a function that returns a migrator instance
 	MigratorBuilder func(*sql.DB) (Migrator, error)
 	EnableAlpha     bool
+	// Context is the parent of the spans traced around loader transactions, since loader methods take no context
+	Context context.Context
+	// Provenance returns the record to write for a bundle image as the loader adds or deprecates it, if it has one
+	Provenance func(path string) (registry.Provenance, bool)
+	// Tx is a transaction of the caller for the loader to write in, instead of committing each of its calls
+	Tx *sql.Tx
 }
 
 type DbOption func(*DbOptions)
@@ -16,6 +25,7 @@ func defaultDBOptions() *DbOptions {
 	return &DbOptions{
 		MigratorBuilder: NewSQLLiteMigrator,
 		EnableAlpha:     false,
+		Context:         context.Background(),
 	}
 }
 
@@ -30,3 +40,25 @@ func WithEnableAlpha(enableAlpha bool) DbOption {
 		o.EnableAlpha = enableAlpha
 	}
 }
+
+func WithContext(ctx context.Context) DbOption {
+	return func(o *DbOptions) {
+		o.Context = ctx
+	}
+}
+
+// WithProvenance records the provenance returned for a bundle image in the same transaction that adds or
+// deprecates the bundle.
+func WithProvenance(provenance func(path string) (registry.Provenance, bool)) DbOption {
+	return func(o *DbOptions) {
+		o.Provenance = provenance
+	}
+}
+
+// WithTx makes the loader write in a transaction of the caller, which commits or rolls back all of its calls at once.
+// The loader must not be migrated through it.
+func WithTx(tx *sql.Tx) DbOption {
+	return func(o *DbOptions) {
+		o.Tx = tx
+	}
+}
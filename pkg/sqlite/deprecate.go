@@ -45,3 +45,46 @@ func (d *BundleDeprecator) Deprecate() error {
 
 	return utilerrors.NewAggregate(errs)
 }
+
+type SQLUndeprecator interface {
+	Undeprecate() error
+}
+
+// BundleUndeprecator removes the deprecation of bundles in the database
+type BundleUndeprecator struct {
+	store   registry.Load
+	bundles []string
+}
+
+var _ SQLUndeprecator = &BundleUndeprecator{}
+
+func NewSQLUndeprecatorForBundles(store registry.Load, bundles []string) *BundleUndeprecator {
+	return &BundleUndeprecator{
+		store:   store,
+		bundles: bundles,
+	}
+}
+
+func (d *BundleUndeprecator) Undeprecate() error {
+	log := logrus.WithField("bundles", d.bundles)
+	log.Info("undeprecating bundles")
+
+	editor, ok := d.store.(registry.Editor)
+	if !ok {
+		return fmt.Errorf("loader %T can't undeprecate bundles", d.store)
+	}
+
+	var errs []error
+	for _, bundlePath := range d.bundles {
+		err := editor.UndeprecateBundle(bundlePath)
+		if errors.Is(err, registry.ErrBundleNotDeprecated) {
+			log.WithField("bundle", bundlePath).Warn("bundle is not deprecated")
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error undeprecating bundle %s: %s", bundlePath, err))
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
@@ -6,12 +6,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/blang/semver"
 	_ "github.com/mattn/go-sqlite3"
+	digest "github.com/opencontainers/go-digest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 
 	libsemver "github.com/operator-framework/operator-registry/pkg/lib/semver"
+	"github.com/operator-framework/operator-registry/pkg/lib/tracing"
 	"github.com/operator-framework/operator-registry/pkg/registry"
 )
 
@@ -19,6 +24,10 @@ type sqlLoader struct {
 	db          *sql.DB
 	migrator    Migrator
 	enableAlpha bool
+	ctx         context.Context
+	provenance  func(path string) (registry.Provenance, bool)
+	// tx, if set, is the transaction of the caller that the loader writes in
+	tx *sql.Tx
 }
 
 type MigratableLoader interface {
@@ -27,6 +36,7 @@ type MigratableLoader interface {
 }
 
 var _ MigratableLoader = &sqlLoader{}
+var _ registry.Editor = &sqlLoader{}
 
 func NewSQLLiteLoader(db *sql.DB, opts ...DbOption) (MigratableLoader, error) {
 	options := defaultDBOptions()
@@ -43,7 +53,45 @@ func NewSQLLiteLoader(db *sql.DB, opts ...DbOption) (MigratableLoader, error) {
 		return nil, err
 	}
 
-	return &sqlLoader{db: db, migrator: migrator, enableAlpha: options.EnableAlpha}, nil
+	return &sqlLoader{db: db, migrator: migrator, enableAlpha: options.EnableAlpha, ctx: options.Context, provenance: options.Provenance, tx: options.Tx}, nil
+}
+
+// traceTx starts a span for the loader transaction name, as a child of any span in the loader's context.
+func (s *sqlLoader) traceTx(name string, attrs ...attribute.KeyValue) trace.Span {
+	_, span := tracing.Start(s.ctx, "sqlite."+name, attrs...)
+	return span
+}
+
+// begin starts the transaction of a loader call. When the loader writes in a transaction of its caller, the call is a
+// savepoint in it instead, so that a failed call is still rolled back on its own.
+func (s *sqlLoader) begin() (*sql.Tx, error) {
+	if s.tx == nil {
+		return s.db.Begin()
+	}
+	if _, err := s.tx.Exec("SAVEPOINT loader"); err != nil {
+		return nil, err
+	}
+	return s.tx, nil
+}
+
+func (s *sqlLoader) commit(tx *sql.Tx) error {
+	if tx != s.tx {
+		return tx.Commit()
+	}
+	_, err := tx.Exec("RELEASE loader")
+	return err
+}
+
+// rollback rolls back a loader call that hasn't been committed. Like rolling back a committed transaction, rolling
+// back to a released savepoint fails without changing anything.
+func (s *sqlLoader) rollback(tx *sql.Tx) {
+	if tx != s.tx {
+		tx.Rollback()
+		return
+	}
+	if _, err := tx.Exec("ROLLBACK TO loader"); err == nil {
+		tx.Exec("RELEASE loader")
+	}
 }
 
 func (s *sqlLoader) Migrate(ctx context.Context) error {
@@ -53,20 +101,23 @@ func (s *sqlLoader) Migrate(ctx context.Context) error {
 	return s.migrator.Migrate(ctx)
 }
 
-func (s *sqlLoader) AddOperatorBundle(bundle *registry.Bundle) error {
-	tx, err := s.db.Begin()
+func (s *sqlLoader) AddOperatorBundle(bundle *registry.Bundle) (err error) {
+	span := s.traceTx("AddOperatorBundle", attribute.String("bundle", bundle.Name))
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := s.begin()
 	if err != nil {
 		return err
 	}
 	defer func() {
-		tx.Rollback()
+		s.rollback(tx)
 	}()
 
 	if err := s.addOperatorBundle(tx, bundle); err != nil {
 		return err
 	}
 
-	return tx.Commit()
+	return s.commit(tx)
 }
 
 func (s *sqlLoader) addOperatorBundle(tx *sql.Tx, bundle *registry.Bundle) error {
@@ -116,9 +167,13 @@ func (s *sqlLoader) addOperatorBundle(tx *sql.Tx, bundle *registry.Bundle) error
 		return fmt.Errorf("SubstitutesFor is an alpha-only feature. You must enable alpha features with the flag --enable-alpha in order to use this feature.")
 	}
 
-	if _, err := addBundle.Exec(csvName, csvBytes, bundleBytes, bundleImage, version, skiprange, replaces, strings.Join(skips, ","), substitutesFor); err != nil {
+	// the csv and bundle objects are stored as blobs, shared by the bundles that have the same objects
+	if _, err := addBundle.Exec(csvName, nil, nil, bundleImage, version, skiprange, replaces, strings.Join(skips, ","), substitutesFor); err != nil {
 		return fmt.Errorf("failed to add bundle %q: %s", csvName, err.Error())
 	}
+	if err := s.addBundleBlobs(tx, csvName, csvBytes, bundleBytes); err != nil {
+		return fmt.Errorf("failed to add objects of bundle %q: %s", csvName, err.Error())
+	}
 
 	imgs, err := bundle.Images()
 	if err != nil {
@@ -141,6 +196,10 @@ func (s *sqlLoader) addOperatorBundle(tx *sql.Tx, bundle *registry.Bundle) error
 		return err
 	}
 
+	if err := s.addProvenance(tx, csvName, bundleImage); err != nil {
+		return err
+	}
+
 	if s.enableAlpha {
 		err = s.addSubstitutesFor(tx, bundle)
 		if err != nil {
@@ -391,13 +450,16 @@ func (s *sqlLoader) appendSkips(tx *sql.Tx, skips []string, csvName string) erro
 	return err
 }
 
-func (s *sqlLoader) AddPackageChannelsFromGraph(graph *registry.Package) error {
-	tx, err := s.db.Begin()
+func (s *sqlLoader) AddPackageChannelsFromGraph(graph *registry.Package) (err error) {
+	span := s.traceTx("AddPackageChannelsFromGraph", attribute.String("package", graph.Name))
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := s.begin()
 	if err != nil {
 		return err
 	}
 	defer func() {
-		tx.Rollback()
+		s.rollback(tx)
 	}()
 
 	var errs []error
@@ -505,20 +567,23 @@ func (s *sqlLoader) AddPackageChannelsFromGraph(graph *registry.Package) error {
 		}
 	}
 
-	if err := tx.Commit(); err != nil {
+	if err := s.commit(tx); err != nil {
 		errs = append(errs, err)
 	}
 
 	return utilerrors.NewAggregate(errs)
 }
 
-func (s *sqlLoader) AddPackageChannels(manifest registry.PackageManifest) error {
-	tx, err := s.db.Begin()
+func (s *sqlLoader) AddPackageChannels(manifest registry.PackageManifest) (err error) {
+	span := s.traceTx("AddPackageChannels", attribute.String("package", manifest.PackageName))
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := s.begin()
 	if err != nil {
 		return err
 	}
 	defer func() {
-		tx.Rollback()
+		s.rollback(tx)
 	}()
 
 	if err := s.rmPackage(tx, manifest.PackageName); err != nil {
@@ -529,7 +594,7 @@ func (s *sqlLoader) AddPackageChannels(manifest registry.PackageManifest) error
 		return err
 	}
 
-	return tx.Commit()
+	return s.commit(tx)
 }
 
 func (s *sqlLoader) addPackageChannels(tx *sql.Tx, manifest registry.PackageManifest) error {
@@ -713,7 +778,15 @@ func (s *sqlLoader) addPackageChannels(tx *sql.Tx, manifest registry.PackageMani
 				break
 			}
 			if _, _, _, err := s.getBundleSkipsReplacesVersion(tx, replaces); err != nil {
-				errs = append(errs, fmt.Errorf("Invalid bundle %s, replaces nonexistent bundle %s", c.CurrentCSVName, replaces))
+				truncated, _, terr := s.truncated(tx, channelEntryCSVName)
+				if terr != nil {
+					errs = append(errs, terr)
+					break
+				}
+				if !truncated {
+					errs = append(errs, fmt.Errorf("Invalid bundle %s, replaces nonexistent bundle %s", c.CurrentCSVName, replaces))
+				}
+				// otherwise the replaced bundle was truncated by a deprecation and hasn't been added back
 				break
 			}
 
@@ -725,13 +798,16 @@ func (s *sqlLoader) addPackageChannels(tx *sql.Tx, manifest registry.PackageMani
 	return utilerrors.NewAggregate(errs)
 }
 
-func (s *sqlLoader) ClearNonHeadBundles() error {
-	tx, err := s.db.Begin()
+func (s *sqlLoader) ClearNonHeadBundles() (err error) {
+	span := s.traceTx("ClearNonHeadBundles")
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := s.begin()
 	if err != nil {
 		return err
 	}
 	defer func() {
-		tx.Rollback()
+		s.rollback(tx)
 	}()
 
 	removeNonHeadBundles, err := tx.Prepare(`
@@ -751,7 +827,23 @@ func (s *sqlLoader) ClearNonHeadBundles() error {
 	if err != nil {
 		return err
 	}
-	return tx.Commit()
+
+	// the blobs themselves stay until the database is compacted, since other bundles may share them
+	_, err = tx.Exec(`
+		delete from operatorbundle_blob
+		where operatorbundle_name in (
+			select name from operatorbundle
+			where (bundlepath != null or bundlepath != "")
+			and name not in (
+				select operatorbundle.name from operatorbundle
+				join channel on channel.head_operatorbundle_name = operatorbundle.name
+			)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	return s.commit(tx)
 }
 
 func (s *sqlLoader) getBundleSkipsReplacesVersion(tx *sql.Tx, bundleName string) (replaces string, skips []string, version string, err error) {
@@ -924,14 +1016,17 @@ func (s *sqlLoader) getCSVNames(tx *sql.Tx, packageName string) ([]string, error
 	return csvNames, nil
 }
 
-func (s *sqlLoader) RemovePackage(packageName string) error {
+func (s *sqlLoader) RemovePackage(packageName string) (err error) {
+	span := s.traceTx("RemovePackage", attribute.String("package", packageName))
+	defer func() { tracing.End(span, err) }()
+
 	if err := func() error {
-		tx, err := s.db.Begin()
+		tx, err := s.begin()
 		if err != nil {
 			return err
 		}
 		defer func() {
-			tx.Rollback()
+			s.rollback(tx)
 		}()
 
 		csvNames, err := s.getCSVNames(tx, packageName)
@@ -963,7 +1058,7 @@ func (s *sqlLoader) RemovePackage(packageName string) error {
 		if _, err := deleteChannel.Exec(packageName); err != nil {
 			return err
 		}
-		return tx.Commit()
+		return s.commit(tx)
 	}(); err != nil {
 		return err
 	}
@@ -972,6 +1067,251 @@ func (s *sqlLoader) RemovePackage(packageName string) error {
 	return s.RemoveStrandedBundles()
 }
 
+// RemoveBundle removes the bundle with the given image from the database. Bundles that replace it are re-linked
+// to replace the bundle it replaced, and skip it, so that installs of the removed bundle still have an upgrade.
+// Channels headed by the bundle are headed by the bundle it replaced instead or, if it replaced none, by the newest
+// bundle of the channel it skipped, which inherits its other skips. Channels left with no bundle are removed.
+// Removing a bundle is refused if it would remove the default channel of its package.
+func (s *sqlLoader) RemoveBundle(path string) (err error) {
+	span := s.traceTx("RemoveBundle", attribute.String("bundle", path))
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := s.begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		s.rollback(tx)
+	}()
+
+	name, _, err := getBundleNameAndVersionForImage(tx, path)
+	if err != nil {
+		return err
+	}
+	replaces, skips, _, err := s.getBundleSkipsReplacesVersion(tx, name)
+	if err != nil {
+		return err
+	}
+	deprecated, err := s.deprecated(tx, name)
+	if err != nil {
+		return err
+	}
+	if deprecated {
+		// the channels of a deprecated bundle end with it, whatever it replaced or skipped has been removed
+		replaces = ""
+		skips = nil
+	}
+
+	manifest, err := s.getPackageManifestForBundle(tx, name)
+	if err != nil {
+		return err
+	}
+	if manifest != nil {
+		channels, err := s.channelsWithoutHead(tx, *manifest, name, replaces, skips)
+		if err != nil {
+			return err
+		}
+		manifest.Channels = channels
+	}
+
+	if err := s.relinkReplaces(tx, name, replaces, skips); err != nil {
+		return err
+	}
+	if err := s.rmBundle(tx, name); err != nil {
+		return err
+	}
+	for _, table := range []string{"properties", "related_image", "dependencies", "deprecated", "truncated"} {
+		if _, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE operatorbundle_name = ?", table), name); err != nil {
+			return err
+		}
+	}
+	if err := s.rmUnreferencedAPIs(tx); err != nil {
+		return err
+	}
+
+	if manifest != nil {
+		// recalculate the channel entries of the package around the removed bundle
+		if err := s.rebuildPackage(tx, *manifest); err != nil {
+			return err
+		}
+	}
+
+	return s.commit(tx)
+}
+
+// getPackageManifestForBundle returns the package manifest of the package with a channel containing the named
+// bundle, or nil if the bundle is in no channel.
+func (s *sqlLoader) getPackageManifestForBundle(tx *sql.Tx, name string) (*registry.PackageManifest, error) {
+	var pkg string
+	err := tx.QueryRow(`SELECT package_name FROM channel_entry WHERE operatorbundle_name = ? LIMIT 1`, name).Scan(&pkg)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.getPackageManifest(tx, pkg)
+}
+
+// getPackageManifest returns the package manifest of the named package, with the current heads of its channels,
+// or nil if there is no such package.
+func (s *sqlLoader) getPackageManifest(tx *sql.Tx, pkg string) (*registry.PackageManifest, error) {
+	manifest := &registry.PackageManifest{PackageName: pkg}
+	var defaultChannel sql.NullString
+	err := tx.QueryRow(`SELECT default_channel FROM package WHERE name = ?`, pkg).Scan(&defaultChannel)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	manifest.DefaultChannelName = defaultChannel.String
+
+	rows, err := tx.Query(`SELECT name, head_operatorbundle_name FROM channel WHERE package_name = ?`, pkg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var channel, head sql.NullString
+		if err := rows.Scan(&channel, &head); err != nil {
+			return nil, err
+		}
+		manifest.Channels = append(manifest.Channels, registry.PackageChannel{Name: channel.String, CurrentCSVName: head.String})
+	}
+	return manifest, rows.Err()
+}
+
+// rebuildPackage recalculates the channels and channel entries of a package from manifest.
+func (s *sqlLoader) rebuildPackage(tx *sql.Tx, manifest registry.PackageManifest) error {
+	if err := s.rmPackage(tx, manifest.PackageName); err != nil {
+		return err
+	}
+	return s.addPackageChannels(tx, manifest)
+}
+
+// channelsWithoutHead returns the channels of manifest with the channels headed by the named bundle headed by
+// replaces instead. If replaces is empty or deprecated, such a channel is headed by the newest bundle in it that
+// the named bundle skips, which is made to skip the rest, or removed if there is none.
+func (s *sqlLoader) channelsWithoutHead(tx *sql.Tx, manifest registry.PackageManifest, name, replaces string, skips []string) ([]registry.PackageChannel, error) {
+	headless := replaces == ""
+	if !headless {
+		deprecated, err := s.deprecated(tx, replaces)
+		if err != nil {
+			return nil, err
+		}
+		// channels headed by a deprecated bundle are elided
+		headless = deprecated
+	}
+
+	var channels []registry.PackageChannel
+	for _, c := range manifest.Channels {
+		if c.CurrentCSVName != name {
+			channels = append(channels, c)
+			continue
+		}
+		if !headless {
+			c.CurrentCSVName = replaces
+			channels = append(channels, c)
+			continue
+		}
+		head, err := s.newestSkipped(tx, manifest.PackageName, c.Name, skips)
+		if err != nil {
+			return nil, err
+		}
+		if head == "" {
+			if c.IsDefaultChannel(manifest) {
+				return nil, fmt.Errorf("%w: %s is the only bundle left in default channel %s of package %s", registry.ErrRemovingDefaultChannelDuringBundleRemoval, name, c.Name, manifest.PackageName)
+			}
+			continue
+		}
+		// the other bundles the removed head skipped stay reachable from the new head
+		var inherited []string
+		for _, skip := range skips {
+			if skip != head {
+				inherited = append(inherited, skip)
+			}
+		}
+		if err := s.appendSkips(tx, inherited, head); err != nil {
+			return nil, err
+		}
+		c.CurrentCSVName = head
+		channels = append(channels, c)
+	}
+	return channels, nil
+}
+
+// newestSkipped returns the bundle of skips with the highest version that is in the given channel and isn't
+// deprecated, or an empty string if there is none.
+func (s *sqlLoader) newestSkipped(tx *sql.Tx, pkg, channel string, skips []string) (string, error) {
+	var newest string
+	var newestVersion semver.Version
+	for _, skip := range skips {
+		var version sql.NullString
+		err := tx.QueryRow(`SELECT operatorbundle.version FROM channel_entry
+			INNER JOIN operatorbundle ON channel_entry.operatorbundle_name = operatorbundle.name
+			WHERE channel_entry.package_name = ? AND channel_entry.channel_name = ? AND channel_entry.operatorbundle_name = ?
+			LIMIT 1`, pkg, channel, skip).Scan(&version)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		deprecated, err := s.deprecated(tx, skip)
+		if err != nil {
+			return "", err
+		}
+		if deprecated {
+			continue
+		}
+		// bundles without a valid version sort below the rest
+		v, _ := semver.Parse(version.String)
+		if newest == "" || v.GT(newestVersion) {
+			newest, newestVersion = skip, v
+		}
+	}
+	return newest, nil
+}
+
+// relinkReplaces makes the bundles that replace the named bundle replace its replacement instead, and skip
+// the named bundle and the bundles it skips.
+func (s *sqlLoader) relinkReplaces(tx *sql.Tx, name, replaces string, skips []string) error {
+	rows, err := tx.Query(`SELECT name FROM operatorbundle WHERE replaces = ?`, name)
+	if err != nil {
+		return err
+	}
+	var replacedBy []string
+	for rows.Next() {
+		var bundle sql.NullString
+		if err := rows.Scan(&bundle); err != nil {
+			rows.Close()
+			return err
+		}
+		replacedBy = append(replacedBy, bundle.String)
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+
+	for _, bundle := range replacedBy {
+		if _, err := tx.Exec(`UPDATE operatorbundle SET replaces = ? WHERE name = ?`, replaces, bundle); err != nil {
+			return err
+		}
+		if err := s.appendSkips(tx, append([]string{name}, skips...), bundle); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *sqlLoader) rmUnreferencedAPIs(tx *sql.Tx) error {
+	_, err := tx.Exec(`DELETE FROM api
+		WHERE NOT EXISTS (SELECT 1 FROM api_provider WHERE api.group_name = api_provider.group_name AND api.version = api_provider.version AND api.kind = api_provider.kind)
+		AND NOT EXISTS (SELECT 1 FROM api_requirer WHERE api.group_name = api_requirer.group_name AND api.version = api_requirer.version AND api.kind = api_requirer.kind)`)
+	return err
+}
+
 func (s *sqlLoader) rmBundle(tx *sql.Tx, csvName string) error {
 	deleteBundle, err := tx.Prepare("DELETE FROM operatorbundle WHERE operatorbundle.name=?")
 	if err != nil {
@@ -1030,13 +1370,16 @@ func (s *sqlLoader) AddBundleSemver(graph *registry.Package, bundle *registry.Bu
 	return nil
 }
 
-func (s *sqlLoader) AddBundlePackageChannels(manifest registry.PackageManifest, bundle *registry.Bundle) error {
-	tx, err := s.db.Begin()
+func (s *sqlLoader) AddBundlePackageChannels(manifest registry.PackageManifest, bundle *registry.Bundle) (err error) {
+	span := s.traceTx("AddBundlePackageChannels", attribute.String("package", manifest.PackageName), attribute.String("bundle", bundle.Name))
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := s.begin()
 	if err != nil {
 		return err
 	}
 	defer func() {
-		tx.Rollback()
+		s.rollback(tx)
 	}()
 
 	if err := s.addOperatorBundle(tx, bundle); err != nil {
@@ -1051,7 +1394,7 @@ func (s *sqlLoader) AddBundlePackageChannels(manifest registry.PackageManifest,
 		return err
 	}
 
-	return tx.Commit()
+	return s.commit(tx)
 }
 
 func (s *sqlLoader) rmPackage(tx *sql.Tx, pkg string) error {
@@ -1134,6 +1477,51 @@ func (s *sqlLoader) addDependencies(tx *sql.Tx, bundle *registry.Bundle) error {
 	return nil
 }
 
+// addBundleBlobs stores the csv and the objects of a bundle as blobs, keyed by the digest of their content, and
+// references them from the bundle in the order they are in.
+func (s *sqlLoader) addBundleBlobs(tx *sql.Tx, bundleName string, csvBytes, bundleBytes []byte) error {
+	addBlob, err := tx.Prepare("insert or ignore into blob(digest, content) values(?, ?)")
+	if err != nil {
+		return err
+	}
+	defer addBlob.Close()
+
+	addBundleBlob, err := tx.Prepare("insert into operatorbundle_blob(operatorbundle_name, field, position, blob_digest) values(?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer addBundleBlob.Close()
+
+	add := func(field string, position int, content string) error {
+		dgst := digest.FromString(content).String()
+		if _, err := addBlob.Exec(dgst, content); err != nil {
+			return err
+		}
+		_, err := addBundleBlob.Exec(bundleName, field, position, dgst)
+		return err
+	}
+
+	// clear references left behind by a bundle with the same name, if foreign keys weren't enforced when it was removed
+	if _, err := tx.Exec("delete from operatorbundle_blob where operatorbundle_name = ?", bundleName); err != nil {
+		return err
+	}
+	if len(csvBytes) > 0 {
+		if err := add("csv", 0, strings.TrimSpace(string(csvBytes))); err != nil {
+			return err
+		}
+	}
+	objs, err := registry.BundleStringToObjectStrings(string(bundleBytes))
+	if err != nil {
+		return err
+	}
+	for i, obj := range objs {
+		if err := add("bundle", i, obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (s *sqlLoader) addProperty(tx *sql.Tx, propType, value, bundleName, version, path string) error {
 	addProp, err := tx.Prepare("insert into properties(type, value, operatorbundle_name, operatorbundle_version, operatorbundle_path) values(?, ?, ?, ?, ?)")
 	if err != nil {
@@ -1413,13 +1801,16 @@ func getBundleNameAndVersionForImage(tx *sql.Tx, path string) (string, string, e
 	return "", "", registry.ErrBundleImageNotInDatabase
 }
 
-func (s *sqlLoader) DeprecateBundle(path string) error {
-	tx, err := s.db.Begin()
+func (s *sqlLoader) DeprecateBundle(path string) (err error) {
+	span := s.traceTx("DeprecateBundle", attribute.String("bundle", path))
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := s.begin()
 	if err != nil {
 		return err
 	}
 	defer func() {
-		tx.Rollback()
+		s.rollback(tx)
 	}()
 
 	name, version, err := getBundleNameAndVersionForImage(tx, path)
@@ -1440,6 +1831,16 @@ func (s *sqlLoader) DeprecateBundle(path string) error {
 		}
 	}
 
+	if err := s.addProvenance(tx, name, path); err != nil {
+		return err
+	}
+
+	// Record the channels that start with the deprecated bundle and that the channels are truncated below it, so that
+	// undeprecating it can restore them
+	if err := s.addTruncated(tx, name); err != nil {
+		return err
+	}
+
 	// Remove any channels that start with the deprecated bundle
 	_, err = tx.Exec(fmt.Sprintf(`DELETE FROM channel WHERE head_operatorbundle_name="%s"`, name))
 	if err != nil {
@@ -1462,23 +1863,196 @@ func (s *sqlLoader) DeprecateBundle(path string) error {
 		return err
 	}
 
-	return tx.Commit()
+	return s.commit(tx)
 }
 
-func (s *sqlLoader) RemoveStrandedBundles() error {
-	tx, err := s.db.Begin()
+// UndeprecateBundle removes the deprecation of the bundle with the given image and recalculates the channels of
+// its package, restoring the channels it headed when it was deprecated. The bundle keeps replacing the bundle it
+// replaced: if that was truncated along with the deprecation and has been added back, the bundle replaces it again;
+// otherwise the bundle remains the tail of its channels.
+func (s *sqlLoader) UndeprecateBundle(path string) (err error) {
+	span := s.traceTx("UndeprecateBundle", attribute.String("bundle", path))
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := s.begin()
 	if err != nil {
 		return err
 	}
 	defer func() {
-		tx.Rollback()
+		s.rollback(tx)
+	}()
+
+	name, _, err := getBundleNameAndVersionForImage(tx, path)
+	if err != nil {
+		return err
+	}
+	deprecated, err := s.deprecated(tx, name)
+	if err != nil {
+		return err
+	}
+	if !deprecated {
+		return registry.ErrBundleNotDeprecated
+	}
+
+	if _, err := tx.Exec(`DELETE FROM deprecated WHERE operatorbundle_name = ?`, name); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM properties WHERE type = ? AND operatorbundle_name = ?`, registry.DeprecatedType, name); err != nil {
+		return err
+	}
+
+	_, headed, err := s.truncated(tx, name)
+	if err != nil {
+		return err
+	}
+	// the channels are restored now, the record that the bundle is truncated stays for as long as it is the tail
+	if _, err := tx.Exec(`UPDATE truncated SET channels = NULL WHERE operatorbundle_name = ?`, name); err != nil {
+		return err
+	}
+
+	pkg, err := s.getPackageForBundle(tx, name)
+	if err != nil {
+		return err
+	}
+	if pkg == "" {
+		return s.commit(tx)
+	}
+	manifest, err := s.getPackageManifest(tx, pkg)
+	if err != nil {
+		return err
+	}
+	if manifest == nil {
+		return s.commit(tx)
+	}
+	existing := map[string]struct{}{}
+	for _, c := range manifest.Channels {
+		existing[c.Name] = struct{}{}
+	}
+	for _, c := range headed {
+		if _, ok := existing[c]; !ok {
+			manifest.Channels = append(manifest.Channels, registry.PackageChannel{Name: c, CurrentCSVName: name})
+		}
+	}
+	if err := s.rebuildPackage(tx, *manifest); err != nil {
+		return err
+	}
+
+	return s.commit(tx)
+}
+
+// addTruncated records that the channels of the named bundle are truncated below it, along with the channels it heads.
+func (s *sqlLoader) addTruncated(tx *sql.Tx, name string) error {
+	rows, err := tx.Query(`SELECT name FROM channel WHERE head_operatorbundle_name = ?`, name)
+	if err != nil {
+		return err
+	}
+	var channels []string
+	seen := map[string]struct{}{}
+	for rows.Next() {
+		var channel sql.NullString
+		if err := rows.Scan(&channel); err != nil {
+			rows.Close()
+			return err
+		}
+		channels = append(channels, channel.String)
+		seen[channel.String] = struct{}{}
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+
+	// keep the channels recorded by an earlier deprecation that hasn't been undone
+	_, recorded, err := s.truncated(tx, name)
+	if err != nil {
+		return err
+	}
+	for _, c := range recorded {
+		if _, ok := seen[c]; !ok {
+			channels = append(channels, c)
+		}
+	}
+
+	var value sql.NullString
+	if len(channels) > 0 {
+		value = sql.NullString{String: strings.Join(channels, ","), Valid: true}
+	}
+	_, err = tx.Exec(`INSERT OR REPLACE INTO truncated(operatorbundle_name, channels) VALUES(?, ?)`, name, value)
+	return err
+}
+
+// truncated returns whether the channels of the named bundle were truncated below it by a deprecation, and the
+// channels it headed when it was deprecated, if it still is.
+func (s *sqlLoader) truncated(tx *sql.Tx, name string) (bool, []string, error) {
+	var channels sql.NullString
+	err := tx.QueryRow(`SELECT channels FROM truncated WHERE operatorbundle_name = ?`, name).Scan(&channels)
+	if err == sql.ErrNoRows {
+		return false, nil, nil
+	}
+	if err != nil {
+		return false, nil, err
+	}
+	if channels.String == "" {
+		return true, nil, nil
+	}
+	return true, strings.Split(channels.String, ","), nil
+}
+
+// getPackageForBundle returns the name of the package of the named bundle, or an empty string if it has no package
+// property.
+func (s *sqlLoader) getPackageForBundle(tx *sql.Tx, name string) (string, error) {
+	var value sql.NullString
+	err := tx.QueryRow(`SELECT value FROM properties WHERE type = ? AND operatorbundle_name = ? LIMIT 1`, registry.PackageType, name).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	var prop registry.PackageProperty
+	if err := json.Unmarshal([]byte(value.String), &prop); err != nil {
+		return "", err
+	}
+	return prop.PackageName, nil
+}
+
+// addProvenance records the provenance of the bundle with the given name and bundle path, if the loader has a record
+// for the path.
+func (s *sqlLoader) addProvenance(tx *sql.Tx, name, path string) error {
+	if s.provenance == nil || path == "" {
+		return nil
+	}
+	provenance, ok := s.provenance(path)
+	if !ok {
+		return nil
+	}
+
+	sqlString := func(s string) sql.NullString {
+		return sql.NullString{String: s, Valid: s != ""}
+	}
+	if _, err := tx.Exec(`INSERT INTO provenance(operatorbundle_name, added_at, source_digest, opm_version, operation) VALUES (?, ?, ?, ?, ?)`,
+		name, provenance.AddedAt.UTC().Format(time.RFC3339), sqlString(provenance.SourceDigest), sqlString(provenance.OpmVersion), string(provenance.Operation)); err != nil {
+		return fmt.Errorf("failed to add provenance of bundle %q: %s", name, err.Error())
+	}
+	return nil
+}
+
+func (s *sqlLoader) RemoveStrandedBundles() (err error) {
+	span := s.traceTx("RemoveStrandedBundles")
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := s.begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		s.rollback(tx)
 	}()
 
 	if err := s.rmStrandedBundles(tx); err != nil {
 		return err
 	}
 
-	return tx.Commit()
+	return s.commit(tx)
 }
 
 func (s *sqlLoader) rmStrandedBundles(tx *sql.Tx) error {
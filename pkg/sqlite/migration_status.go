@@ -0,0 +1,190 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// MigrationStatus describes a migration and whether it has been applied to a database.
+type MigrationStatus struct {
+	Id      int
+	Applied bool
+	// DownErr is why the migration can't be reverted, or nil if it can.
+	DownErr error
+}
+
+// Status returns the status of every known migration, in order. Migrations that haven't been applied are pending.
+//
+// Each migration's Down is checked on a copy of the database, which is migrated to the latest version and then
+// down one migration at a time, the way the database would be downgraded, in a transaction that is rolled back.
+// A Down fails the check if it returns an error or leaves foreign keys violated or referencing tables they no
+// longer match. Downs can't be checked past a failing one, since a downgrade stops there, so they fail too.
+func (m *SQLLiteMigrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	version, err := m.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+	downErrs, err := m.checkDowns(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []MigrationStatus
+	for _, migration := range m.migrations.From(0) {
+		statuses = append(statuses, MigrationStatus{
+			Id:      migration.Id,
+			Applied: migration.Id <= version,
+			DownErr: downErrs[migration.Id],
+		})
+	}
+	return statuses, nil
+}
+
+// checkDowns migrates a copy of the database from version to the latest version and then reverts each migration,
+// newest first, in a single transaction that is rolled back. It returns the errors of the Downs that fail, or can't
+// be checked, by migration id.
+func (m *SQLLiteMigrator) checkDowns(ctx context.Context, version int) (map[int]error, error) {
+	scratch, err := copyDB(ctx, m.db)
+	if err != nil {
+		return nil, fmt.Errorf("copy database: %v", err)
+	}
+	defer scratch.close()
+
+	tx, err := scratch.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	errs := map[int]error{}
+	all := m.migrations.From(0)
+	var failed error
+	for _, migration := range all {
+		if migration.Id <= version {
+			continue
+		}
+		if failed == nil {
+			if err := migration.Up(ctx, tx); err != nil {
+				failed = fmt.Errorf("migration %d failed to apply: %v", migration.Id, err)
+			}
+		}
+		if failed != nil {
+			errs[migration.Id] = failed
+		}
+	}
+	for i := len(all) - 1; i >= 0; i-- {
+		migration := all[i]
+		if _, ok := errs[migration.Id]; ok {
+			continue
+		}
+		if failed != nil {
+			errs[migration.Id] = failed
+			continue
+		}
+		var downErr error
+		if migration.Down == nil {
+			downErr = fmt.Errorf("migration has no down")
+		} else if downErr = migration.Down(ctx, tx); downErr == nil {
+			downErr = checkForeignKeys(ctx, tx)
+		}
+		if downErr != nil {
+			errs[migration.Id] = downErr
+			failed = fmt.Errorf("not checked, migration %d can't be reverted", migration.Id)
+		}
+	}
+	return errs, nil
+}
+
+type scratchDB struct {
+	db   *sql.DB
+	path string
+}
+
+// copyDB returns a copy of db in a temporary file.
+func copyDB(ctx context.Context, db *sql.DB) (*scratchDB, error) {
+	f, err := ioutil.TempFile(os.TempDir(), "migrate-status-*.db")
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+
+	// enforce foreign keys like Open, since some Downs only fail when they are
+	scratch := &scratchDB{path: f.Name()}
+	if scratch.db, err = Open(scratch.path); err != nil {
+		os.Remove(scratch.path)
+		return nil, err
+	}
+	if err := backup(ctx, scratch.db, db); err != nil {
+		scratch.close()
+		return nil, err
+	}
+	return scratch, nil
+}
+
+// backup copies the main database of src into dst with sqlite's online backup API, which copies in-memory and
+// file databases alike.
+func backup(ctx context.Context, dst, src *sql.DB) error {
+	dstConn, err := dst.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer dstConn.Close()
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+
+	return dstConn.Raw(func(dstDriverConn interface{}) error {
+		return srcConn.Raw(func(srcDriverConn interface{}) error {
+			to, ok := dstDriverConn.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("unexpected connection type %T", dstDriverConn)
+			}
+			from, ok := srcDriverConn.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("unexpected connection type %T", srcDriverConn)
+			}
+			b, err := to.Backup("main", from, "main")
+			if err != nil {
+				return err
+			}
+			if _, err := b.Step(-1); err != nil {
+				b.Close()
+				return err
+			}
+			return b.Finish()
+		})
+	})
+}
+
+func (s *scratchDB) close() {
+	s.db.Close()
+	os.Remove(s.path)
+}
+
+// checkForeignKeys returns an error if any foreign key in tx is violated or references columns that
+// are not a primary key or unique in their table.
+func checkForeignKeys(ctx context.Context, tx *sql.Tx) error {
+	rows, err := tx.QueryContext(ctx, "PRAGMA foreign_key_check")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if rows.Next() {
+		var table string
+		var rowid sql.NullInt64
+		var parent string
+		var fkid int
+		if err := rows.Scan(&table, &rowid, &parent, &fkid); err != nil {
+			return err
+		}
+		return fmt.Errorf("foreign key violation: %q referencing %q", table, parent)
+	}
+	return rows.Err()
+}
@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+)
+
+const PropertyValuesMigrationKey = 13
+
+// Register this migration
+func init() {
+	registerMigration(PropertyValuesMigrationKey, propertyValuesMigration)
+}
+
+var propertyValuesMigration = &Migration{
+	Id: PropertyValuesMigrationKey,
+	Up: func(ctx context.Context, tx *sql.Tx) error {
+		// The properties migration stored the values it generated as blobs, which never compare equal
+		// to the text values the loader stores and queries by
+		_, err := tx.ExecContext(ctx, `UPDATE properties SET value = CAST(value AS TEXT) WHERE typeof(value) = 'blob'`)
+
+		return err
+	},
+	Down: func(ctx context.Context, tx *sql.Tx) error {
+		// Text values are what the loader stores at every version, so there is nothing to undo
+		return nil
+	},
+}
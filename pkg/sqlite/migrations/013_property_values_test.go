@@ -0,0 +1,45 @@
+package migrations_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/pkg/sqlite/migrations"
+)
+
+func TestPropertyValues(t *testing.T) {
+	db, migrator, cleanup := CreateTestDbAt(t, migrations.PropertyValuesMigrationKey-1)
+	defer cleanup()
+
+	_, err := db.Exec(`PRAGMA foreign_keys = 0`)
+	require.NoError(t, err)
+
+	// The properties migration stored the values it generated as blobs, while the loader stores text
+	gvk := `{"group":"test.coreos.com","kind":"testapi","version":"v1"}`
+	insertProperty := "INSERT INTO properties(type, value, operatorbundle_name, operatorbundle_version, operatorbundle_path) VALUES (?, ?, ?, ?, ?)"
+	_, err = db.Exec(insertProperty, "olm.gvk", []byte(gvk), "etcdoperator.v0.6.1", "0.6.1", "quay.io/image")
+	require.NoError(t, err)
+	_, err = db.Exec(insertProperty, "olm.gvk", gvk, "etcdoperator.v0.9.0", "0.9.0", "quay.io/image")
+	require.NoError(t, err)
+
+	matching := func() int {
+		var count int
+		require.NoError(t, db.QueryRow("SELECT count(*) FROM properties WHERE value = ?", gvk).Scan(&count))
+		return count
+	}
+	require.Equal(t, 1, matching())
+
+	// This migration should convert the blob values to text
+	require.NoError(t, migrator.Up(context.Background(), migrations.Only(migrations.PropertyValuesMigrationKey)))
+	require.Equal(t, 2, matching())
+
+	var blobs int
+	require.NoError(t, db.QueryRow("SELECT count(*) FROM properties WHERE typeof(value) = 'blob'").Scan(&blobs))
+	require.Zero(t, blobs)
+
+	// This migration should leave the text values in place on downgrade
+	require.NoError(t, migrator.Down(context.Background(), migrations.Only(migrations.PropertyValuesMigrationKey)))
+	require.Equal(t, 2, matching())
+}
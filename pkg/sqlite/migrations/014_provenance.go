@@ -0,0 +1,39 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+)
+
+const ProvenanceMigrationKey = 14
+
+// Register this migration
+func init() {
+	registerMigration(ProvenanceMigrationKey, provenanceMigration)
+}
+
+var provenanceMigration = &Migration{
+	Id: ProvenanceMigrationKey,
+	Up: func(ctx context.Context, tx *sql.Tx) error {
+		// Like the deprecated table, forego a foreign key constraint so the history of a bundle survives
+		// operations that drop and readd it, e.g. overwriting the latest bundle of a package
+		sql := `
+		CREATE TABLE IF NOT EXISTS provenance (
+			operatorbundle_name TEXT NOT NULL,
+			added_at TEXT NOT NULL,
+			source_digest TEXT,
+			opm_version TEXT,
+			operation TEXT NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS provenance_operatorbundle_name ON provenance(operatorbundle_name);
+		`
+		_, err := tx.ExecContext(ctx, sql)
+
+		return err
+	},
+	Down: func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `DROP TABLE provenance`)
+
+		return err
+	},
+}
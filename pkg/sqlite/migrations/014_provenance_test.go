@@ -0,0 +1,37 @@
+package migrations_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/pkg/sqlite/migrations"
+)
+
+func TestProvenance(t *testing.T) {
+	db, migrator, cleanup := CreateTestDbAt(t, migrations.ProvenanceMigrationKey-1)
+	defer cleanup()
+
+	// This migration should create the provenance table
+	require.NoError(t, migrator.Up(context.Background(), migrations.Only(migrations.ProvenanceMigrationKey)))
+
+	insertProvenance := "INSERT INTO provenance(operatorbundle_name, added_at, source_digest, opm_version, operation) VALUES (?, ?, ?, ?, ?)"
+	_, err := db.Exec(insertProvenance, "operator.v1.0.0", "2021-01-01T00:00:00Z", "sha256:abc", "v1.17.0", "add")
+	require.NoError(t, err)
+	// Bundles need not be in the database to have provenance records
+	_, err = db.Exec(insertProvenance, "operator.v1.0.0", "2021-01-02T00:00:00Z", nil, nil, "overwrite")
+	require.NoError(t, err)
+
+	var count int
+	require.NoError(t, db.QueryRow("SELECT count(*) FROM provenance WHERE operatorbundle_name = ?", "operator.v1.0.0").Scan(&count))
+	require.Equal(t, 2, count)
+
+	// This migration should drop the provenance table
+	require.NoError(t, migrator.Down(context.Background(), migrations.Only(migrations.ProvenanceMigrationKey)))
+
+	table, err := db.Query("SELECT name FROM sqlite_master WHERE type='table' AND name='provenance'")
+	require.NoError(t, err)
+	defer table.Close()
+	require.False(t, table.Next(), "provenance table wasn't properly cleaned up on downgrade")
+}
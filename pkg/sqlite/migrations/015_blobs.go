@@ -0,0 +1,119 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	digest "github.com/opencontainers/go-digest"
+
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+const BlobsMigrationKey = 15
+
+// Register this migration
+func init() {
+	registerMigration(BlobsMigrationKey, blobsMigration)
+}
+
+// The csv and bundle columns of operatorbundle repeat the same objects across bundles: every bundle's csv is also
+// one of its objects, and CRDs rarely change between versions of an operator. This migration moves them into the
+// blob table, which stores each object once, keyed by its digest, and which operatorbundle_blob references in the
+// order they were in. The csv field has a single blob at position 0, and the bundle field has one per object.
+var blobsMigration = &Migration{
+	Id: BlobsMigrationKey,
+	Up: func(ctx context.Context, tx *sql.Tx) error {
+		createTables := `
+		CREATE TABLE IF NOT EXISTS blob (
+			digest TEXT PRIMARY KEY,
+			content TEXT NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS operatorbundle_blob (
+			operatorbundle_name TEXT NOT NULL,
+			field TEXT NOT NULL,
+			position INTEGER NOT NULL,
+			blob_digest TEXT NOT NULL,
+			PRIMARY KEY(operatorbundle_name, field, position),
+			FOREIGN KEY(operatorbundle_name) REFERENCES operatorbundle(name) ON DELETE CASCADE,
+			FOREIGN KEY(blob_digest) REFERENCES blob(digest)
+		);
+		CREATE INDEX IF NOT EXISTS operatorbundle_blob_digest ON operatorbundle_blob(blob_digest);
+		`
+		if _, err := tx.ExecContext(ctx, createTables); err != nil {
+			return err
+		}
+
+		type content struct {
+			name, csv, bundle string
+		}
+		rows, err := tx.QueryContext(ctx, `SELECT name, csv, bundle FROM operatorbundle WHERE csv IS NOT NULL OR bundle IS NOT NULL`)
+		if err != nil {
+			return err
+		}
+		var contents []content
+		for rows.Next() {
+			var name, csv, bundle sql.NullString
+			if err := rows.Scan(&name, &csv, &bundle); err != nil {
+				rows.Close()
+				return err
+			}
+			contents = append(contents, content{name: name.String, csv: csv.String, bundle: bundle.String})
+		}
+		if err := rows.Close(); err != nil {
+			return err
+		}
+
+		for _, c := range contents {
+			var objs []string
+			if c.bundle != "" {
+				if objs, err = registry.BundleStringToObjectStrings(c.bundle); err != nil {
+					return err
+				}
+			}
+			if c.csv != "" {
+				if err := addBlob(ctx, tx, c.name, "csv", 0, strings.TrimSpace(c.csv)); err != nil {
+					return err
+				}
+			}
+			for i, obj := range objs {
+				if err := addBlob(ctx, tx, c.name, "bundle", i, obj); err != nil {
+					return err
+				}
+			}
+			if _, err := tx.ExecContext(ctx, `UPDATE operatorbundle SET csv = NULL, bundle = NULL WHERE name = ?`, c.name); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	},
+	Down: func(ctx context.Context, tx *sql.Tx) error {
+		// objects were encoded followed by a newline before they were moved to blobs
+		restore := `
+		UPDATE operatorbundle SET
+		csv = (SELECT blob.content || char(10) FROM operatorbundle_blob INNER JOIN blob ON blob.digest = operatorbundle_blob.blob_digest
+			WHERE operatorbundle_blob.operatorbundle_name = operatorbundle.name AND operatorbundle_blob.field = 'csv'),
+		bundle = (SELECT group_concat(content, '') FROM (
+			SELECT blob.content || char(10) AS content FROM operatorbundle_blob INNER JOIN blob ON blob.digest = operatorbundle_blob.blob_digest
+			WHERE operatorbundle_blob.operatorbundle_name = operatorbundle.name AND operatorbundle_blob.field = 'bundle'
+			ORDER BY operatorbundle_blob.position))
+		WHERE name IN (SELECT operatorbundle_name FROM operatorbundle_blob)
+		`
+		if _, err := tx.ExecContext(ctx, restore); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `DROP TABLE operatorbundle_blob; DROP TABLE blob;`)
+
+		return err
+	},
+}
+
+func addBlob(ctx context.Context, tx *sql.Tx, bundleName, field string, position int, content string) error {
+	dgst := digest.FromString(content).String()
+	if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO blob(digest, content) VALUES (?, ?)`, dgst, content); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, `INSERT OR REPLACE INTO operatorbundle_blob(operatorbundle_name, field, position, blob_digest) VALUES (?, ?, ?, ?)`, bundleName, field, position, dgst)
+	return err
+}
@@ -0,0 +1,71 @@
+package migrations_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/pkg/sqlite/migrations"
+)
+
+func TestBlobs(t *testing.T) {
+	db, migrator, cleanup := CreateTestDbAt(t, migrations.BlobsMigrationKey-1)
+	defer cleanup()
+
+	crd := `{"apiVersion":"apiextensions.k8s.io/v1","kind":"CustomResourceDefinition","metadata":{"name":"etcdclusters.etcd.database.coreos.com"}}` + "\n"
+	csv1 := `{"apiVersion":"operators.coreos.com/v1alpha1","kind":"ClusterServiceVersion","metadata":{"name":"etcdoperator.v0.9.0"}}` + "\n"
+	csv2 := `{"apiVersion":"operators.coreos.com/v1alpha1","kind":"ClusterServiceVersion","metadata":{"name":"etcdoperator.v0.9.2"}}` + "\n"
+
+	insertBundle := "INSERT INTO operatorbundle(name, csv, bundle, bundlepath) VALUES (?, ?, ?, ?)"
+	_, err := db.Exec(insertBundle, "etcdoperator.v0.9.0", csv1, crd+csv1, "quay.io/etcd:v0.9.0")
+	require.NoError(t, err)
+	_, err = db.Exec(insertBundle, "etcdoperator.v0.9.2", csv2, crd+csv2, "quay.io/etcd:v0.9.2")
+	require.NoError(t, err)
+	// bundles added by path have no content
+	_, err = db.Exec(insertBundle, "etcdoperator.v0.9.4", nil, nil, "quay.io/etcd:v0.9.4")
+	require.NoError(t, err)
+
+	// This migration should move the objects into blobs, storing each once
+	require.NoError(t, migrator.Up(context.Background(), migrations.Only(migrations.BlobsMigrationKey)))
+
+	var count int
+	require.NoError(t, db.QueryRow("SELECT count(*) FROM blob").Scan(&count))
+	require.Equal(t, 3, count, "the crd and each csv should be stored once")
+	require.NoError(t, db.QueryRow("SELECT count(*) FROM operatorbundle_blob").Scan(&count))
+	require.Equal(t, 6, count, "each bundle should reference its csv and both of its objects")
+	require.NoError(t, db.QueryRow("SELECT count(*) FROM operatorbundle WHERE csv IS NOT NULL OR bundle IS NOT NULL").Scan(&count))
+	require.Equal(t, 0, count, "the content should be moved out of operatorbundle")
+
+	var first string
+	require.NoError(t, db.QueryRow(`SELECT blob.content FROM operatorbundle_blob INNER JOIN blob ON blob.digest = operatorbundle_blob.blob_digest
+		WHERE operatorbundle_name = ? AND field = 'bundle' AND position = 0`, "etcdoperator.v0.9.2").Scan(&first))
+	require.Equal(t, crd[:len(crd)-1], first)
+
+	// This migration should move the objects back, and drop the blob tables
+	require.NoError(t, migrator.Down(context.Background(), migrations.Only(migrations.BlobsMigrationKey)))
+
+	rows, err := db.Query("SELECT name, csv, bundle FROM operatorbundle ORDER BY name")
+	require.NoError(t, err)
+	defer rows.Close()
+	expected := [][2]sql.NullString{
+		{{String: csv1, Valid: true}, {String: crd + csv1, Valid: true}},
+		{{String: csv2, Valid: true}, {String: crd + csv2, Valid: true}},
+		{{}, {}},
+	}
+	for _, e := range expected {
+		require.True(t, rows.Next())
+		var name string
+		var csv, bundle sql.NullString
+		require.NoError(t, rows.Scan(&name, &csv, &bundle))
+		require.Equal(t, e[0], csv, name)
+		require.Equal(t, e[1], bundle, name)
+	}
+	require.False(t, rows.Next())
+
+	table, err := db.Query("SELECT name FROM sqlite_master WHERE type='table' AND name IN ('blob', 'operatorbundle_blob')")
+	require.NoError(t, err)
+	defer table.Close()
+	require.False(t, table.Next(), "blob tables weren't properly cleaned up on downgrade")
+}
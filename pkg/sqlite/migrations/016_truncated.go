@@ -0,0 +1,40 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+)
+
+const TruncatedMigrationKey = 16
+
+// Register this migration
+func init() {
+	registerMigration(TruncatedMigrationKey, truncatedMigration)
+}
+
+var truncatedMigration = &Migration{
+	Id: TruncatedMigrationKey,
+	Up: func(ctx context.Context, tx *sql.Tx) error {
+		// Like the deprecated table, forego a foreign key constraint so this table can survive operations that drop bundles
+		// channels lists the channels the bundle headed when it was deprecated, comma separated, until it is undeprecated
+		sql := `
+		CREATE TABLE IF NOT EXISTS truncated (
+			operatorbundle_name TEXT PRIMARY KEY,
+			channels TEXT
+		);
+		`
+		if _, err := tx.ExecContext(ctx, sql); err != nil {
+			return err
+		}
+
+		// Every deprecated bundle has had its channels truncated below it, but which channels it headed wasn't recorded
+		_, err := tx.ExecContext(ctx, `INSERT OR REPLACE INTO truncated(operatorbundle_name) SELECT operatorbundle_name FROM deprecated`)
+
+		return err
+	},
+	Down: func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `DROP TABLE truncated`)
+
+		return err
+	},
+}
@@ -0,0 +1,41 @@
+package migrations_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/pkg/sqlite/migrations"
+)
+
+func TestTruncated(t *testing.T) {
+	db, migrator, cleanup := CreateTestDbAt(t, migrations.TruncatedMigrationKey-1)
+	defer cleanup()
+
+	_, err := db.Exec("INSERT INTO deprecated(operatorbundle_name) VALUES (?)", "operator.v1.0.0")
+	require.NoError(t, err)
+
+	// This migration should record every deprecated bundle as truncated, without the channels it headed
+	require.NoError(t, migrator.Up(context.Background(), migrations.Only(migrations.TruncatedMigrationKey)))
+
+	truncated, err := db.Query("SELECT operatorbundle_name, channels FROM truncated")
+	require.NoError(t, err)
+	defer truncated.Close()
+
+	require.True(t, truncated.Next(), "failed to detect truncated bundle")
+	var name, channels sql.NullString
+	require.NoError(t, truncated.Scan(&name, &channels))
+	require.Equal(t, "operator.v1.0.0", name.String)
+	require.False(t, channels.Valid)
+	require.False(t, truncated.Next(), "incorrect number of truncated bundles")
+
+	// This migration should drop the truncated table
+	require.NoError(t, migrator.Down(context.Background(), migrations.Only(migrations.TruncatedMigrationKey)))
+
+	table, err := db.Query("SELECT name FROM sqlite_master WHERE type='table' AND name='truncated'")
+	require.NoError(t, err)
+	defer table.Close()
+	require.False(t, table.Next(), "truncated table wasn't properly cleaned up on downgrade")
+}
@@ -4,11 +4,14 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"sort"
 	"strings"
 
 	_ "github.com/golang-migrate/migrate/v4/source/file" // indirect import required by golang-migrate package
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 
+	"github.com/operator-framework/operator-registry/pkg/lib/tracing"
 	"github.com/operator-framework/operator-registry/pkg/sqlite/migrations"
 )
 
@@ -18,13 +21,22 @@ type Migrator interface {
 	Down(ctx context.Context, migrations migrations.Migrations) error
 }
 
+// VersionedMigrator is a Migrator that can also report the version of its database and migrate it to any version.
+// The Migrator returned by NewSQLLiteMigrator implements it.
+type VersionedMigrator interface {
+	Migrator
+	MigrateTo(ctx context.Context, version int) error
+	Version(ctx context.Context) (int, error)
+	Status(ctx context.Context) ([]MigrationStatus, error)
+}
+
 type SQLLiteMigrator struct {
 	db              *sql.DB
 	migrationsTable string
 	migrations      migrations.MigrationSet
 }
 
-var _ Migrator = &SQLLiteMigrator{}
+var _ VersionedMigrator = &SQLLiteMigrator{}
 
 const (
 	DefaultMigrationsTable = "schema_migrations"
@@ -64,8 +76,69 @@ func (m *SQLLiteMigrator) Migrate(ctx context.Context) error {
 	return m.Up(ctx, m.migrations.From(version+1))
 }
 
+// MigrateTo migrates the database up or down to version, which is NilVersion to revert every migration.
+func (m *SQLLiteMigrator) MigrateTo(ctx context.Context, version int) error {
+	latest := m.latest()
+	if version < NilVersion || version > latest {
+		return fmt.Errorf("invalid version %d, expected a version from %d to %d", version, NilVersion, latest)
+	}
+
+	current, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+	if current > latest {
+		return fmt.Errorf("database version %d is newer than the latest known version %d", current, latest)
+	}
+
+	switch {
+	case version > current:
+		return m.Up(ctx, m.between(current+1, version))
+	case version < current:
+		// revert the newest migration first
+		down := m.between(version+1, current)
+		sort.Sort(sort.Reverse(down))
+		return m.Down(ctx, down)
+	}
+	return nil
+}
+
+// Version returns the version of the database, which is NilVersion if no migration has been applied.
+func (m *SQLLiteMigrator) Version(ctx context.Context) (int, error) {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return NilVersion, err
+	}
+	defer tx.Rollback()
+	return m.version(ctx, tx)
+}
+
+// between returns the migrations from first to last, inclusive, in order.
+func (m *SQLLiteMigrator) between(first, last int) migrations.Migrations {
+	var between migrations.Migrations
+	for _, migration := range m.migrations.From(first) {
+		if migration.Id <= last {
+			between = append(between, migration)
+		}
+	}
+	return between
+}
+
+func (m *SQLLiteMigrator) latest() int {
+	latest := NilVersion
+	for id := range m.migrations {
+		if id > latest {
+			latest = id
+		}
+	}
+	return latest
+}
+
 // Up runs a specific set of migrations.
-func (m *SQLLiteMigrator) Up(ctx context.Context, migrations migrations.Migrations) error {
+func (m *SQLLiteMigrator) Up(ctx context.Context, migrations migrations.Migrations) (err error) {
+	ctx, span := tracing.Start(ctx, "sqlite.MigrateUp", attribute.Int("migrations", len(migrations)))
+	defer func() { tracing.End(span, err) }()
+
 	tx, err := m.db.Begin()
 	if err != nil {
 		return err
@@ -107,7 +180,10 @@ func (m *SQLLiteMigrator) Up(ctx context.Context, migrations migrations.Migratio
 	return commitErr
 }
 
-func (m *SQLLiteMigrator) Down(ctx context.Context, migrations migrations.Migrations) error {
+func (m *SQLLiteMigrator) Down(ctx context.Context, migrations migrations.Migrations) (err error) {
+	ctx, span := tracing.Start(ctx, "sqlite.MigrateDown", attribute.Int("migrations", len(migrations)))
+	defer func() { tracing.End(span, err) }()
+
 	tx, err := m.db.Begin()
 	if err != nil {
 		return err
@@ -431,3 +431,183 @@ func TestSQLLiteMigrator_Migrate(t *testing.T) {
 		})
 	}
 }
+
+// tableMigration creates table on Up and drops it on Down.
+func tableMigration(id int, table string) *migrations.Migration {
+	return &migrations.Migration{
+		Id: id,
+		Up: func(ctx context.Context, tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (name TEXT PRIMARY KEY)", table))
+			return err
+		},
+		Down: func(ctx context.Context, tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, fmt.Sprintf("DROP TABLE %s", table))
+			return err
+		},
+	}
+}
+
+func TestSQLLiteMigrator_MigrateTo(t *testing.T) {
+	db, cleanup := CreateTestDb(t)
+	defer cleanup()
+	m := &SQLLiteMigrator{
+		db:              db,
+		migrationsTable: DefaultMigrationsTable,
+		migrations: migrations.MigrationSet{
+			0: tableMigration(0, "zero"),
+			1: tableMigration(1, "one"),
+			2: tableMigration(2, "two"),
+		},
+	}
+	ctx := context.TODO()
+
+	for _, step := range []struct {
+		to     int
+		tables []string
+	}{
+		{to: 1, tables: []string{"zero", "one"}},
+		{to: 2, tables: []string{"zero", "one", "two"}},
+		{to: 2, tables: []string{"zero", "one", "two"}},
+		{to: 0, tables: []string{"zero"}},
+		{to: NilVersion},
+	} {
+		require.NoError(t, m.MigrateTo(ctx, step.to))
+		version, err := m.Version(ctx)
+		require.NoError(t, err)
+		require.Equal(t, step.to, version)
+
+		tx, err := db.Begin()
+		require.NoError(t, err)
+		for _, table := range []string{"zero", "one", "two"} {
+			exists, err := m.tableExists(tx, table)
+			require.NoError(t, err)
+			require.Equal(t, contains(step.tables, table), exists, "table %s at version %d", table, step.to)
+		}
+		require.NoError(t, tx.Rollback())
+	}
+
+	require.Error(t, m.MigrateTo(ctx, 3))
+	require.Error(t, m.MigrateTo(ctx, -2))
+}
+
+func TestSQLLiteMigrator_Status(t *testing.T) {
+	parent := tableMigration(0, "parent")
+	child := &migrations.Migration{
+		Id: 1,
+		Up: func(ctx context.Context, tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, "CREATE TABLE child (parent_name TEXT, FOREIGN KEY(parent_name) REFERENCES parent(name))")
+			return err
+		},
+		Down: func(ctx context.Context, tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, "DROP TABLE child")
+			return err
+		},
+	}
+	// reverting this orphans any child rows, so it only fails on a database that has them
+	deletesParents := &migrations.Migration{
+		Id: 2,
+		Up: func(context.Context, *sql.Tx) error { return nil },
+		Down: func(ctx context.Context, tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, "DELETE FROM parent")
+			return err
+		},
+	}
+	failing := &migrations.Migration{
+		Id:   3,
+		Up:   func(context.Context, *sql.Tx) error { return nil },
+		Down: func(context.Context, *sql.Tx) error { return fmt.Errorf("irreversible") },
+	}
+	noDown := &migrations.Migration{
+		Id: 3,
+		Up: func(context.Context, *sql.Tx) error { return nil },
+	}
+
+	tests := []struct {
+		name       string
+		migrations migrations.MigrationSet
+		version    int
+		rows       bool
+		// failing lists the migrations whose Down fails or can't be checked
+		failing []int
+	}{
+		{
+			name:       "Reversible",
+			migrations: migrations.MigrationSet{0: parent, 1: child, 2: deletesParents},
+			version:    2,
+		},
+		{
+			name:       "FailsWithData",
+			migrations: migrations.MigrationSet{0: parent, 1: child, 2: deletesParents},
+			version:    2,
+			rows:       true,
+			failing:    []int{0, 1, 2},
+		},
+		{
+			name:       "PendingFails",
+			migrations: migrations.MigrationSet{0: parent, 1: child, 2: deletesParents, 3: failing},
+			version:    1,
+			failing:    []int{0, 1, 2, 3},
+		},
+		{
+			name:       "NoDown",
+			migrations: migrations.MigrationSet{0: parent, 1: child, 2: deletesParents, 3: noDown},
+			version:    3,
+			failing:    []int{0, 1, 2, 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, cleanup := CreateTestDb(t)
+			defer cleanup()
+
+			ctx := context.TODO()
+			m := &SQLLiteMigrator{
+				db:              db,
+				migrationsTable: DefaultMigrationsTable,
+				migrations:      tt.migrations,
+			}
+			require.NoError(t, m.MigrateTo(ctx, tt.version))
+			if tt.rows {
+				_, err := db.Exec("INSERT INTO parent (name) VALUES ('p'); INSERT INTO child (parent_name) VALUES ('p')")
+				require.NoError(t, err)
+			}
+
+			statuses, err := m.Status(ctx)
+			require.NoError(t, err)
+			require.Len(t, statuses, len(tt.migrations))
+			for i, s := range statuses {
+				require.Equal(t, i, s.Id)
+				require.Equal(t, i <= tt.version, s.Applied, "migration %d", i)
+				require.Equal(t, containsInt(tt.failing, i), s.DownErr != nil, "migration %d: %v", i, s.DownErr)
+			}
+
+			// the checks run on a copy, so the database is unchanged
+			version, err := m.Version(ctx)
+			require.NoError(t, err)
+			require.Equal(t, tt.version, version)
+			var children int
+			require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM child").Scan(&children))
+			if tt.rows {
+				require.Equal(t, 1, children)
+			}
+		})
+	}
+}
+
+func containsInt(list []int, i int) bool {
+	for _, l := range list {
+		if l == i {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
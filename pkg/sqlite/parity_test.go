@@ -0,0 +1,331 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/internal/declcfg"
+	"github.com/operator-framework/operator-registry/internal/property"
+	"github.com/operator-framework/operator-registry/pkg/api"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+// parityDatabases are the sqlite databases checked in as testdata, relative to this package.
+var parityDatabases = []string{
+	"../../internal/action/testdata/foo-index-v0.2.0-sqlite/database/index.db",
+	"../lib/indexer/testdata/bundles.db",
+}
+
+// migratedCopy returns a querier for a copy of the database at path in dir, migrated to the latest revision.
+func migratedCopy(t *testing.T, dir, path string) *SQLQuerier {
+	src, err := os.Open(path)
+	require.NoError(t, err)
+	defer src.Close()
+	dbPath := filepath.Join(dir, filepath.Base(path))
+	dst, err := os.Create(dbPath)
+	require.NoError(t, err)
+	_, err = io.Copy(dst, src)
+	require.NoError(t, err)
+	require.NoError(t, dst.Close())
+
+	db, err := Open(dbPath)
+	require.NoError(t, err)
+	migrator, err := NewSQLLiteMigrator(db)
+	require.NoError(t, err)
+	require.NoError(t, migrator.Migrate(context.TODO()))
+	require.NoError(t, db.Close())
+
+	store, err := NewSQLLiteQuerier(dbPath)
+	require.NoError(t, err)
+	return store
+}
+
+// manifestsDB returns a querier for a database loaded from the manifests directory.
+func manifestsDB(t *testing.T, dir string) *SQLQuerier {
+	dbPath := filepath.Join(dir, "manifests.db")
+	db, err := Open(dbPath)
+	require.NoError(t, err)
+	load, err := NewSQLLiteLoader(db)
+	require.NoError(t, err)
+	require.NoError(t, load.Migrate(context.TODO()))
+	require.NoError(t, NewSQLLoaderForDirectory(load, "../../manifests").Populate())
+	require.NoError(t, db.Close())
+
+	store, err := NewSQLLiteQuerier(dbPath)
+	require.NoError(t, err)
+	return store
+}
+
+// TestModelParity checks that serving a sqlite database from the model it converts to gives the same
+// answers to every GRPCQuery method as querying the database directly.
+func TestModelParity(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "parity_test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	t.Run("manifests", func(t *testing.T) {
+		testParity(t, manifestsDB(t, tmpDir))
+	})
+	t.Run("provenance", func(t *testing.T) {
+		dir := filepath.Join(tmpDir, "provenance")
+		require.NoError(t, os.Mkdir(dir, 0755))
+		ctx := context.TODO()
+		store := manifestsDB(t, dir)
+		info, err := store.GetCatalogInfo(ctx)
+		require.NoError(t, err)
+		bundles, err := store.ListBundles(ctx)
+		require.NoError(t, err)
+
+		// provenance records are kept out of the served catalog, so they change neither answers nor the digest
+		db, err := Open(filepath.Join(dir, "manifests.db"))
+		require.NoError(t, err)
+		for _, b := range bundles {
+			_, err := db.Exec(`INSERT INTO provenance(operatorbundle_name, added_at, source_digest, opm_version, operation) VALUES (?, ?, ?, ?, ?)`,
+				b.CsvName, "2021-01-01T00:00:00Z", "sha256:abc", "v1.17.0", "add")
+			require.NoError(t, err)
+		}
+		require.NoError(t, db.Close())
+
+		store, err = NewSQLLiteQuerier(filepath.Join(dir, "manifests.db"))
+		require.NoError(t, err)
+		provenance, err := store.ListBundleProvenance(ctx)
+		require.NoError(t, err)
+		require.NotEmpty(t, provenance)
+		testParity(t, store)
+		withProvenance, err := store.GetCatalogInfo(ctx)
+		require.NoError(t, err)
+		require.Equal(t, info.Digest, withProvenance.Digest)
+	})
+	for i, path := range parityDatabases {
+		dir := filepath.Join(tmpDir, fmt.Sprint(i))
+		require.NoError(t, os.Mkdir(dir, 0755))
+		path := path
+		t.Run(path, func(t *testing.T) {
+			testParity(t, migratedCopy(t, dir, path))
+		})
+	}
+}
+
+// TestCatalogInfoDigest checks that a sqlite database reports the same digest as the declarative
+// config rendered from it, since both serve the same content.
+func TestCatalogInfoDigest(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "parity_test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	ctx := context.TODO()
+	store := migratedCopy(t, tmpDir, "../../internal/action/testdata/foo-index-v0.2.0-sqlite/database/index.db")
+	cfg, err := declcfg.LoadFS(ctx, os.DirFS("../../internal/action/testdata/foo-index-v0.2.0-declcfg"))
+	require.NoError(t, err)
+	expected, err := declcfg.Digest(ctx, *cfg)
+	require.NoError(t, err)
+
+	info, err := store.GetCatalogInfo(ctx)
+	require.NoError(t, err)
+	require.Equal(t, expected.String(), info.Digest)
+	require.Equal(t, 1, info.Packages)
+	require.Equal(t, 2, info.Bundles)
+}
+
+func testParity(t *testing.T, store *SQLQuerier) {
+	m, err := ToModel(context.TODO(), store)
+	require.NoError(t, err)
+	var (
+		db    registry.GRPCQuery = store
+		model registry.GRPCQuery = registry.NewQuerier(m)
+	)
+	ctx := context.TODO()
+	// the bundles sqlite lists, which unlike single bundle lookups carry their replaces and skips
+	listed := map[string]*api.Bundle{}
+
+	// both return the same answer, or both fail
+	same := func(t *testing.T, call func(q registry.GRPCQuery) (interface{}, error)) {
+		t.Helper()
+		expected, expectedErr := call(db)
+		actual, actualErr := call(model)
+		if expectedErr != nil || actualErr != nil {
+			require.Equal(t, expectedErr != nil, actualErr != nil, "sqlite error: %v, model error: %v", expectedErr, actualErr)
+			return
+		}
+		expected = withDerivedProperties(expected, listed)
+		require.Truef(t, cmp.Equal(expected, actual, parityOptions...), cmp.Diff(expected, actual, parityOptions...))
+	}
+
+	packages, err := db.ListPackages(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, packages)
+	same(t, func(q registry.GRPCQuery) (interface{}, error) { return q.ListPackages(ctx) })
+
+	bundles, err := db.ListBundles(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, bundles)
+	for _, b := range bundles {
+		listed[bundleKey(b)] = b
+	}
+	same(t, func(q registry.GRPCQuery) (interface{}, error) { return q.ListBundles(ctx) })
+
+	for _, p := range append(packages, "missing") {
+		p := p
+		same(t, func(q registry.GRPCQuery) (interface{}, error) { return q.GetPackage(ctx, p) })
+	}
+
+	type gvk struct{ group, version, kind string }
+	gvks := map[gvk]struct{}{{group: "missing.example.com", version: "v1", kind: "Missing"}: {}}
+	for _, b := range bundles {
+		b := b
+		same(t, func(q registry.GRPCQuery) (interface{}, error) {
+			return q.GetBundle(ctx, b.PackageName, b.ChannelName, b.CsvName)
+		})
+		same(t, func(q registry.GRPCQuery) (interface{}, error) {
+			return q.GetBundleForChannel(ctx, b.PackageName, b.ChannelName)
+		})
+		same(t, func(q registry.GRPCQuery) (interface{}, error) {
+			return q.GetChannelEntriesThatReplace(ctx, b.CsvName)
+		})
+		same(t, func(q registry.GRPCQuery) (interface{}, error) {
+			return q.GetBundleThatReplaces(ctx, b.CsvName, b.PackageName, b.ChannelName)
+		})
+		for _, api := range b.ProvidedApis {
+			gvks[gvk{group: api.Group, version: api.Version, kind: api.Kind}] = struct{}{}
+		}
+	}
+
+	for g := range gvks {
+		g := g
+		same(t, func(q registry.GRPCQuery) (interface{}, error) {
+			return q.GetChannelEntriesThatProvide(ctx, g.group, g.version, g.kind)
+		})
+		same(t, func(q registry.GRPCQuery) (interface{}, error) {
+			return q.GetLatestChannelEntriesThatProvide(ctx, g.group, g.version, g.kind)
+		})
+		same(t, func(q registry.GRPCQuery) (interface{}, error) {
+			return q.GetBundleThatProvides(ctx, g.group, g.version, g.kind)
+		})
+	}
+
+	// both find the same bundles by their properties
+	var (
+		dbProperties    registry.PropertyQuery = store
+		modelProperties registry.PropertyQuery = registry.NewQuerier(m)
+	)
+	predicates := []registry.PropertyPredicate{
+		{Type: "olm.package"},
+		{Type: "olm.package", Path: "$.packageName", Value: &packages[0]},
+		{Type: "missing"},
+		// properties the model derives from channels and dependencies
+		{Type: "olm.channel"},
+		{Type: "olm.channel", Path: "$.name", Value: &bundles[0].ChannelName},
+		{Type: "olm.skips"},
+		{Type: "olm.skipRange"},
+		{Type: "olm.package.required"},
+		{Type: "olm.gvk.required"},
+	}
+	for g := range gvks {
+		kind := g.kind
+		predicates = append(predicates, registry.PropertyPredicate{Type: "olm.gvk", Path: "$.kind", Value: &kind})
+	}
+	for _, predicate := range predicates {
+		expected, err := dbProperties.ListBundlesWithProperty(ctx, predicate)
+		require.NoError(t, err)
+		actual, err := modelProperties.ListBundlesWithProperty(ctx, predicate)
+		require.NoError(t, err)
+		require.Truef(t, cmp.Equal(expected, actual, parityOptions...), cmp.Diff(expected, actual, parityOptions...))
+	}
+	for _, typ := range []string{"olm.package", "olm.channel"} {
+		matches, err := dbProperties.ListBundlesWithProperty(ctx, registry.PropertyPredicate{Type: typ})
+		require.NoError(t, err)
+		require.NotEmpty(t, matches)
+	}
+}
+
+// parityOptions compare results regardless of the order the stores return them in, or whether an
+// empty list is nil, which encode the same over the wire.
+var parityOptions = []cmp.Option{
+	cmpopts.EquateEmpty(),
+	cmpopts.IgnoreUnexported(api.Bundle{}, api.GroupVersionKind{}, api.Property{}, api.Dependency{}),
+	cmpopts.SortSlices(func(x, y string) bool { return x < y }),
+	cmpopts.SortSlices(func(x, y registry.PackageChannel) bool { return x.Name < y.Name }),
+	cmpopts.SortSlices(func(x, y *registry.ChannelEntry) bool { return channelEntryKey(x) < channelEntryKey(y) }),
+	cmpopts.SortSlices(func(x, y *api.Bundle) bool { return bundleKey(x) < bundleKey(y) }),
+	cmpopts.SortSlices(func(x, y *api.GroupVersionKind) bool { return fmt.Sprint(x) < fmt.Sprint(y) }),
+	cmpopts.SortSlices(func(x, y *api.Property) bool { return x.Type+x.Value < y.Type+y.Value }),
+	cmpopts.SortSlices(func(x, y *api.Dependency) bool { return x.Type+x.Value < y.Type+y.Value }),
+}
+
+// withDerivedProperties adds to the bundles in a sqlite answer the properties the model derives from
+// their channel entries, skips, skip range and dependencies, which a sqlite database doesn't store.
+// Serving declarative configs returns the same properties, so this is the one known difference.
+// Single bundle lookups don't return replaces and skips, so they are taken from the listed bundles.
+func withDerivedProperties(v interface{}, listed map[string]*api.Bundle) interface{} {
+	switch v := v.(type) {
+	case *api.Bundle:
+		addDerivedProperties(v, listed)
+	case []*api.Bundle:
+		for _, b := range v {
+			addDerivedProperties(b, listed)
+		}
+	}
+	return v
+}
+
+func addDerivedProperties(b *api.Bundle, listed map[string]*api.Bundle) {
+	replaces, skips := b.Replaces, b.Skips
+	if l, ok := listed[bundleKey(b)]; ok {
+		replaces, skips = l.Replaces, l.Skips
+	}
+	derived := []property.Property{property.MustBuildChannel(b.ChannelName, replaces)}
+	for _, skip := range skips {
+		derived = append(derived, property.MustBuildSkips(skip))
+	}
+	if b.SkipRange != "" {
+		derived = append(derived, property.MustBuildSkipRange(b.SkipRange))
+	}
+	for _, d := range b.Dependencies {
+		switch d.Type {
+		case property.TypePackage:
+			var v property.Package
+			if err := json.Unmarshal([]byte(d.Value), &v); err == nil {
+				derived = append(derived, property.MustBuildPackageRequired(v.PackageName, v.Version))
+			}
+		case property.TypeGVK:
+			var v property.GVK
+			if err := json.Unmarshal([]byte(d.Value), &v); err == nil {
+				derived = append(derived, property.MustBuildGVKRequired(v.Group, v.Version, v.Kind))
+			}
+		}
+	}
+	for _, gvk := range b.RequiredApis {
+		derived = append(derived, property.MustBuildGVKRequired(gvk.Group, gvk.Version, gvk.Kind))
+	}
+
+	seen := map[string]bool{}
+	for _, p := range b.Properties {
+		seen[p.Type+p.Value] = true
+	}
+	for _, p := range derived {
+		key := p.Type + string(p.Value)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		b.Properties = append(b.Properties, &api.Property{Type: p.Type, Value: string(p.Value)})
+	}
+}
+
+func channelEntryKey(e *registry.ChannelEntry) string {
+	return e.PackageName + "/" + e.ChannelName + "/" + e.BundleName + "/" + e.Replaces
+}
+
+func bundleKey(b *api.Bundle) string {
+	return b.PackageName + "/" + b.ChannelName + "/" + b.CsvName
+}
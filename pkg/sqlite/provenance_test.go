@@ -0,0 +1,72 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/internal/property"
+	"github.com/operator-framework/operator-registry/pkg/image"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+func TestBundleProvenance(t *testing.T) {
+	db, cleanup := CreateTestDb(t)
+	defer cleanup()
+
+	added := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+	store, err := NewSQLLiteLoader(db, WithProvenance(func(path string) (registry.Provenance, bool) {
+		if path != "quay.io/test/prometheus.0.15.0" {
+			return registry.Provenance{}, false
+		}
+		return registry.Provenance{AddedAt: added, SourceDigest: "sha256:abc", OpmVersion: "v1.17.0", Operation: registry.ProvenanceAdd}, true
+	}))
+	require.NoError(t, err)
+	require.NoError(t, store.Migrate(context.TODO()))
+
+	query := NewSQLLiteQuerierFromDb(db)
+
+	graphLoader, err := NewSQLGraphLoaderFromDB(db)
+	require.NoError(t, err)
+
+	refs := map[image.Reference]string{}
+	for _, name := range []string{"prometheus.0.14.0", "prometheus.0.15.0", "prometheus.0.22.2"} {
+		refs[image.SimpleReference("quay.io/test/"+name)] = "../../bundles/" + name
+	}
+	require.NoError(t, registry.NewDirectoryPopulator(store, graphLoader, query, refs, nil, false).Populate(registry.ReplacesMode))
+
+	records, err := query.GetBundleProvenance(context.TODO(), "prometheusoperator.0.14.0")
+	require.NoError(t, err)
+	require.Empty(t, records)
+
+	deprecator, err := NewSQLLiteLoader(db, WithProvenance(func(string) (registry.Provenance, bool) {
+		return registry.Provenance{AddedAt: added.Add(time.Hour), Operation: registry.ProvenanceDeprecate}, true
+	}))
+	require.NoError(t, err)
+	require.NoError(t, deprecator.DeprecateBundle("quay.io/test/prometheus.0.15.0"))
+
+	expected := []registry.Provenance{
+		{BundleName: "prometheusoperator.0.15.0", AddedAt: added, SourceDigest: "sha256:abc", OpmVersion: "v1.17.0", Operation: registry.ProvenanceAdd},
+		{BundleName: "prometheusoperator.0.15.0", AddedAt: added.Add(time.Hour), Operation: registry.ProvenanceDeprecate},
+	}
+	records, err = query.GetBundleProvenance(context.TODO(), "prometheusoperator.0.15.0")
+	require.NoError(t, err)
+	require.Equal(t, expected, records)
+
+	all, err := query.ListBundleProvenance(context.TODO())
+	require.NoError(t, err)
+	require.Equal(t, map[string][]registry.Provenance{"prometheusoperator.0.15.0": expected}, all)
+
+	// provenance is not part of the served catalog
+	m, err := ToModel(context.TODO(), query)
+	require.NoError(t, err)
+	for _, ch := range m["prometheus"].Channels {
+		for _, b := range ch.Bundles {
+			props, err := property.Parse(b.Properties)
+			require.NoError(t, err)
+			require.Empty(t, props.Provenances)
+		}
+	}
+}
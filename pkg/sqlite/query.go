@@ -8,9 +8,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 
+	"github.com/operator-framework/operator-registry/internal/property"
 	"github.com/operator-framework/operator-registry/pkg/api"
 	"github.com/operator-framework/operator-registry/pkg/registry"
 )
@@ -34,10 +37,20 @@ func (a dbQuerierAdapter) QueryContext(ctx context.Context, query string, args .
 }
 
 type SQLQuerier struct {
-	db Querier
+	db       Querier
+	loadTime time.Time
+
+	// hasBlobs caches whether the database stores bundle objects as blobs, once it is known
+	blobsMu  sync.Mutex
+	hasBlobs *bool
+
+	// info caches the description of the catalog, once it is computed
+	infoMu sync.Mutex
+	info   *registry.CatalogInfo
 }
 
 var _ registry.Query = &SQLQuerier{}
+var _ registry.CatalogInfoQuery = &SQLQuerier{}
 
 func NewSQLLiteQuerier(dbFilename string) (*SQLQuerier, error) {
 	db, err := OpenReadOnly(dbFilename)
@@ -45,15 +58,49 @@ func NewSQLLiteQuerier(dbFilename string) (*SQLQuerier, error) {
 		return nil, err
 	}
 
-	return &SQLQuerier{dbQuerierAdapter{db}}, nil
+	return NewSQLLiteQuerierFromDb(db), nil
 }
 
 func NewSQLLiteQuerierFromDb(db *sql.DB) *SQLQuerier {
-	return &SQLQuerier{dbQuerierAdapter{db}}
+	return NewSQLLiteQuerierFromDBQuerier(dbQuerierAdapter{db})
+}
+
+type txQuerierAdapter struct {
+	tx *sql.Tx
+}
+
+func (a txQuerierAdapter) QueryContext(ctx context.Context, query string, args ...interface{}) (RowScanner, error) {
+	return a.tx.QueryContext(ctx, query, args...)
+}
+
+// NewSQLLiteQuerierFromTx returns a querier that reads in a transaction, and so sees what was written in it.
+func NewSQLLiteQuerierFromTx(tx *sql.Tx) *SQLQuerier {
+	return NewSQLLiteQuerierFromDBQuerier(txQuerierAdapter{tx})
 }
 
 func NewSQLLiteQuerierFromDBQuerier(q Querier) *SQLQuerier {
-	return &SQLQuerier{q}
+	return &SQLQuerier{db: q, loadTime: time.Now()}
+}
+
+// GetCatalogInfo returns the digest of the catalog the database serves, which is the digest of the
+// model it converts to, and its number of packages and bundles. The load time is when the querier was
+// created. Like the digest of a model, it is computed once, when first asked for; a failed attempt,
+// such as one whose context was canceled, is retried by the next call.
+func (s *SQLQuerier) GetCatalogInfo(ctx context.Context) (*registry.CatalogInfo, error) {
+	s.infoMu.Lock()
+	defer s.infoMu.Unlock()
+	if s.info == nil {
+		m, err := ToModel(ctx, s)
+		if err != nil {
+			return nil, registry.NewInternalError(registry.Resource{}, "convert database to model: %v", err)
+		}
+		if s.info, err = registry.NewQuerier(m).GetCatalogInfo(ctx); err != nil {
+			return nil, err
+		}
+	}
+	info := *s.info
+	info.LoadTime = s.loadTime
+	return &info, nil
 }
 
 func (s *SQLQuerier) ListTables(ctx context.Context) ([]string, error) {
@@ -114,7 +161,7 @@ func (s *SQLQuerier) GetPackage(ctx context.Context, name string) (*registry.Pac
 	var channelName sql.NullString
 	var bundleName sql.NullString
 	if !rows.Next() {
-		return nil, fmt.Errorf("package %s not found", name)
+		return nil, registry.NewNotFoundError(registry.Resource{Package: name}, "package %s not found", name)
 	}
 	if err := rows.Scan(&pkgName, &defaultChannel, &channelName, &bundleName); err != nil {
 		return nil, err
@@ -220,7 +267,7 @@ func (s *SQLQuerier) GetBundle(ctx context.Context, pkgName, channelName, csvNam
 	defer rows.Close()
 
 	if !rows.Next() {
-		return nil, fmt.Errorf("no entry found for %s %s %s", pkgName, channelName, csvName)
+		return nil, registry.NewNotFoundError(registry.Resource{Package: pkgName, Channel: channelName, Bundle: csvName}, "no entry found for %s %s %s", pkgName, channelName, csvName)
 	}
 	var entryId sql.NullInt64
 	var name sql.NullString
@@ -231,6 +278,13 @@ func (s *SQLQuerier) GetBundle(ctx context.Context, pkgName, channelName, csvNam
 	if err := rows.Scan(&entryId, &name, &bundle, &bundlePath, &version, &skipRange); err != nil {
 		return nil, err
 	}
+	// the rest of the bundle is read with separate queries
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if bundle, err = s.bundleContent(ctx, name.String, bundle); err != nil {
+		return nil, err
+	}
 
 	out := &api.Bundle{}
 	if bundle.Valid && bundle.String != "" {
@@ -253,13 +307,13 @@ func (s *SQLQuerier) GetBundle(ctx context.Context, pkgName, channelName, csvNam
 	out.ProvidedApis = provided
 	out.RequiredApis = required
 
-	dependencies, err := s.GetDependenciesForBundle(ctx, name.String, version.String, bundlePath.String)
+	dependencies, err := s.bundleDependencies(ctx, name.String)
 	if err != nil {
 		return nil, err
 	}
 	out.Dependencies = dependencies
 
-	properties, err := s.GetPropertiesForBundle(ctx, name.String, version.String, bundlePath.String)
+	properties, err := s.bundleProperties(ctx, name.String)
 	if err != nil {
 		return nil, err
 	}
@@ -280,7 +334,7 @@ func (s *SQLQuerier) GetBundleForChannel(ctx context.Context, pkgName string, ch
 	defer rows.Close()
 
 	if !rows.Next() {
-		return nil, fmt.Errorf("no entry found for %s %s", pkgName, channelName)
+		return nil, registry.NewNotFoundError(registry.Resource{Package: pkgName, Channel: channelName}, "no entry found for %s %s", pkgName, channelName)
 	}
 	var entryId sql.NullInt64
 	var name sql.NullString
@@ -291,6 +345,13 @@ func (s *SQLQuerier) GetBundleForChannel(ctx context.Context, pkgName string, ch
 	if err := rows.Scan(&entryId, &name, &bundle, &bundlePath, &version, &skipRange); err != nil {
 		return nil, err
 	}
+	// the rest of the bundle is read with separate queries
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if bundle, err = s.bundleContent(ctx, name.String, bundle); err != nil {
+		return nil, err
+	}
 
 	out := &api.Bundle{}
 	if bundle.Valid && bundle.String != "" {
@@ -313,13 +374,13 @@ func (s *SQLQuerier) GetBundleForChannel(ctx context.Context, pkgName string, ch
 	out.ProvidedApis = provided
 	out.RequiredApis = required
 
-	dependencies, err := s.GetDependenciesForBundle(ctx, name.String, version.String, bundlePath.String)
+	dependencies, err := s.bundleDependencies(ctx, name.String)
 	if err != nil {
 		return nil, err
 	}
 	out.Dependencies = dependencies
 
-	properties, err := s.GetPropertiesForBundle(ctx, name.String, version.String, bundlePath.String)
+	properties, err := s.bundleProperties(ctx, name.String)
 	if err != nil {
 		return nil, err
 	}
@@ -357,7 +418,7 @@ func (s *SQLQuerier) GetChannelEntriesThatReplace(ctx context.Context, name stri
 		})
 	}
 	if len(entries) == 0 {
-		err = fmt.Errorf("no channel entries found that replace %s", name)
+		err = registry.NewNotFoundError(registry.Resource{Bundle: name}, "no channel entries found that replace %s", name)
 		return
 	}
 	return
@@ -376,7 +437,7 @@ func (s *SQLQuerier) GetBundleThatReplaces(ctx context.Context, name, pkgName, c
 	defer rows.Close()
 
 	if !rows.Next() {
-		return nil, fmt.Errorf("no entry found for %s %s", pkgName, channelName)
+		return nil, registry.NewNotFoundError(registry.Resource{Package: pkgName, Channel: channelName, Bundle: name}, "no entry found for %s %s", pkgName, channelName)
 	}
 	var entryId sql.NullInt64
 	var outName sql.NullString
@@ -387,6 +448,13 @@ func (s *SQLQuerier) GetBundleThatReplaces(ctx context.Context, name, pkgName, c
 	if err := rows.Scan(&entryId, &outName, &bundle, &bundlePath, &version, &skipRange); err != nil {
 		return nil, err
 	}
+	// the rest of the bundle is read with separate queries
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if bundle, err = s.bundleContent(ctx, outName.String, bundle); err != nil {
+		return nil, err
+	}
 
 	out := &api.Bundle{}
 	if bundle.Valid && bundle.String != "" {
@@ -409,13 +477,13 @@ func (s *SQLQuerier) GetBundleThatReplaces(ctx context.Context, name, pkgName, c
 	out.ProvidedApis = provided
 	out.RequiredApis = required
 
-	dependencies, err := s.GetDependenciesForBundle(ctx, outName.String, version.String, bundlePath.String)
+	dependencies, err := s.bundleDependencies(ctx, outName.String)
 	if err != nil {
 		return nil, err
 	}
 	out.Dependencies = dependencies
 
-	properties, err := s.GetPropertiesForBundle(ctx, outName.String, version.String, bundlePath.String)
+	properties, err := s.bundleProperties(ctx, outName.String)
 	if err != nil {
 		return nil, err
 	}
@@ -465,7 +533,7 @@ func (s *SQLQuerier) GetChannelEntriesThatProvide(ctx context.Context, group, ve
 		})
 	}
 	if len(entries) == 0 {
-		err = fmt.Errorf("no channel entries found that provide %s %s %s", group, version, kind)
+		err = registry.NewNotFoundError(registry.APIResource(group, version, kind), "no channel entries found that provide %s %s %s", group, version, kind)
 		return
 	}
 	return
@@ -515,13 +583,13 @@ func (s *SQLQuerier) GetLatestChannelEntriesThatProvide(ctx context.Context, gro
 		})
 	}
 	if len(entries) == 0 {
-		err = fmt.Errorf("no channel entries found that provide %s %s %s", group, version, kind)
+		err = registry.NewNotFoundError(registry.APIResource(group, version, kind), "no channel entries found that provide %s %s %s", group, version, kind)
 		return nil, err
 	}
 	return entries, nil
 }
 
-// Get the the latest bundle that provides the API in a default channel, error unless there is ONLY one
+// Get the the latest bundle that provides the API in a default channel, from the first package by name when several do
 func (s *SQLQuerier) GetBundleThatProvides(ctx context.Context, group, apiVersion, kind string) (*api.Bundle, error) {
 	query := `SELECT DISTINCT channel_entry.entry_id, operatorbundle.bundle, operatorbundle.bundlepath, MIN(channel_entry.depth), channel_entry.operatorbundle_name, channel_entry.package_name, channel_entry.channel_name, channel_entry.replaces, operatorbundle.version, operatorbundle.skiprange
           FROM channel_entry
@@ -529,7 +597,8 @@ func (s *SQLQuerier) GetBundleThatProvides(ctx context.Context, group, apiVersio
 		  INNER JOIN properties ON channel_entry.operatorbundle_name = properties.operatorbundle_name
 		  INNER JOIN package ON package.name = channel_entry.package_name
 		  WHERE properties.type = ? AND properties.value = ? AND package.default_channel = channel_entry.channel_name
-		  GROUP BY channel_entry.package_name, channel_entry.channel_name`
+		  GROUP BY channel_entry.package_name, channel_entry.channel_name
+		  ORDER BY channel_entry.package_name`
 
 	value, err := json.Marshal(map[string]string{
 		"group":   group,
@@ -543,7 +612,7 @@ func (s *SQLQuerier) GetBundleThatProvides(ctx context.Context, group, apiVersio
 	defer rows.Close()
 
 	if !rows.Next() {
-		return nil, fmt.Errorf("no entry found that provides %s %s %s", group, apiVersion, kind)
+		return nil, registry.NewNotFoundError(registry.APIResource(group, apiVersion, kind), "no entry found that provides %s %s %s", group, apiVersion, kind)
 	}
 	var entryId sql.NullInt64
 	var bundle sql.NullString
@@ -558,9 +627,18 @@ func (s *SQLQuerier) GetBundleThatProvides(ctx context.Context, group, apiVersio
 	if err := rows.Scan(&entryId, &bundle, &bundlePath, &min_depth, &bundleName, &pkgName, &channelName, &replaces, &version, &skipRange); err != nil {
 		return nil, err
 	}
+	// the rest of the bundle is read with separate queries
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if bundleName.Valid {
+		if bundle, err = s.bundleContent(ctx, bundleName.String, bundle); err != nil {
+			return nil, err
+		}
+	}
 
 	if !bundle.Valid {
-		return nil, fmt.Errorf("no entry found that provides %s %s %s", group, apiVersion, kind)
+		return nil, registry.NewNotFoundError(registry.APIResource(group, apiVersion, kind), "no entry found that provides %s %s %s", group, apiVersion, kind)
 	}
 
 	out := &api.Bundle{}
@@ -584,13 +662,13 @@ func (s *SQLQuerier) GetBundleThatProvides(ctx context.Context, group, apiVersio
 	out.ProvidedApis = provided
 	out.RequiredApis = required
 
-	dependencies, err := s.GetDependenciesForBundle(ctx, bundleName.String, version.String, bundlePath.String)
+	dependencies, err := s.bundleDependencies(ctx, bundleName.String)
 	if err != nil {
 		return nil, err
 	}
 	out.Dependencies = dependencies
 
-	properties, err := s.GetPropertiesForBundle(ctx, bundleName.String, version.String, bundlePath.String)
+	properties, err := s.bundleProperties(ctx, bundleName.String)
 	if err != nil {
 		return nil, err
 	}
@@ -1007,6 +1085,9 @@ func (s *SQLQuerier) ListBundles(ctx context.Context) ([]*api.Bundle, error) {
 
 	var bundles []*api.Bundle
 	bundlesMap := map[string]*api.Bundle{}
+	// Bundle blobs and APIs are read once the rows are closed, so listing never needs a second connection
+	entryIDs := map[*api.Bundle]int64{}
+	withoutContent := map[string][]*api.Bundle{}
 	for rows.Next() {
 		var (
 			entryID     sql.NullInt64
@@ -1056,6 +1137,8 @@ func (s *SQLQuerier) ListBundles(ctx context.Context) ([]*api.Bundle, error) {
 				if err != nil {
 					return nil, err
 				}
+			} else {
+				withoutContent[bundleName.String] = append(withoutContent[bundleName.String], out)
 			}
 
 			out.CsvName = bundleName.String
@@ -1068,17 +1151,7 @@ func (s *SQLQuerier) ListBundles(ctx context.Context) ([]*api.Bundle, error) {
 			if skips.Valid {
 				out.Skips = strings.Split(skips.String, ",")
 			}
-
-			provided, required, err := s.GetApisForEntry(ctx, entryID.Int64)
-			if err != nil {
-				return nil, err
-			}
-			if len(provided) > 0 {
-				out.ProvidedApis = provided
-			}
-			if len(required) > 0 {
-				out.RequiredApis = required
-			}
+			entryIDs[out] = entryID.Int64
 
 			if depType.Valid && depValue.Valid {
 				out.Dependencies = []*api.Dependency{{
@@ -1097,6 +1170,43 @@ func (s *SQLQuerier) ListBundles(ctx context.Context) ([]*api.Bundle, error) {
 			bundlesMap[bundleKey] = out
 		}
 	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+
+	if len(withoutContent) > 0 {
+		contents, err := s.bundleContents(ctx, "")
+		if err != nil {
+			return nil, err
+		}
+		for name, outs := range withoutContent {
+			content, ok := contents[name]
+			if !ok {
+				continue
+			}
+			decoded, err := registry.BundleStringToAPIBundle(content)
+			if err != nil {
+				return nil, err
+			}
+			for _, out := range outs {
+				out.CsvJson = decoded.CsvJson
+				out.Object = decoded.Object
+			}
+		}
+	}
+
+	for out, entryID := range entryIDs {
+		provided, required, err := s.GetApisForEntry(ctx, entryID)
+		if err != nil {
+			return nil, err
+		}
+		if len(provided) > 0 {
+			out.ProvidedApis = provided
+		}
+		if len(required) > 0 {
+			out.RequiredApis = required
+		}
+	}
 
 	for _, v := range bundlesMap {
 		if len(v.Dependencies) > 1 {
@@ -1139,6 +1249,51 @@ func uniqueProps(props []*api.Property) []*api.Property {
 	return list
 }
 
+// bundleDependencies returns the dependencies of the named bundle. Like ListBundles, it matches them on the
+// bundle name alone, since bundle names are unique and rows may have been recorded with an older bundle path.
+func (s *SQLQuerier) bundleDependencies(ctx context.Context, name string) ([]*api.Dependency, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT DISTINCT type, value FROM dependencies WHERE operatorbundle_name=?`, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	dependencies := []*api.Dependency{}
+	for rows.Next() {
+		var typeName, value sql.NullString
+		if err := rows.Scan(&typeName, &value); err != nil {
+			return nil, err
+		}
+		if !typeName.Valid || !value.Valid {
+			continue
+		}
+		dependencies = append(dependencies, &api.Dependency{Type: typeName.String, Value: value.String})
+	}
+	return dependencies, nil
+}
+
+// bundleProperties returns the properties of the named bundle, matched as bundleDependencies matches dependencies.
+func (s *SQLQuerier) bundleProperties(ctx context.Context, name string) ([]*api.Property, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT DISTINCT type, value FROM properties WHERE operatorbundle_name=?`, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	properties := []*api.Property{}
+	for rows.Next() {
+		var typeName, value sql.NullString
+		if err := rows.Scan(&typeName, &value); err != nil {
+			return nil, err
+		}
+		if !typeName.Valid || !value.Valid {
+			continue
+		}
+		properties = append(properties, &api.Property{Type: typeName.String, Value: value.String})
+	}
+	return properties, nil
+}
+
 func (s *SQLQuerier) GetDependenciesForBundle(ctx context.Context, name, version, path string) (dependencies []*api.Dependency, err error) {
 	depQuery := `SELECT DISTINCT type, value FROM dependencies
 	WHERE operatorbundle_name=?
@@ -1203,6 +1358,130 @@ func (s *SQLQuerier) GetPropertiesForBundle(ctx context.Context, name, version,
 	return
 }
 
+// derivedPropertyTypes are the types of the properties that aren't stored in the properties table, but derived from the
+// channels, dependencies and objects of bundles when they are converted to the model.
+var derivedPropertyTypes = map[string]struct{}{
+	property.TypeChannel:         {},
+	property.TypeSkips:           {},
+	property.TypeSkipRange:       {},
+	property.TypePackageRequired: {},
+	property.TypeGVKRequired:     {},
+	property.TypeBundleObject:    {},
+}
+
+// ListBundlesWithProperty returns the bundles with properties matching a predicate. Bundles that aren't in any channel
+// are listed without a package, unless the properties are derived from their channels, in which case they aren't
+// listed.
+func (s *SQLQuerier) ListBundlesWithProperty(ctx context.Context, predicate registry.PropertyPredicate) ([]*registry.BundlePropertyMatch, error) {
+	if err := predicate.Validate(); err != nil {
+		return nil, err
+	}
+	if _, ok := derivedPropertyTypes[predicate.Type]; ok {
+		return s.listBundlesWithDerivedProperty(ctx, predicate)
+	}
+
+	propQuery := `SELECT DISTINCT channel_entry.package_name, operatorbundle.name, operatorbundle.version, operatorbundle.bundlepath, properties.value
+				 FROM properties
+				 INNER JOIN operatorbundle ON operatorbundle.name = properties.operatorbundle_name
+				 LEFT OUTER JOIN channel_entry ON channel_entry.operatorbundle_name = properties.operatorbundle_name
+				 WHERE properties.type=?`
+
+	rows, err := s.db.QueryContext(ctx, propQuery, predicate.Type)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var matches []*registry.BundlePropertyMatch
+	byBundle := map[registry.BundleKey]*registry.BundlePropertyMatch{}
+	for rows.Next() {
+		var pkgName, bundleName, version, bundlePath, value sql.NullString
+		if err := rows.Scan(&pkgName, &bundleName, &version, &bundlePath, &value); err != nil {
+			return nil, err
+		}
+		ok, err := predicate.Match(value.String)
+		if err != nil {
+			return nil, fmt.Errorf("bundle %s: %v", bundleName.String, err)
+		}
+		if !ok {
+			continue
+		}
+
+		key := registry.BundleKey{CsvName: bundleName.String, Version: version.String, BundlePath: bundlePath.String}
+		match, ok := byBundle[key]
+		if !ok {
+			match = &registry.BundlePropertyMatch{
+				PackageName: pkgName.String,
+				CsvName:     bundleName.String,
+				Version:     version.String,
+				BundlePath:  bundlePath.String,
+			}
+			byBundle[key] = match
+			matches = append(matches, match)
+		}
+		match.Properties = append(match.Properties, &api.Property{Type: predicate.Type, Value: value.String})
+	}
+
+	registry.SortBundlePropertyMatches(matches)
+	return matches, nil
+}
+
+// listBundlesWithDerivedProperty returns the bundles with derived properties matching a predicate, deriving them from
+// the listed bundles the way the model does.
+func (s *SQLQuerier) listBundlesWithDerivedProperty(ctx context.Context, predicate registry.PropertyPredicate) ([]*registry.BundlePropertyMatch, error) {
+	bundles, err := s.ListBundles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []*registry.BundlePropertyMatch
+	byBundle := map[registry.BundleKey]*registry.BundlePropertyMatch{}
+	// bundles are listed once per channel, and properties derived from their dependencies and objects are the same
+	// in each, so each property is added once
+	seen := map[registry.BundleKey]map[string]struct{}{}
+	for _, b := range bundles {
+		mb, err := api.ConvertAPIBundleToModelBundle(b)
+		if err != nil {
+			return nil, fmt.Errorf("convert bundle %q: %v", b.CsvName, err)
+		}
+
+		key := registry.BundleKey{CsvName: b.CsvName, Version: b.Version, BundlePath: b.BundlePath}
+		for _, p := range mb.Properties {
+			if p.Type != predicate.Type {
+				continue
+			}
+			if _, ok := seen[key][string(p.Value)]; ok {
+				continue
+			}
+			ok, err := predicate.Match(string(p.Value))
+			if err != nil {
+				return nil, fmt.Errorf("bundle %s: %v", b.CsvName, err)
+			}
+			if !ok {
+				continue
+			}
+
+			match, ok := byBundle[key]
+			if !ok {
+				match = &registry.BundlePropertyMatch{
+					PackageName: b.PackageName,
+					CsvName:     b.CsvName,
+					Version:     b.Version,
+					BundlePath:  b.BundlePath,
+				}
+				byBundle[key] = match
+				seen[key] = map[string]struct{}{}
+				matches = append(matches, match)
+			}
+			seen[key][string(p.Value)] = struct{}{}
+			match.Properties = append(match.Properties, &api.Property{Type: p.Type, Value: string(p.Value)})
+		}
+	}
+
+	registry.SortBundlePropertyMatches(matches)
+	return matches, nil
+}
+
 func (s *SQLQuerier) GetBundlePathIfExists(ctx context.Context, bundleName string) (bundlePath string, err error) {
 	getBundlePathQuery := `
 	  SELECT bundlepath
@@ -1249,7 +1528,8 @@ func (s *SQLQuerier) ListRegistryBundles(ctx context.Context) ([]*registry.Bundl
 		err  error
 		rows RowScanner
 	)
-	if pkg, ok := registry.PackageFromContext(ctx); ok {
+	pkg, filtered := registry.PackageFromContext(ctx)
+	if filtered {
 		listBundlesQuery += " WHERE channel_entry.package_name=?"
 		rows, err = s.db.QueryContext(ctx, listBundlesQuery, pkg)
 	} else {
@@ -1260,28 +1540,55 @@ func (s *SQLQuerier) ListRegistryBundles(ctx context.Context) ([]*registry.Bundl
 	}
 	defer rows.Close()
 
-	var bundles []*registry.Bundle
+	type bundleRow struct {
+		bundleName    sql.NullString
+		bundleVersion sql.NullString
+		bundle        sql.NullString
+		packageName   sql.NullString
+	}
+	var (
+		bundleRows     []bundleRow
+		withoutContent bool
+	)
 	for rows.Next() {
-		var (
-			bundleName    sql.NullString
-			bundleVersion sql.NullString
-			bundle        sql.NullString
-			packageName   sql.NullString
-		)
-		if err := rows.Scan(&bundleName, &bundleVersion, &bundle, &packageName); err != nil {
+		var row bundleRow
+		if err := rows.Scan(&row.bundleName, &row.bundleVersion, &row.bundle, &row.packageName); err != nil {
 			return nil, err
 		}
 
 		switch {
-		case !bundleName.Valid:
+		case !row.bundleName.Valid:
 			return nil, fmt.Errorf("bundle name column corrupted")
-		case !bundleVersion.Valid:
+		case !row.bundleVersion.Valid:
 			// Version field is currently nullable
-		case !bundle.Valid:
+		case !row.bundle.Valid:
 			// Bundle field is currently nullable
-		case !packageName.Valid:
+		case !row.packageName.Valid:
 			return nil, fmt.Errorf("package name column corrupted")
 		}
+		if !row.bundle.Valid || row.bundle.String == "" {
+			withoutContent = true
+		}
+		bundleRows = append(bundleRows, row)
+	}
+	// Channels and blobs are read once the rows are closed, so listing never needs a second connection
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+
+	var contents map[string]string
+	if withoutContent {
+		if contents, err = s.bundleContents(ctx, pkg); err != nil {
+			return nil, err
+		}
+	}
+
+	var bundles []*registry.Bundle
+	for _, row := range bundleRows {
+		bundleName, bundleVersion, bundle, packageName := row.bundleName, row.bundleVersion, row.bundle, row.packageName
+		if content, ok := contents[bundleName.String]; ok && (!bundle.Valid || bundle.String == "") {
+			bundle = sql.NullString{String: content, Valid: true}
+		}
 
 		// Allow the channel_entry table to be authoritative
 		channels, err := s.listBundleChannels(ctx, bundleName.String)
@@ -1334,3 +1641,186 @@ func (s *SQLQuerier) listBundleChannels(ctx context.Context, bundleName string)
 
 	return channels, nil
 }
+
+// GetBundleProvenance returns the provenance records of a bundle, oldest first. Databases that predate
+// provenance records have none.
+func (s *SQLQuerier) GetBundleProvenance(ctx context.Context, bundleName string) ([]registry.Provenance, error) {
+	provenance, err := s.listProvenance(ctx, `WHERE operatorbundle_name = ?`, bundleName)
+	if err != nil {
+		return nil, err
+	}
+	return provenance[bundleName], nil
+}
+
+// ListBundleProvenance returns the provenance records of every bundle that has any, by bundle name, oldest first.
+func (s *SQLQuerier) ListBundleProvenance(ctx context.Context) (map[string][]registry.Provenance, error) {
+	return s.listProvenance(ctx, "")
+}
+
+func (s *SQLQuerier) listProvenance(ctx context.Context, where string, args ...interface{}) (map[string][]registry.Provenance, error) {
+	exists, err := s.tableExists(ctx, "provenance")
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	listProvenanceQuery := `
+	SELECT operatorbundle_name, added_at, source_digest, opm_version, operation
+	FROM provenance ` + where + `
+	ORDER BY operatorbundle_name, added_at, rowid`
+
+	rows, err := s.db.QueryContext(ctx, listProvenanceQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := map[string][]registry.Provenance{}
+	for rows.Next() {
+		var (
+			bundleName   sql.NullString
+			addedAt      sql.NullString
+			sourceDigest sql.NullString
+			opmVersion   sql.NullString
+			operation    sql.NullString
+		)
+		if err := rows.Scan(&bundleName, &addedAt, &sourceDigest, &opmVersion, &operation); err != nil {
+			return nil, err
+		}
+		if !bundleName.Valid || !addedAt.Valid || !operation.Valid {
+			return nil, fmt.Errorf("provenance columns corrupt for bundle %s", bundleName.String)
+		}
+		at, err := time.Parse(time.RFC3339, addedAt.String)
+		if err != nil {
+			return nil, fmt.Errorf("provenance time corrupt for bundle %s: %v", bundleName.String, err)
+		}
+
+		records[bundleName.String] = append(records[bundleName.String], registry.Provenance{
+			BundleName:   bundleName.String,
+			AddedAt:      at,
+			SourceDigest: sourceDigest.String,
+			OpmVersion:   opmVersion.String,
+			Operation:    registry.ProvenanceOperation(operation.String),
+		})
+	}
+
+	return records, nil
+}
+
+// bundleContent returns the objects of a bundle, concatenated as they were when it was added. Databases that predate
+// blobs store them inline, in the bundle column, and newer ones store them as blobs, which are reassembled.
+func (s *SQLQuerier) bundleContent(ctx context.Context, bundleName string, inline sql.NullString) (sql.NullString, error) {
+	if inline.Valid && inline.String != "" {
+		return inline, nil
+	}
+	hasBlobs, err := s.blobsTableExists(ctx)
+	if err != nil || !hasBlobs {
+		return inline, err
+	}
+
+	getBlobsQuery := `
+	SELECT blob.content
+	FROM operatorbundle_blob
+	INNER JOIN blob ON blob.digest = operatorbundle_blob.blob_digest
+	WHERE operatorbundle_blob.operatorbundle_name = ? AND operatorbundle_blob.field = 'bundle'
+	ORDER BY operatorbundle_blob.position`
+
+	rows, err := s.db.QueryContext(ctx, getBlobsQuery, bundleName)
+	if err != nil {
+		return inline, err
+	}
+	defer rows.Close()
+
+	var content strings.Builder
+	for rows.Next() {
+		var obj sql.NullString
+		if err := rows.Scan(&obj); err != nil {
+			return inline, err
+		}
+		// objects were encoded followed by a newline
+		content.WriteString(obj.String)
+		content.WriteString("\n")
+	}
+	if content.Len() == 0 {
+		return inline, nil
+	}
+
+	return sql.NullString{String: content.String(), Valid: true}, nil
+}
+
+// bundleContents returns the objects of every bundle stored as blobs, or of every bundle of pkg if it isn't empty, by
+// bundle name, reassembled as bundleContent does.
+func (s *SQLQuerier) bundleContents(ctx context.Context, pkg string) (map[string]string, error) {
+	contents := map[string]string{}
+	hasBlobs, err := s.blobsTableExists(ctx)
+	if err != nil || !hasBlobs {
+		return contents, err
+	}
+
+	getBlobsQuery := `
+	SELECT operatorbundle_blob.operatorbundle_name, blob.content
+	FROM operatorbundle_blob
+	INNER JOIN blob ON blob.digest = operatorbundle_blob.blob_digest
+	WHERE operatorbundle_blob.field = 'bundle'`
+	var args []interface{}
+	if pkg != "" {
+		getBlobsQuery += ` AND operatorbundle_blob.operatorbundle_name IN (SELECT operatorbundle_name FROM channel_entry WHERE package_name = ?)`
+		args = append(args, pkg)
+	}
+	getBlobsQuery += ` ORDER BY operatorbundle_blob.operatorbundle_name, operatorbundle_blob.position`
+
+	rows, err := s.db.QueryContext(ctx, getBlobsQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var (
+		name    string
+		content strings.Builder
+	)
+	for rows.Next() {
+		var bundleName, obj sql.NullString
+		if err := rows.Scan(&bundleName, &obj); err != nil {
+			return nil, err
+		}
+		if bundleName.String != name && content.Len() > 0 {
+			contents[name] = content.String()
+			content.Reset()
+		}
+		name = bundleName.String
+		// objects were encoded followed by a newline
+		content.WriteString(obj.String)
+		content.WriteString("\n")
+	}
+	if content.Len() > 0 {
+		contents[name] = content.String()
+	}
+
+	return contents, nil
+}
+
+// blobsTableExists returns whether the database stores bundle objects as blobs. The answer is cached once known,
+// since the querier is only used on databases that are already migrated.
+func (s *SQLQuerier) blobsTableExists(ctx context.Context) (bool, error) {
+	s.blobsMu.Lock()
+	defer s.blobsMu.Unlock()
+	if s.hasBlobs != nil {
+		return *s.hasBlobs, nil
+	}
+	exists, err := s.tableExists(ctx, "operatorbundle_blob")
+	if err != nil {
+		return false, err
+	}
+	s.hasBlobs = &exists
+	return exists, nil
+}
+
+func (s *SQLQuerier) tableExists(ctx context.Context, table string) (bool, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT name FROM sqlite_master WHERE type='table' AND name=?`, table)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	return rows.Next(), nil
+}
@@ -47,6 +47,40 @@ func (d *PackageRemover) Remove() error {
 	return utilerrors.NewAggregate(errs)
 }
 
+// BundleRemover removes bundles from the database, leaving the rest of their packages in place
+type BundleRemover struct {
+	store   registry.Load
+	bundles []string
+}
+
+var _ SQLRemover = &BundleRemover{}
+
+func NewSQLRemoverForBundles(store registry.Load, bundles []string) *BundleRemover {
+	return &BundleRemover{
+		store:   store,
+		bundles: bundles,
+	}
+}
+
+func (d *BundleRemover) Remove() error {
+	log := logrus.WithField("bundles", d.bundles)
+	log.Info("deleting bundles")
+
+	editor, ok := d.store.(registry.Editor)
+	if !ok {
+		return fmt.Errorf("loader %T can't remove bundles", d.store)
+	}
+
+	var errs []error
+	for _, bundlePath := range sanitizePackageList(d.bundles) {
+		if err := editor.RemoveBundle(bundlePath); err != nil {
+			errs = append(errs, fmt.Errorf("error removing bundle %s: %s", bundlePath, err))
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
 // sanitizePackageList sanitizes the set of package(s) specified. It removes
 // duplicates and ignores empty string.
 func sanitizePackageList(in []string) []string {
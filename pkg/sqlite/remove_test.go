@@ -2,6 +2,7 @@ package sqlite
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -98,3 +99,143 @@ func TestRemover(t *testing.T) {
 	require.True(t, rows.Next())
 	require.NoError(t, rows.Close())
 }
+
+func TestBundleRemover(t *testing.T) {
+	db, cleanup := CreateTestDb(t)
+	defer cleanup()
+	store, err := NewSQLLiteLoader(db)
+	require.NoError(t, err)
+	require.NoError(t, store.Migrate(context.TODO()))
+
+	query := NewSQLLiteQuerierFromDb(db)
+
+	graphLoader, err := NewSQLGraphLoaderFromDB(db)
+	require.NoError(t, err)
+
+	// preview: 0.22.2 -> 0.15.0 -> 0.14.0, stable: 0.15.0 -> 0.14.0
+	for _, name := range []string{"prometheus.0.14.0", "prometheus.0.15.0", "prometheus.0.22.2"} {
+		require.NoError(t, registry.NewDirectoryPopulator(
+			store,
+			graphLoader,
+			query,
+			map[image.Reference]string{
+				image.SimpleReference("quay.io/test/" + name): "../../bundles/" + name,
+			},
+			make(map[string]map[image.Reference]string, 0), false).Populate(registry.ReplacesMode))
+	}
+
+	channelHeads := func() map[string]string {
+		pkg, err := query.GetPackage(context.TODO(), "prometheus")
+		require.NoError(t, err)
+		heads := map[string]string{}
+		for _, c := range pkg.Channels {
+			heads[c.Name] = c.CurrentCSVName
+		}
+		return heads
+	}
+	replacement := func(name, channel string) string {
+		bundle, err := query.GetBundleThatReplaces(context.TODO(), name, "prometheus", channel)
+		require.NoError(t, err)
+		return bundle.CsvName
+	}
+	requireConsistent := func() {
+		results, err := CheckDB(context.TODO(), db, false)
+		require.NoError(t, err)
+		for _, result := range results {
+			require.False(t, result.Failed(), "check %s: %v", result.Name, result.Violations)
+		}
+	}
+	countRows := func(table, name string) int {
+		var count int
+		require.NoError(t, db.QueryRow("SELECT count(*) FROM "+table+" WHERE operatorbundle_name = ?", name).Scan(&count))
+		return count
+	}
+
+	// removing a bundle in the middle of a channel links the bundles around it
+	require.NoError(t, NewSQLRemoverForBundles(store, []string{"quay.io/test/prometheus.0.15.0"}).Remove())
+	require.Equal(t, map[string]string{"preview": "prometheusoperator.0.22.2", "stable": "prometheusoperator.0.14.0"}, channelHeads())
+	require.Equal(t, "prometheusoperator.0.22.2", replacement("prometheusoperator.0.14.0", "preview"))
+	// installs of the removed bundle still have an upgrade
+	require.Equal(t, "prometheusoperator.0.22.2", replacement("prometheusoperator.0.15.0", "preview"))
+	_, err = query.GetBundle(context.TODO(), "prometheus", "preview", "prometheusoperator.0.15.0")
+	require.Error(t, err)
+	var bundles int
+	require.NoError(t, db.QueryRow("SELECT count(*) FROM operatorbundle WHERE name = ?", "prometheusoperator.0.15.0").Scan(&bundles))
+	require.Zero(t, bundles)
+	for _, table := range []string{"api_provider", "properties", "related_image"} {
+		require.Zero(t, countRows(table, "prometheusoperator.0.15.0"), table)
+	}
+	requireConsistent()
+
+	// removing the last bundle of a channel removes the channel
+	require.NoError(t, store.(registry.Editor).RemoveBundle("quay.io/test/prometheus.0.14.0"))
+	require.Equal(t, map[string]string{"preview": "prometheusoperator.0.22.2"}, channelHeads())
+	require.Equal(t, "prometheusoperator.0.22.2", replacement("prometheusoperator.0.14.0", "preview"))
+	requireConsistent()
+
+	// unless the channel is the default channel
+	err = store.(registry.Editor).RemoveBundle("quay.io/test/prometheus.0.22.2")
+	require.True(t, errors.Is(err, registry.ErrRemovingDefaultChannelDuringBundleRemoval), err)
+	require.Equal(t, map[string]string{"preview": "prometheusoperator.0.22.2"}, channelHeads())
+
+	err = store.(registry.Editor).RemoveBundle("quay.io/test/missing")
+	require.True(t, errors.Is(err, registry.ErrBundleImageNotInDatabase), err)
+}
+
+func TestBundleRemoverSkips(t *testing.T) {
+	db, cleanup := CreateTestDb(t)
+	defer cleanup()
+	store, err := NewSQLLiteLoader(db)
+	require.NoError(t, err)
+	require.NoError(t, store.Migrate(context.TODO()))
+
+	query := NewSQLLiteQuerierFromDb(db)
+
+	graphLoader, err := NewSQLGraphLoaderFromDB(db)
+	require.NoError(t, err)
+
+	for _, name := range []string{"prometheus.0.14.0", "prometheus.0.15.0", "prometheus.0.22.2"} {
+		require.NoError(t, registry.NewDirectoryPopulator(
+			store,
+			graphLoader,
+			query,
+			map[image.Reference]string{
+				image.SimpleReference("quay.io/test/" + name): "../../bundles/" + name,
+			},
+			make(map[string]map[image.Reference]string, 0), false).Populate(registry.ReplacesMode))
+	}
+
+	// preview: 0.22.2 skips 0.15.0 -> 0.14.0, without replacing anything
+	loader := store.(*sqlLoader)
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	_, err = tx.Exec(`UPDATE operatorbundle SET replaces = NULL, skips = ? WHERE name = ?`, "prometheusoperator.0.15.0", "prometheusoperator.0.22.2")
+	require.NoError(t, err)
+	manifest, err := loader.getPackageManifest(tx, "prometheus")
+	require.NoError(t, err)
+	require.NoError(t, loader.rebuildPackage(tx, *manifest))
+	require.NoError(t, tx.Commit())
+
+	// removing the head leaves the bundles it skipped in the channel, headed by the newest of them
+	require.NoError(t, store.(registry.Editor).RemoveBundle("quay.io/test/prometheus.0.22.2"))
+	pkg, err := query.GetPackage(context.TODO(), "prometheus")
+	require.NoError(t, err)
+	heads := map[string]string{}
+	for _, c := range pkg.Channels {
+		heads[c.Name] = c.CurrentCSVName
+	}
+	require.Equal(t, map[string]string{"preview": "prometheusoperator.0.15.0", "stable": "prometheusoperator.0.15.0"}, heads)
+	for _, name := range []string{"prometheusoperator.0.15.0", "prometheusoperator.0.14.0"} {
+		_, err := query.GetBundle(context.TODO(), "prometheus", "preview", name)
+		require.NoError(t, err, name)
+	}
+	bundle, err := query.GetBundleThatReplaces(context.TODO(), "prometheusoperator.0.14.0", "prometheus", "preview")
+	require.NoError(t, err)
+	require.Equal(t, "prometheusoperator.0.15.0", bundle.CsvName)
+
+	results, err := CheckDB(context.TODO(), db, false)
+	require.NoError(t, err)
+	for _, result := range results {
+		require.False(t, result.Failed(), "check %s: %v", result.Name, result.Violations)
+	}
+}
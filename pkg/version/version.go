@@ -0,0 +1,12 @@
+// Package version holds the version information of the opm build, set during build via -ldflags.
+package version
+
+var (
+	// OpmVersion is the constant representing the version of the opm binary
+	OpmVersion = "unknown"
+	// GitCommit is a constant representing the source version that
+	// generated this build. It should be set during build via -ldflags.
+	GitCommit string
+	// BuildDate in ISO8601 format, output of $(date -u +'%Y-%m-%dT%H:%M:%SZ')
+	BuildDate string
+)